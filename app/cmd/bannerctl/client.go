@@ -0,0 +1,137 @@
+package main
+
+import (
+	bannermodels "banner/internal/models/banner"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const (
+	tokenHeaderName   = "token"
+	contentTypeHeader = "Content-Type"
+	contentTypeJSON   = "application/json"
+	contentTypeNDJSON = "application/x-ndjson"
+
+	requestTimeout = 30 * time.Second
+)
+
+// error response of banner API
+type apiError struct {
+	Status int
+	Code   string
+	Msg    string
+}
+
+func (e *apiError) Error() string {
+	msg := fmt.Sprintf("request failed: %d %s", e.Status, http.StatusText(e.Status))
+	if e.Code != "" {
+		msg += ": " + e.Code
+	}
+	if e.Msg != "" {
+		msg += ": " + e.Msg
+	}
+	return msg
+}
+
+type client struct {
+	baseURL string
+	token   string
+
+	http *http.Client
+	// without timeout for export and import
+	streamHTTP *http.Client
+}
+
+func newClient(baseURL string, token string) *client {
+	return &client{
+		baseURL: strings.TrimRight(baseURL, "/"),
+		token:   token,
+
+		http:       &http.Client{Timeout: requestTimeout},
+		streamHTTP: &http.Client{},
+	}
+}
+
+// send request and return response with any status, caller must close body,
+// streaming requests are not limited by requestTimeout
+func (c *client) send(method string, path string, query url.Values, contentType string, body io.Reader, stream bool) (*http.Response, error) {
+	u := c.baseURL + path
+	if len(query) != 0 {
+		u += "?" + query.Encode()
+	}
+
+	req, err := http.NewRequest(method, u, body)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set(tokenHeaderName, c.token)
+	if body != nil {
+		req.Header.Set(contentTypeHeader, contentType)
+	}
+
+	httpClient := c.http
+	if stream {
+		httpClient = c.streamHTTP
+	}
+
+	return httpClient.Do(req)
+}
+
+// send request and return response with 2xx status, caller must close body
+func (c *client) do(method string, path string, query url.Values, contentType string, body io.Reader, stream bool) (*http.Response, error) {
+	resp, err := c.send(method, path, query, contentType, body, stream)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		defer resp.Body.Close()
+		return nil, responseError(resp)
+	}
+
+	return resp, nil
+}
+
+// send request and return whole body of successful response
+func (c *client) doBytes(method string, path string, query url.Values, body io.Reader) ([]byte, error) {
+	resp, err := c.do(method, path, query, contentTypeJSON, body, false)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	return io.ReadAll(resp.Body)
+}
+
+func responseError(resp *http.Response) error {
+	body, _ := io.ReadAll(resp.Body)
+
+	var envelope struct {
+		Error struct {
+			Code    string `json:"code"`
+			Message string `json:"message"`
+			Details []struct {
+				Field   string `json:"field"`
+				Message string `json:"message"`
+			} `json:"details"`
+			Conflicts []bannermodels.Slot `json:"conflicts"`
+		} `json:"error"`
+	}
+	if json.Unmarshal(body, &envelope) == nil && envelope.Error.Code != "" {
+		msg := envelope.Error.Message
+		for _, detail := range envelope.Error.Details {
+			msg += fmt.Sprintf("; %s: %s", detail.Field, detail.Message)
+		}
+		for _, slot := range envelope.Error.Conflicts {
+			msg += fmt.Sprintf("; tag %d of feature %d is held by banner %d", slot.TagID, slot.FeatureID, slot.BannerID)
+		}
+		return &apiError{Status: resp.StatusCode, Code: envelope.Error.Code, Msg: msg}
+	}
+
+	return &apiError{Status: resp.StatusCode, Msg: strings.TrimSpace(string(body))}
+}
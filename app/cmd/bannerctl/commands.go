@@ -0,0 +1,745 @@
+package main
+
+import (
+	bannermodels "banner/internal/models/banner"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// stdin or stdout instead of file
+const stdioPath = "-"
+
+type bannerIDMsg struct {
+	ID int `json:"banner_id"`
+}
+
+// comma separated list of ints, e.g. 1,2,3
+type intsFlag []int
+
+func (f *intsFlag) String() string {
+	strs := make([]string, len(*f))
+	for i, v := range *f {
+		strs[i] = strconv.Itoa(v)
+	}
+	return strings.Join(strs, ",")
+}
+
+func (f *intsFlag) Set(s string) error {
+	*f = intsFlag{}
+	if s == "" {
+		return nil
+	}
+
+	for _, part := range strings.Split(s, ",") {
+		v, err := strconv.Atoi(strings.TrimSpace(part))
+		if err != nil {
+			return fmt.Errorf("%q is not an integer", part)
+		}
+		*f = append(*f, v)
+	}
+	return nil
+}
+
+// repeated flag
+type stringsFlag []string
+
+func (f *stringsFlag) String() string {
+	return strings.Join(*f, " ")
+}
+
+func (f *stringsFlag) Set(s string) error {
+	*f = append(*f, s)
+	return nil
+}
+
+func newFlagSet(name string, usageArgs string) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: bannerctl %s %s\n\nFlags:\n", name, usageArgs)
+		fs.PrintDefaults()
+	}
+	return fs
+}
+
+func parseFlags(fs *flag.FlagSet, args []string) error {
+	err := fs.Parse(args)
+	if err != nil {
+		return errUsage
+	}
+	if fs.NArg() != 0 {
+		fmt.Fprintf(fs.Output(), "unexpected arguments: %v\n", fs.Args())
+		fs.Usage()
+		return errUsage
+	}
+	return nil
+}
+
+// banner id may be given before or after flags
+func parseIDAndFlags(fs *flag.FlagSet, args []string) (int, error) {
+	var idStr string
+	if len(args) != 0 && !strings.HasPrefix(args[0], "-") {
+		idStr, args = args[0], args[1:]
+	}
+
+	err := fs.Parse(args)
+	if err != nil {
+		return 0, errUsage
+	}
+
+	rest := fs.Args()
+	if idStr == "" && len(rest) != 0 {
+		idStr, rest = rest[0], rest[1:]
+	}
+	if idStr == "" || len(rest) != 0 {
+		fs.Usage()
+		return 0, errUsage
+	}
+
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		return 0, fmt.Errorf("banner id must be an integer, got %q", idStr)
+	}
+	return id, nil
+}
+
+func isFlagSet(fs *flag.FlagSet, name string) bool {
+	set := false
+	fs.Visit(func(f *flag.Flag) {
+		if f.Name == name {
+			set = true
+		}
+	})
+	return set
+}
+
+// read content object from file or stdin
+func (a *app) readContent(path string) (map[string]interface{}, error) {
+	var data []byte
+	var err error
+	if path == stdioPath {
+		data, err = io.ReadAll(a.stdin)
+	} else {
+		data, err = os.ReadFile(path)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var content map[string]interface{}
+	err = json.Unmarshal(data, &content)
+	if err != nil {
+		return nil, fmt.Errorf("content must be JSON object: %w", err)
+	}
+	return content, nil
+}
+
+func (a *app) openInput(path string) (io.ReadCloser, error) {
+	if path == stdioPath {
+		return io.NopCloser(a.stdin), nil
+	}
+	return os.Open(path)
+}
+
+func runCreate(a *app, args []string) error {
+	fs := newFlagSet("create", "-feature-id ID -tag-ids IDS -content FILE [-active]")
+
+	var tagIDs intsFlag
+	fs.Var(&tagIDs, "tag-ids", "comma separated tag ids")
+	featureID := fs.Int("feature-id", 0, "feature id")
+	isActive := fs.Bool("active", false, "create active banner")
+	contentPath := fs.String("content", stdioPath, "file with content JSON, - for stdin")
+
+	err := parseFlags(fs, args)
+	if err != nil {
+		return err
+	}
+
+	if !isFlagSet(fs, "feature-id") || !isFlagSet(fs, "tag-ids") {
+		fs.Usage()
+		return errUsage
+	}
+
+	content, err := a.readContent(*contentPath)
+	if err != nil {
+		return err
+	}
+
+	body, err := json.Marshal(bannermodels.BannerRequest{
+		TagIDs:    tagIDs,
+		FeatureID: *featureID,
+		Content:   content,
+		IsActive:  *isActive,
+	})
+	if err != nil {
+		return err
+	}
+
+	respBody, err := a.client.doBytes(http.MethodPost, "/banner", nil, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+
+	if a.output == outputJSON {
+		return a.printJSON(respBody)
+	}
+
+	var msg bannerIDMsg
+	err = json.Unmarshal(respBody, &msg)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(a.stdout, "banner_id: %d\n", msg.ID)
+	return nil
+}
+
+func runClone(a *app, args []string) error {
+	fs := newFlagSet("clone", "ID [-feature-id ID] [-tag-ids IDS] [-active=BOOL]")
+
+	var tagIDs intsFlag
+	fs.Var(&tagIDs, "tag-ids", "comma separated tag ids, source ones if not set")
+	featureID := fs.Int("feature-id", 0, "feature id, source one if not set")
+	isActive := fs.Bool("active", false, "banner state, source one if not set")
+
+	id, err := parseIDAndFlags(fs, args)
+	if err != nil {
+		return err
+	}
+
+	var clone bannermodels.BannerClone
+	if isFlagSet(fs, "tag-ids") {
+		clone.TagIDs = tagIDs
+	}
+	if isFlagSet(fs, "feature-id") {
+		clone.FeatureID = featureID
+	}
+	if isFlagSet(fs, "active") {
+		clone.IsActive = isActive
+	}
+
+	body, err := json.Marshal(clone)
+	if err != nil {
+		return err
+	}
+
+	respBody, err := a.client.doBytes(http.MethodPost, fmt.Sprintf("/banner/%d/clone", id), nil, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+
+	if a.output == outputJSON {
+		return a.printJSON(respBody)
+	}
+
+	var msg bannerIDMsg
+	err = json.Unmarshal(respBody, &msg)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(a.stdout, "banner_id: %d\n", msg.ID)
+	return nil
+}
+
+func runGet(a *app, args []string) error {
+	fs := newFlagSet("get", "-tag-id ID -feature-id ID [-last-revision]")
+
+	tagID := fs.Int("tag-id", 0, "tag id")
+	featureID := fs.Int("feature-id", 0, "feature id")
+	lastRevision := fs.Bool("last-revision", false, "bypass cache")
+
+	err := parseFlags(fs, args)
+	if err != nil {
+		return err
+	}
+
+	if !isFlagSet(fs, "tag-id") || !isFlagSet(fs, "feature-id") {
+		fs.Usage()
+		return errUsage
+	}
+
+	query := url.Values{
+		"tag_id":            {strconv.Itoa(*tagID)},
+		"feature_id":        {strconv.Itoa(*featureID)},
+		"use_last_revision": {strconv.FormatBool(*lastRevision)},
+	}
+
+	respBody, err := a.client.doBytes(http.MethodGet, "/user_banner", query, nil)
+	if err != nil {
+		return err
+	}
+
+	// content is arbitrary object, so it is printed as JSON in any format
+	return a.printJSON(respBody)
+}
+
+// filters shared by list and export
+type filterFlags struct {
+	featureID      *int
+	tagID          *int
+	query          *string
+	isActive       *string
+	sort           *string
+	order          *string
+	trashed        *bool
+	contentFilters stringsFlag
+}
+
+func addFilterFlags(fs *flag.FlagSet) *filterFlags {
+	ff := &filterFlags{
+		featureID: fs.Int("feature-id", 0, "filter by feature id"),
+		tagID:     fs.Int("tag-id", 0, "filter by tag id"),
+		query:     fs.String("q", "", "full-text search over content"),
+		isActive:  fs.String("active", "", "filter by state: true or false"),
+		sort:      fs.String("sort", "", "sort by created_at, updated_at, id or feature_id"),
+		order:     fs.String("order", "", "asc or desc"),
+		trashed:   fs.Bool("trashed", false, "banners in trash instead of live ones"),
+	}
+	fs.Var(&ff.contentFilters, "content-filter", "content predicate PATH=VALUE or PATH[contains]=VALUE, may be repeated")
+	return ff
+}
+
+func (ff *filterFlags) values(fs *flag.FlagSet) (url.Values, error) {
+	query := url.Values{}
+
+	if isFlagSet(fs, "feature-id") {
+		query.Set("feature_id", strconv.Itoa(*ff.featureID))
+	}
+	if isFlagSet(fs, "tag-id") {
+		query.Set("tag_id", strconv.Itoa(*ff.tagID))
+	}
+	if *ff.trashed {
+		query.Set("trashed", "true")
+	}
+
+	optional := map[string]*string{
+		"q":         ff.query,
+		"is_active": ff.isActive,
+		"sort":      ff.sort,
+		"order":     ff.order,
+	}
+	for name, value := range optional {
+		if *value != "" {
+			query.Set(name, *value)
+		}
+	}
+
+	for _, f := range ff.contentFilters {
+		path, value, ok := strings.Cut(f, "=")
+		if !ok || path == "" {
+			return nil, fmt.Errorf("content filter must be PATH=VALUE, got %q", f)
+		}
+		query.Add("content."+path, value)
+	}
+
+	return query, nil
+}
+
+func runList(a *app, args []string) error {
+	fs := newFlagSet("list", "[flags]")
+
+	ff := addFilterFlags(fs)
+	limit := fs.Int("limit", 0, "page size")
+	offset := fs.Int("offset", 0, "offset")
+	cursor := fs.String("cursor", "", "cursor of the page, switches to cursor pagination")
+	all := fs.Bool("all", false, "fetch all pages with cursor pagination")
+	withTotal := fs.Bool("with-total", false, "print total count, requires cursor pagination")
+
+	err := parseFlags(fs, args)
+	if err != nil {
+		return err
+	}
+
+	query, err := ff.values(fs)
+	if err != nil {
+		return err
+	}
+	if isFlagSet(fs, "limit") {
+		query.Set("limit", strconv.Itoa(*limit))
+	}
+	if isFlagSet(fs, "offset") {
+		query.Set("offset", strconv.Itoa(*offset))
+	}
+
+	if !isFlagSet(fs, "cursor") && !*all && !*withTotal {
+		respBody, err := a.client.doBytes(http.MethodGet, "/banner", query, nil)
+		if err != nil {
+			return err
+		}
+
+		if a.output == outputJSON {
+			return a.printJSON(respBody)
+		}
+
+		var banners []bannermodels.Banner
+		err = json.Unmarshal(respBody, &banners)
+		if err != nil {
+			return err
+		}
+		return a.printBannersTable(banners)
+	}
+
+	query.Set("cursor", *cursor)
+	if *withTotal {
+		query.Set("with_total", "true")
+	}
+
+	var banners []bannermodels.Banner
+	var last bannermodels.BannerPage
+	for {
+		respBody, err := a.client.doBytes(http.MethodGet, "/banner", query, nil)
+		if err != nil {
+			return err
+		}
+
+		var page bannermodels.BannerPage
+		err = json.Unmarshal(respBody, &page)
+		if err != nil {
+			return err
+		}
+
+		banners = append(banners, page.Items...)
+		last = page
+
+		if !*all || page.NextCursor == nil {
+			break
+		}
+		query.Set("cursor", *page.NextCursor)
+	}
+
+	if a.output == outputJSON {
+		last.Items = banners
+		return a.printObjJSON(last)
+	}
+
+	err = a.printBannersTable(banners)
+	if err != nil {
+		return err
+	}
+	if last.Total != nil {
+		fmt.Fprintf(a.stdout, "total: %d\n", *last.Total)
+	}
+	if last.NextCursor != nil {
+		fmt.Fprintf(a.stdout, "next cursor: %s\n", *last.NextCursor)
+	}
+	return nil
+}
+
+func runPatch(a *app, args []string) error {
+	fs := newFlagSet("patch", "ID [-feature-id ID] [-tag-ids IDS] [-content FILE] [-active=true|false]")
+
+	var tagIDs intsFlag
+	fs.Var(&tagIDs, "tag-ids", "comma separated tag ids")
+	featureID := fs.Int("feature-id", 0, "feature id")
+	isActive := fs.Bool("active", false, "banner state")
+	contentPath := fs.String("content", "", "file with content JSON, - for stdin")
+
+	id, err := parseIDAndFlags(fs, args)
+	if err != nil {
+		return err
+	}
+
+	patch := map[string]interface{}{}
+	if isFlagSet(fs, "tag-ids") {
+		patch["tag_ids"] = []int(tagIDs)
+	}
+	if isFlagSet(fs, "feature-id") {
+		patch["feature_id"] = *featureID
+	}
+	if isFlagSet(fs, "active") {
+		patch["is_active"] = *isActive
+	}
+	if isFlagSet(fs, "content") {
+		content, err := a.readContent(*contentPath)
+		if err != nil {
+			return err
+		}
+		patch["content"] = content
+	}
+
+	if len(patch) == 0 {
+		return errors.New("nothing to update")
+	}
+
+	body, err := json.Marshal(patch)
+	if err != nil {
+		return err
+	}
+
+	_, err = a.client.doBytes(http.MethodPatch, fmt.Sprintf("/banner/%d", id), nil, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+
+	return a.printDone(id, "updated")
+}
+
+func runDelete(a *app, args []string) error {
+	fs := newFlagSet("delete", "ID")
+
+	id, err := parseIDAndFlags(fs, args)
+	if err != nil {
+		return err
+	}
+
+	_, err = a.client.doBytes(http.MethodDelete, fmt.Sprintf("/banner/%d", id), nil, nil)
+	if err != nil {
+		return err
+	}
+
+	return a.printDone(id, "moved to trash")
+}
+
+func runRestore(a *app, args []string) error {
+	fs := newFlagSet("restore", "ID")
+
+	id, err := parseIDAndFlags(fs, args)
+	if err != nil {
+		return err
+	}
+
+	_, err = a.client.doBytes(http.MethodPost, fmt.Sprintf("/banner/%d/restore", id), nil, nil)
+	if err != nil {
+		return err
+	}
+
+	return a.printDone(id, "restored")
+}
+
+func runSlots(a *app, args []string) error {
+	fs := newFlagSet("slots", "-feature-id ID")
+
+	featureID := fs.Int("feature-id", 0, "feature id")
+
+	err := parseFlags(fs, args)
+	if err != nil {
+		return err
+	}
+
+	if !isFlagSet(fs, "feature-id") {
+		fs.Usage()
+		return errUsage
+	}
+
+	query := url.Values{"feature_id": {strconv.Itoa(*featureID)}}
+
+	respBody, err := a.client.doBytes(http.MethodGet, "/slots", query, nil)
+	if err != nil {
+		return err
+	}
+
+	if a.output == outputJSON {
+		return a.printJSON(respBody)
+	}
+
+	var slots []bannermodels.Slot
+	err = json.Unmarshal(respBody, &slots)
+	if err != nil {
+		return err
+	}
+	return a.printSlotsTable(slots)
+}
+
+func runTransfer(a *app, args []string) error {
+	fs := newFlagSet("transfer", "-from ID -to ID -tag-ids IDS [-swap]")
+
+	var tagIDs intsFlag
+	fs.Var(&tagIDs, "tag-ids", "comma separated tag ids")
+	fromID := fs.Int("from", 0, "banner id to take tags from")
+	toID := fs.Int("to", 0, "banner id to give tags to")
+	swap := fs.Bool("swap", false, "swap tags between banners instead of moving")
+
+	err := parseFlags(fs, args)
+	if err != nil {
+		return err
+	}
+
+	if !isFlagSet(fs, "from") || !isFlagSet(fs, "to") || !isFlagSet(fs, "tag-ids") {
+		fs.Usage()
+		return errUsage
+	}
+
+	mode := bannermodels.TransferMove
+	if *swap {
+		mode = bannermodels.TransferSwap
+	}
+
+	body, err := json.Marshal(bannermodels.SlotTransfer{
+		FromBannerID: *fromID,
+		ToBannerID:   *toID,
+		TagIDs:       tagIDs,
+		Mode:         mode,
+	})
+	if err != nil {
+		return err
+	}
+
+	respBody, err := a.client.doBytes(http.MethodPost, "/slots/transfer", nil, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+
+	if a.output == outputJSON {
+		return a.printJSON(respBody)
+	}
+
+	var result bannermodels.TransferResult
+	err = json.Unmarshal(respBody, &result)
+	if err != nil {
+		return err
+	}
+	return a.printSlotsTable(result.Slots)
+}
+
+func runToggle(a *app, args []string) error {
+	fs := newFlagSet("toggle", "[-feature-id ID] [-tag-ids IDS] -active=BOOL | -feature-id ID -kill [-dry-run]")
+
+	var tagIDs intsFlag
+	fs.Var(&tagIDs, "tag-ids", "comma separated tag ids")
+	featureID := fs.Int("feature-id", 0, "feature id")
+	isActive := fs.Bool("active", false, "target banner state")
+	kill := fs.Bool("kill", false, "kill switch: deactivate whole feature and drop its cache")
+	dryRun := fs.Bool("dry-run", false, "only show banners that would change")
+
+	err := parseFlags(fs, args)
+	if err != nil {
+		return err
+	}
+
+	var toggle bannermodels.BannerToggle
+	if isFlagSet(fs, "feature-id") {
+		toggle.FeatureID = featureID
+	}
+	if isFlagSet(fs, "tag-ids") {
+		toggle.TagIDs = tagIDs
+	}
+	if isFlagSet(fs, "active") {
+		toggle.IsActive = isActive
+	}
+	toggle.KillSwitch = *kill
+
+	body, err := json.Marshal(toggle)
+	if err != nil {
+		return err
+	}
+
+	query := url.Values{"dry_run": {strconv.FormatBool(*dryRun)}}
+	respBody, err := a.client.doBytes(http.MethodPost, "/banner/toggle", query, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+
+	if a.output == outputJSON {
+		return a.printJSON(respBody)
+	}
+
+	var result bannermodels.ToggleResult
+	err = json.Unmarshal(respBody, &result)
+	if err != nil {
+		return err
+	}
+
+	action := "deactivated"
+	if result.IsActive {
+		action = "activated"
+	}
+	if result.DryRun {
+		action = "would be " + action
+	}
+	fmt.Fprintf(a.stdout, "%d banners %s: %v\n", len(result.BannerIDs), action, result.BannerIDs)
+	return nil
+}
+
+func runExport(a *app, args []string) error {
+	fs := newFlagSet("export", "[-out FILE] [filter flags]")
+
+	ff := addFilterFlags(fs)
+	outPath := fs.String("out", stdioPath, "output file, - for stdout")
+
+	err := parseFlags(fs, args)
+	if err != nil {
+		return err
+	}
+
+	query, err := ff.values(fs)
+	if err != nil {
+		return err
+	}
+
+	resp, err := a.client.do(http.MethodGet, "/banner/export", query, "", nil, true)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	out := a.stdout
+	if *outPath != stdioPath {
+		f, err := os.Create(*outPath)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		out = f
+	}
+
+	_, err = io.Copy(out, resp.Body)
+	return err
+}
+
+func runImport(a *app, args []string) error {
+	fs := newFlagSet("import", "[-in FILE] [-on-conflict fail|upsert] [-dry-run]")
+
+	inPath := fs.String("in", stdioPath, "NDJSON file, - for stdin")
+	onConflict := fs.String("on-conflict", "fail", "fail or upsert")
+	dryRun := fs.Bool("dry-run", false, "report changes without applying them")
+
+	err := parseFlags(fs, args)
+	if err != nil {
+		return err
+	}
+
+	in, err := a.openInput(*inPath)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	query := url.Values{
+		"on_conflict": {*onConflict},
+		"dry_run":     {strconv.FormatBool(*dryRun)},
+	}
+
+	resp, err := a.client.send(http.MethodPost, "/banner/import", query, contentTypeNDJSON, in, true)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	// lines with taken slots are in details of conflict error
+	if resp.StatusCode != http.StatusOK {
+		return responseError(resp)
+	}
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+
+	if a.output == outputJSON {
+		return a.printJSON(respBody)
+	}
+
+	var report bannermodels.ImportReport
+	err = json.Unmarshal(respBody, &report)
+	if err != nil {
+		return err
+	}
+	return a.printImportReport(report)
+}
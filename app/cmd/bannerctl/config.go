@@ -0,0 +1,152 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+)
+
+const envConfig = "BANNERCTL_CONFIG"
+
+// defaults saved by login, flags and env override them
+type fileConfig struct {
+	URL   string `json:"url,omitempty"`
+	Token string `json:"token,omitempty"`
+}
+
+func configPath() (string, error) {
+	if path, ok := os.LookupEnv(envConfig); ok {
+		return path, nil
+	}
+
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "bannerctl", "config.json"), nil
+}
+
+// missing file is empty config
+func loadConfig(path string) (fileConfig, error) {
+	var cfg fileConfig
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return cfg, nil
+	}
+	if err != nil {
+		return cfg, err
+	}
+
+	err = json.Unmarshal(data, &cfg)
+	if err != nil {
+		return cfg, fmt.Errorf("config %s: %w", path, err)
+	}
+	return cfg, nil
+}
+
+// file holds token, so only owner may read it
+func saveConfig(path string, cfg fileConfig) error {
+	data, err := json.MarshalIndent(cfg, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	err = os.MkdirAll(filepath.Dir(path), 0o700)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(data, '\n'), 0o600)
+}
+
+// check token against API and save it with base URL as defaults
+func runLogin(a *app, args []string) error {
+	fs := newFlagSet("login", "-token TOKEN [-url URL] [-no-check]")
+
+	baseURL := fs.String("url", a.client.baseURL, "base URL of banner API")
+	token := fs.String("token", "", "admin token")
+	noCheck := fs.Bool("no-check", false, "save without checking token")
+
+	err := parseFlags(fs, args)
+	if err != nil {
+		return err
+	}
+
+	if *token == "" {
+		fs.Usage()
+		return errUsage
+	}
+
+	c := newClient(*baseURL, *token)
+	if !*noCheck {
+		_, err = c.doBytes(http.MethodGet, "/banner", url.Values{"limit": {"1"}}, nil)
+		if err != nil {
+			return err
+		}
+	}
+
+	path, err := configPath()
+	if err != nil {
+		return err
+	}
+
+	err = saveConfig(path, fileConfig{URL: c.baseURL, Token: *token})
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(a.stdout, "logged in to %s, config saved to %s\n", c.baseURL, path)
+	return nil
+}
+
+func runLogout(a *app, args []string) error {
+	fs := newFlagSet("logout", "")
+
+	err := parseFlags(fs, args)
+	if err != nil {
+		return err
+	}
+
+	path, err := configPath()
+	if err != nil {
+		return err
+	}
+
+	err = os.Remove(path)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+
+	fmt.Fprintf(a.stdout, "config %s removed\n", path)
+	return nil
+}
+
+func runConfig(a *app, args []string) error {
+	fs := newFlagSet("config", "")
+
+	err := parseFlags(fs, args)
+	if err != nil {
+		return err
+	}
+
+	path, err := configPath()
+	if err != nil {
+		return err
+	}
+
+	token := "not set"
+	if a.client.token != "" {
+		token = "set"
+	}
+
+	if a.output == outputJSON {
+		return a.printObjJSON(map[string]any{"config": path, "url": a.client.baseURL, "token_set": a.client.token != ""})
+	}
+
+	fmt.Fprintf(a.stdout, "config:\t%s\nurl:\t%s\ntoken:\t%s\n", path, a.client.baseURL, token)
+	return nil
+}
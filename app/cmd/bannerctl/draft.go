@@ -0,0 +1,108 @@
+package main
+
+import (
+	bannermodels "banner/internal/models/banner"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+)
+
+const draftUsage = "Usage: bannerctl draft put|get|delete|approve|publish ID [flags]"
+
+// bannerctl draft <action> ID
+func runDraft(a *app, args []string) error {
+	if len(args) == 0 {
+		fmt.Fprintln(os.Stderr, draftUsage)
+		return errUsage
+	}
+
+	switch action, args := args[0], args[1:]; action {
+	case "put":
+		return runDraftPut(a, args)
+	case "get":
+		return runDraftAction(a, "get", http.MethodGet, "", args)
+	case "delete":
+		return runDraftAction(a, "delete", http.MethodDelete, "", args)
+	case "approve":
+		return runDraftAction(a, "approve", http.MethodPost, "/approve", args)
+	case "publish":
+		return runDraftAction(a, "publish", http.MethodPost, "/publish", args)
+	default:
+		fmt.Fprintln(os.Stderr, draftUsage)
+		return errUsage
+	}
+}
+
+func runDraftPut(a *app, args []string) error {
+	fs := newFlagSet("draft put", "ID -feature-id ID -tag-ids IDS -content FILE [-active]")
+
+	var tagIDs intsFlag
+	fs.Var(&tagIDs, "tag-ids", "comma separated tag ids")
+	featureID := fs.Int("feature-id", 0, "feature id")
+	isActive := fs.Bool("active", false, "banner state after publishing")
+	contentPath := fs.String("content", stdioPath, "file with content JSON, - for stdin")
+
+	id, err := parseIDAndFlags(fs, args)
+	if err != nil {
+		return err
+	}
+
+	if !isFlagSet(fs, "feature-id") || !isFlagSet(fs, "tag-ids") {
+		fs.Usage()
+		return errUsage
+	}
+
+	content, err := a.readContent(*contentPath)
+	if err != nil {
+		return err
+	}
+
+	body, err := json.Marshal(bannermodels.BannerRequest{
+		TagIDs:    tagIDs,
+		FeatureID: *featureID,
+		Content:   content,
+		IsActive:  *isActive,
+	})
+	if err != nil {
+		return err
+	}
+
+	respBody, err := a.client.doBytes(http.MethodPut, fmt.Sprintf("/banner/%d/draft", id), nil, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+
+	// draft has arbitrary content, so it is printed as JSON in any format
+	return a.printJSON(respBody)
+}
+
+func runDraftAction(a *app, action string, method string, pathSuffix string, args []string) error {
+	fs := newFlagSet("draft "+action, "ID")
+
+	id, err := parseIDAndFlags(fs, args)
+	if err != nil {
+		return err
+	}
+
+	respBody, err := a.client.doBytes(method, fmt.Sprintf("/banner/%d/draft%s", id, pathSuffix), nil, nil)
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case action == "delete":
+		return a.printDone(id, "draft deleted")
+	case action == "publish" && a.output == outputTable:
+		var result bannermodels.PublishResult
+		err = json.Unmarshal(respBody, &result)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(a.stdout, "banner %d published by %s, approved by %s\n", id, result.PublishedBy, result.ApprovedBy)
+		return nil
+	default:
+		return a.printJSON(respBody)
+	}
+}
@@ -0,0 +1,150 @@
+// bannerctl is a command line client for banner service admin API
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+)
+
+const (
+	envBaseURL = "BANNERCTL_URL"
+	envToken   = "BANNERCTL_TOKEN"
+
+	defaultBaseURL = "http://localhost:9000"
+
+	outputTable = "table"
+	outputJSON  = "json"
+)
+
+var errUsage = errors.New("bad usage")
+
+type app struct {
+	client *client
+	output string
+
+	stdin  io.Reader
+	stdout io.Writer
+}
+
+type command struct {
+	name    string
+	summary string
+	run     func(a *app, args []string) error
+}
+
+var commands = []command{
+	{"create", "create banner", runCreate},
+	{"clone", "create banner with content of another one", runClone},
+	{"get", "get banner content for tag and feature as user sees it", runGet},
+	{"list", "list banners with filters", runList},
+	{"patch", "partially update banner", runPatch},
+	{"versions", "show banner states after each change, read from event log within its retention", runVersions},
+	{"delete", "move banner to trash", runDelete},
+	{"restore", "restore banner from trash", runRestore},
+	{"toggle", "activate or deactivate banners of feature or tags", runToggle},
+	{"draft", "edit, approve and publish banner draft", runDraft},
+	{"slots", "show which banner holds each tag of feature", runSlots},
+	{"transfer", "move or swap tags between banners", runTransfer},
+	{"export", "export banners as NDJSON", runExport},
+	{"import", "import banners from NDJSON", runImport},
+	{"login", "check token and save it with base URL to config", runLogin},
+	{"logout", "remove saved config", runLogout},
+	{"config", "show base URL and config in use", runConfig},
+}
+
+func usage(fs *flag.FlagSet) func() {
+	return func() {
+		out := fs.Output()
+		fmt.Fprintf(out, "Usage: bannerctl [flags] <command> [command flags]\n\nCommands:\n")
+		for _, cmd := range commands {
+			fmt.Fprintf(out, "  %-9s %s\n", cmd.name, cmd.summary)
+		}
+		fmt.Fprintf(out, "\nRun 'bannerctl <command> -h' for command flags.\n\nFlags:\n")
+		fs.PrintDefaults()
+	}
+}
+
+func firstSet(values ...string) string {
+	for _, value := range values {
+		if value != "" {
+			return value
+		}
+	}
+	return ""
+}
+
+// broken config is only reported, so flags, env and logout still work
+func savedConfig() fileConfig {
+	path, err := configPath()
+	if err == nil {
+		var cfg fileConfig
+		cfg, err = loadConfig(path)
+		if err == nil {
+			return cfg
+		}
+	}
+
+	fmt.Fprintf(os.Stderr, "bannerctl: saved config is ignored: %v\n", err)
+	return fileConfig{}
+}
+
+func main() {
+	fs := flag.NewFlagSet("bannerctl", flag.ExitOnError)
+	fs.Usage = usage(fs)
+
+	// not flag defaults, so saved config is read only if needed and token is not printed by usage
+	baseURL := fs.String("url", "", "base URL of banner API (env "+envBaseURL+", saved by login, default "+defaultBaseURL+")")
+	token := fs.String("token", "", "admin token (env "+envToken+", saved by login)")
+	output := fs.String("o", outputTable, "output format: table or json")
+
+	fs.Parse(os.Args[1:])
+
+	*baseURL = firstSet(*baseURL, os.Getenv(envBaseURL))
+	*token = firstSet(*token, os.Getenv(envToken))
+	if *baseURL == "" || *token == "" {
+		saved := savedConfig()
+		*baseURL = firstSet(*baseURL, saved.URL, defaultBaseURL)
+		*token = firstSet(*token, saved.Token)
+	}
+
+	if fs.NArg() == 0 {
+		fs.Usage()
+		os.Exit(2)
+	}
+
+	if *output != outputTable && *output != outputJSON {
+		fmt.Fprintf(os.Stderr, "bannerctl: unknown output format %q\n", *output)
+		os.Exit(2)
+	}
+
+	a := &app{
+		client: newClient(*baseURL, *token),
+		output: *output,
+		stdin:  os.Stdin,
+		stdout: os.Stdout,
+	}
+
+	name := fs.Arg(0)
+	for _, cmd := range commands {
+		if cmd.name != name {
+			continue
+		}
+
+		err := cmd.run(a, fs.Args()[1:])
+		switch {
+		case errors.Is(err, errUsage):
+			os.Exit(2)
+		case err != nil:
+			fmt.Fprintln(os.Stderr, "bannerctl:", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	fmt.Fprintf(os.Stderr, "bannerctl: unknown command %q\n", name)
+	fs.Usage()
+	os.Exit(2)
+}
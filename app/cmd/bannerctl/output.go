@@ -0,0 +1,102 @@
+package main
+
+import (
+	bannermodels "banner/internal/models/banner"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+func (a *app) printJSON(data []byte) error {
+	var buf bytes.Buffer
+	err := json.Indent(&buf, data, "", "  ")
+	if err != nil {
+		return err
+	}
+	buf.WriteByte('\n')
+
+	_, err = a.stdout.Write(buf.Bytes())
+	return err
+}
+
+func (a *app) printObjJSON(obj any) error {
+	data, err := json.Marshal(obj)
+	if err != nil {
+		return err
+	}
+	return a.printJSON(data)
+}
+
+func (a *app) printDone(id int, action string) error {
+	if a.output == outputJSON {
+		return a.printObjJSON(bannerIDMsg{ID: id})
+	}
+
+	fmt.Fprintf(a.stdout, "banner %d %s\n", id, action)
+	return nil
+}
+
+func (a *app) printBannersTable(banners []bannermodels.Banner) error {
+	tw := tabwriter.NewWriter(a.stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "ID\tFEATURE\tTAGS\tACTIVE\tCREATED\tUPDATED")
+
+	for _, b := range banners {
+		tags := make([]string, len(b.TagIDs))
+		for i, tagID := range b.TagIDs {
+			tags[i] = strconv.Itoa(tagID)
+		}
+
+		fmt.Fprintf(
+			tw,
+			"%d\t%d\t%s\t%t\t%s\t%s\n",
+			b.ID,
+			b.FeatureID,
+			strings.Join(tags, ","),
+			b.IsActive,
+			b.CreatedAt.Format(time.DateTime),
+			b.UpdatedAt.Format(time.DateTime),
+		)
+	}
+
+	return tw.Flush()
+}
+
+func (a *app) printSlotsTable(slots []bannermodels.Slot) error {
+	tw := tabwriter.NewWriter(a.stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "TAG\tFEATURE\tBANNER")
+
+	for _, slot := range slots {
+		fmt.Fprintf(tw, "%d\t%d\t%d\n", slot.TagID, slot.FeatureID, slot.BannerID)
+	}
+
+	return tw.Flush()
+}
+
+func (a *app) printImportReport(report bannermodels.ImportReport) error {
+	tw := tabwriter.NewWriter(a.stdout, 0, 4, 2, ' ', 0)
+
+	fmt.Fprintf(tw, "dry run:\t%t\n", report.DryRun)
+	fmt.Fprintf(tw, "applied:\t%t\n", report.Applied)
+	fmt.Fprintf(tw, "created:\t%d\n", report.Created)
+	fmt.Fprintf(tw, "updated:\t%d\n", report.Updated)
+	fmt.Fprintf(tw, "unchanged:\t%d\n", report.Unchanged)
+	fmt.Fprintf(tw, "conflicts:\t%d\n", report.Conflicts)
+
+	for _, result := range report.Results {
+		if result.Action != bannermodels.ImportConflict {
+			continue
+		}
+
+		holders := make([]string, len(result.ConflictBannerIDs))
+		for i, id := range result.ConflictBannerIDs {
+			holders[i] = strconv.Itoa(id)
+		}
+		fmt.Fprintf(tw, "line %d:\tslots are taken by banners %s\n", result.Line, strings.Join(holders, ","))
+	}
+
+	return tw.Flush()
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	bannermodels "banner/internal/models/banner"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+// max limit of /events
+const eventsPageSize = 1000
+
+// States of banner after each of its changes, oldest first.
+// History is read from event log, changes older than events.retention of service are not shown.
+func runVersions(a *app, args []string) error {
+	fs := newFlagSet("versions", "ID")
+
+	id, err := parseIDAndFlags(fs, args)
+	if err != nil {
+		return err
+	}
+
+	versions := []bannermodels.BannerChange{}
+	var after int64
+	for {
+		query := url.Values{
+			"banner_id": {strconv.Itoa(id)},
+			"after":     {strconv.FormatInt(after, 10)},
+			"limit":     {strconv.Itoa(eventsPageSize)},
+		}
+		respBody, err := a.client.doBytes(http.MethodGet, "/events", query, nil)
+		if err != nil {
+			return err
+		}
+
+		var events []bannermodels.BannerChange
+		err = json.Unmarshal(respBody, &events)
+		if err != nil {
+			return err
+		}
+
+		versions = append(versions, events...)
+
+		if len(events) < eventsPageSize {
+			break
+		}
+		after = events[len(events)-1].ID
+	}
+
+	if a.output == outputJSON {
+		return a.printObjJSON(versions)
+	}
+	return a.printVersionsTable(versions)
+}
+
+func (a *app) printVersionsTable(versions []bannermodels.BannerChange) error {
+	tw := tabwriter.NewWriter(a.stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "EVENT\tCHANGE\tAT\tFEATURE\tTAGS\tACTIVE")
+
+	for _, v := range versions {
+		// deleted banner has no state after change
+		feature, tags, active := "-", "-", "-"
+		if v.After != nil {
+			tagStrs := make([]string, len(v.After.TagIDs))
+			for i, tagID := range v.After.TagIDs {
+				tagStrs[i] = strconv.Itoa(tagID)
+			}
+			feature = strconv.Itoa(v.After.FeatureID)
+			tags = strings.Join(tagStrs, ",")
+			active = strconv.FormatBool(v.After.IsActive)
+		}
+
+		fmt.Fprintf(
+			tw,
+			"%d\t%s\t%s\t%s\t%s\t%s\n",
+			v.ID,
+			v.Type,
+			v.OccurredAt.Format(time.DateTime),
+			feature,
+			tags,
+			active,
+		)
+	}
+
+	return tw.Flush()
+}
@@ -0,0 +1,28 @@
+package main
+
+import (
+	"banner/internal/config"
+	"fmt"
+	"os"
+)
+
+const configUsage = "usage: banner config print [config flags]"
+
+// banner config print, effective config is printed even if it is invalid
+func runConfig(args []string) {
+	if len(args) == 0 || args[0] != "print" {
+		fmt.Fprintln(os.Stderr, configUsage)
+		os.Exit(2)
+	}
+
+	cfg, _ := loadConfig("banner config print", args[1:], func(config.Config) error { return nil })
+
+	if err := cfg.Print(os.Stdout); err != nil {
+		fatal("print config", err)
+	}
+
+	if err := cfg.Validate(); err != nil {
+		fmt.Fprintf(os.Stderr, "bad config:\n%v\n", err)
+		os.Exit(1)
+	}
+}
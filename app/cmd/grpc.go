@@ -0,0 +1,67 @@
+package main
+
+import (
+	"banner/internal/grpcapi"
+	"banner/internal/grpcapi/bannerpb"
+	"banner/internal/ratelimit"
+	"banner/internal/service"
+	"log/slog"
+	"net"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/reflection"
+)
+
+// gRPC API with auth, rate limit if limiter is not nil, and reflection for grpcurl
+func newGRPCServer(
+	bannerService *service.BannerService,
+	authenticator *grpcapi.Authenticator,
+	limiter ratelimit.Limiter,
+	policy ratelimit.Policy,
+	logger *slog.Logger,
+) *grpc.Server {
+	interceptors := []grpc.UnaryServerInterceptor{
+		grpcapi.LogInterceptor(logger),
+		authenticator.UnaryInterceptor(grpcapi.PublicMethods),
+	}
+	if limiter != nil {
+		interceptors = append(interceptors, grpcapi.RateLimitInterceptor(limiter, policy))
+	}
+
+	server := grpc.NewServer(grpc.ChainUnaryInterceptor(interceptors...))
+	bannerpb.RegisterBannerServiceServer(server, grpcapi.NewServer(bannerService))
+	reflection.Register(server)
+
+	return server
+}
+
+// listen before returning, so bad address fails start
+func serveGRPC(server *grpc.Server, addr string) {
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		fatal("listen grpc", err)
+	}
+
+	slog.Info("starting grpc server", "addr", addr)
+	go func() {
+		if err := server.Serve(listener); err != nil {
+			slog.Error("serve grpc", "err", err)
+		}
+	}()
+}
+
+// stop accepting calls and wait for running ones, calls left after timeout are cancelled
+func stopGRPC(server *grpc.Server, timeout time.Duration) {
+	stopped := make(chan struct{})
+	go func() {
+		server.GracefulStop()
+		close(stopped)
+	}()
+
+	select {
+	case <-stopped:
+	case <-time.After(timeout):
+		server.Stop()
+	}
+}
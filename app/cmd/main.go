@@ -2,62 +2,165 @@ package main
 
 import (
 	cache "banner/internal/banner_cache"
+	"banner/internal/config"
 	"banner/internal/db"
+	"banner/internal/grpcapi"
 	"banner/internal/handler"
+	"banner/internal/health"
+	"banner/internal/logging"
+	"banner/internal/metrics"
 	"banner/internal/middleware"
+	"banner/internal/ratelimit"
 	"banner/internal/repo"
 	"banner/internal/service"
+	"banner/internal/tracing"
+	"banner/internal/webhook"
+	"errors"
+	"flag"
 	"fmt"
+	"sync"
+	"syscall"
 	"time"
 
 	"context"
-	"log"
+	"log/slog"
 	"net/http"
 	"os"
+	"os/signal"
 
 	"github.com/gorilla/mux"
 	"github.com/redis/go-redis/v9"
 )
 
-func getPostgresDB(ctx context.Context) *db.Database {
-	psgDsn, ok := os.LookupEnv("POSTGRES_DB_DSN")
-	if !ok {
-		panic("no POSTGRES_DB_DSN in env vars")
+// whole readiness probe, checks run concurrently
+const readinessTimeout = 2 * time.Second
+
+// log fatal error and exit, used only before server starts serving
+func fatal(msg string, err error) {
+	slog.Error(msg, "err", err)
+	os.Exit(1)
+}
+
+// load config of command from args, exit on bad flags, values or extra args
+func loadConfig(name string, args []string, validate func(config.Config) error) (config.Config, []string) {
+	cfg, rest, err := config.Load(name, args, os.Stderr)
+	switch {
+	case errors.Is(err, flag.ErrHelp):
+		os.Exit(0)
+	case err != nil:
+		fmt.Fprintln(os.Stderr, "bad config:", err)
+		os.Exit(2)
 	}
-	database, err := db.NewDB(ctx, psgDsn)
-	if err != nil {
-		log.Panic(err)
+
+	if err := validate(cfg); err != nil {
+		fmt.Fprintf(os.Stderr, "bad config:\n%v\n", err)
+		os.Exit(2)
 	}
-	return database
+
+	return cfg, rest
 }
 
-func getRedisClient(ctx context.Context) *redis.Client {
-	redisAddr, ok := os.LookupEnv("REDIS_ADDR")
-	if !ok {
-		panic("no REDIS_ADDR in env vars")
-	}
+func newLogger(cfg config.LogConfig) *slog.Logger {
+	// level is checked by config validation
+	level, _ := logging.ParseLevel(cfg.Level)
+	return logging.New(os.Stdout, level)
+}
 
-	redisPassword, ok := os.LookupEnv("REDIS_PASSWORD")
-	if !ok {
-		panic("no REDIS_PASSWORD in env vars")
+func getPostgresDB(ctx context.Context, cfg config.PostgresConfig) *db.Database {
+	database, err := db.NewDBWithPool(ctx, cfg.DSN, db.PoolConfig{
+		MaxConns:        cfg.MaxConns,
+		MinConns:        cfg.MinConns,
+		MaxConnLifetime: cfg.MaxConnLifetime,
+		MaxConnIdleTime: cfg.MaxConnIdleTime,
+	})
+	if err != nil {
+		fatal("connect to postgres", err)
 	}
+	return database
+}
 
+func getRedisClient(ctx context.Context, cfg config.RedisConfig) *redis.Client {
 	redisClient := redis.NewClient(&redis.Options{
-		Addr:     redisAddr,
-		Password: redisPassword,
-		DB:       0,
+		Addr:         cfg.Addr,
+		Password:     cfg.Password,
+		DB:           cfg.DB,
+		PoolSize:     cfg.PoolSize,
+		DialTimeout:  cfg.DialTimeout,
+		ReadTimeout:  cfg.ReadTimeout,
+		WriteTimeout: cfg.WriteTimeout,
 	})
 
 	_, err := redisClient.Do(ctx, "PING").Result()
 	if err != nil {
-		panic(err)
+		fatal("connect to redis", err)
 	}
 
 	return redisClient
 }
 
+// metrics are served on separate port, so they are not exposed with public API
+func serveAdmin(addr string, appMetrics *metrics.Metrics) *http.Server {
+	adminRouter := http.NewServeMux()
+	adminRouter.Handle("/metrics", appMetrics.Handler())
+
+	server := &http.Server{
+		Addr:              addr,
+		Handler:           adminRouter,
+		ReadHeaderTimeout: 5 * time.Second,
+	}
+
+	go func() {
+		if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			fatal("serve admin", err)
+		}
+	}()
+
+	return server
+}
+
+// dependencies checked by /readyz
+func newHealthChecker(database *db.Database, redisClient *redis.Client, migrator *db.Migrator) *health.Checker {
+	checker := health.NewChecker(readinessTimeout)
+	checker.Add("postgres", database.Ping)
+	checker.Add("schema", migrator.CheckVersion)
+	if redisClient != nil {
+		checker.Add("redis", func(ctx context.Context) error {
+			return redisClient.Ping(ctx).Err()
+		})
+	}
+	return checker
+}
+
+// serve until ctx is done, then stop accepting connections and wait for
+// in-flight requests, connections left after timeout are closed
+func serve(ctx context.Context, server *http.Server, timeout time.Duration) error {
+	serveErr := make(chan error, 1)
+	go func() {
+		serveErr <- server.ListenAndServe()
+	}()
+
+	select {
+	case err := <-serveErr:
+		return err
+	case <-ctx.Done():
+	}
+
+	slog.Info("shutting down server", "timeout", timeout.String())
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	err := server.Shutdown(shutdownCtx)
+	if err != nil {
+		server.Close()
+		return fmt.Errorf("drain requests: %w", err)
+	}
+	return nil
+}
+
 func register(router *mux.Router, bannerHandler *handler.BannerHandler) {
 	router.HandleFunc("/user_banner", bannerHandler.GetUserBanner).Methods(http.MethodGet)
+	router.HandleFunc("/user_banner/stream", bannerHandler.StreamUserBanners).Methods(http.MethodGet)
 
 	router.Handle(
 		"/banner",
@@ -69,6 +172,31 @@ func register(router *mux.Router, bannerHandler *handler.BannerHandler) {
 		middleware.OnlyAdmin((http.HandlerFunc(bannerHandler.CreateBanner))),
 	).Methods(http.MethodPost)
 
+	router.Handle(
+		"/banner/export",
+		middleware.OnlyAdmin((http.HandlerFunc(bannerHandler.ExportBanners))),
+	).Methods(http.MethodGet)
+
+	router.Handle(
+		"/banner/import",
+		middleware.OnlyAdmin((http.HandlerFunc(bannerHandler.ImportBanners))),
+	).Methods(http.MethodPost)
+
+	router.Handle(
+		"/banner/toggle",
+		middleware.OnlyAdmin((http.HandlerFunc(bannerHandler.ToggleBanners))),
+	).Methods(http.MethodPost)
+
+	router.Handle(
+		"/slots",
+		middleware.OnlyAdmin((http.HandlerFunc(bannerHandler.FeatureSlots))),
+	).Methods(http.MethodGet)
+
+	router.Handle(
+		"/slots/transfer",
+		middleware.OnlyAdmin((http.HandlerFunc(bannerHandler.TransferSlots))),
+	).Methods(http.MethodPost)
+
 	router.Handle(
 		"/banner/{id:[0-9]+}",
 		middleware.OnlyAdmin((http.HandlerFunc(bannerHandler.UpdatePatial))),
@@ -78,47 +206,321 @@ func register(router *mux.Router, bannerHandler *handler.BannerHandler) {
 		"/banner/{id:[0-9]+}",
 		middleware.OnlyAdmin((http.HandlerFunc(bannerHandler.DeleteBanner))),
 	).Methods(http.MethodDelete)
+
+	router.Handle(
+		"/banner/{id:[0-9]+}/clone",
+		middleware.OnlyAdmin((http.HandlerFunc(bannerHandler.CloneBanner))),
+	).Methods(http.MethodPost)
+
+	router.Handle(
+		"/banner/{id:[0-9]+}/restore",
+		middleware.OnlyAdmin((http.HandlerFunc(bannerHandler.RestoreBanner))),
+	).Methods(http.MethodPost)
+
+	router.Handle(
+		"/banner/{id:[0-9]+}/draft",
+		middleware.OnlyAdmin((http.HandlerFunc(bannerHandler.SaveDraft))),
+	).Methods(http.MethodPut)
+
+	router.Handle(
+		"/banner/{id:[0-9]+}/draft",
+		middleware.OnlyAdmin((http.HandlerFunc(bannerHandler.GetDraft))),
+	).Methods(http.MethodGet)
+
+	router.Handle(
+		"/banner/{id:[0-9]+}/draft",
+		middleware.OnlyAdmin((http.HandlerFunc(bannerHandler.DeleteDraft))),
+	).Methods(http.MethodDelete)
+
+	router.Handle(
+		"/banner/{id:[0-9]+}/draft/approve",
+		middleware.OnlyAdmin((http.HandlerFunc(bannerHandler.ApproveDraft))),
+	).Methods(http.MethodPost)
+
+	router.Handle(
+		"/banner/{id:[0-9]+}/draft/publish",
+		middleware.OnlyAdmin((http.HandlerFunc(bannerHandler.PublishDraft))),
+	).Methods(http.MethodPost)
+
+	router.Handle(
+		"/webhooks",
+		middleware.OnlyAdmin((http.HandlerFunc(bannerHandler.ListWebhooks))),
+	).Methods(http.MethodGet)
+
+	router.Handle(
+		"/webhooks",
+		middleware.OnlyAdmin((http.HandlerFunc(bannerHandler.CreateWebhook))),
+	).Methods(http.MethodPost)
+
+	router.Handle(
+		"/webhooks/deliveries",
+		middleware.OnlyAdmin((http.HandlerFunc(bannerHandler.WebhookDeliveries))),
+	).Methods(http.MethodGet)
+
+	router.Handle(
+		"/webhooks/{id:[0-9]+}",
+		middleware.OnlyAdmin((http.HandlerFunc(bannerHandler.DeleteWebhook))),
+	).Methods(http.MethodDelete)
+
+	router.Handle(
+		"/webhooks/{id:[0-9]+}/deliveries",
+		middleware.OnlyAdmin((http.HandlerFunc(bannerHandler.WebhookDeliveries))),
+	).Methods(http.MethodGet)
+
+	router.Handle(
+		"/webhooks/{id:[0-9]+}/deliveries/{delivery_id:[0-9]+}/redeliver",
+		middleware.OnlyAdmin((http.HandlerFunc(bannerHandler.RedeliverWebhook))),
+	).Methods(http.MethodPost)
+
+	router.Handle(
+		"/events",
+		middleware.OnlyAdmin((http.HandlerFunc(bannerHandler.BannerEvents))),
+	).Methods(http.MethodGet)
 }
 
 func main() {
-	ctx, cancel := context.WithCancel(context.Background())
-	defer cancel()
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	args := os.Args[1:]
+	if len(args) > 0 {
+		switch args[0] {
+		case "migrate":
+			runMigrate(ctx, args[1:])
+			return
+		case "config":
+			runConfig(args[1:])
+			return
+		}
+	}
+
+	cfg, rest := loadConfig("banner", args, config.Config.Validate)
+	if len(rest) != 0 {
+		fmt.Fprintf(os.Stderr, "unknown command %q, commands: migrate, config\n", rest[0])
+		os.Exit(2)
+	}
+
+	logger := newLogger(cfg.Log)
+	slog.SetDefault(logger)
+
+	prepareSchema(ctx, cfg.Postgres.DSN, cfg.Migrate.Auto)
+
+	shutdownTracing, err := tracing.Setup(ctx, cfg.Tracing.SetupConfig())
+	if err != nil {
+		fatal("setup tracing", err)
+	}
+	// spans of drained requests are flushed last
+	flushTracing := func() {
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := shutdownTracing(shutdownCtx); err != nil {
+			slog.Error("shutdown tracing", "err", err)
+		}
+	}
 
-	database := getPostgresDB(ctx)
-	defer database.Close()
+	// closed explicitly after server and workers are stopped
+	database := getPostgresDB(ctx, cfg.Postgres)
 
-	redisClient := getRedisClient(ctx)
-	defer redisClient.Close()
+	// reads schema version for readiness probe
+	migrator, err := db.NewMigrator(cfg.Postgres.DSN)
+	if err != nil {
+		fatal("migrate", err)
+	}
+
+	appMetrics := metrics.New()
+	appMetrics.MustRegister(metrics.NewPoolCollector(database))
 
-	bannerRepo := repo.NewBannerRepo(database)
-	bannerCache := cache.NewBannerRedisCahe(redisClient, 5*time.Minute)
+	bannerRepo := repo.NewBannerRepo(database, appMetrics)
+
+	var redisClient *redis.Client
+	if cfg.UsesRedis() {
+		redisClient = getRedisClient(ctx, cfg.Redis)
+	}
+
+	bannerCache := cache.NewInstrumentedCache(cache.NewBannerNoCache(), appMetrics)
+	if cfg.Cache.Enabled {
+		bannerCache = cache.NewInstrumentedCache(
+			cache.NewBannerRedisCahe(redisClient, cfg.Cache.BannerTTL, cfg.Cache.Encoding == cache.EncodingCompact),
+			appMetrics,
+		)
+	}
 
 	bannerService := service.NewBannerService(bannerRepo, bannerCache)
-	bannerHandler := handler.NewBannerHandler(bannerService)
+	// banners are stale no longer than cache ttl, without cache they are always fresh
+	var userBannerMaxAge time.Duration
+	if cfg.Cache.Enabled {
+		userBannerMaxAge = cfg.Cache.BannerTTL
+	}
+	bannerHandler := handler.NewBannerHandler(bannerService, userBannerMaxAge, handler.StreamConfig{
+		Heartbeat:      cfg.Stream.Heartbeat,
+		ResyncInterval: cfg.Stream.ResyncInterval,
+	})
+
+	// workers outlive ctx, they are stopped only after requests are drained
+	workersCtx, stopWorkers := context.WithCancel(context.Background())
+	var workers sync.WaitGroup
+
+	if cfg.Trash.PurgeEnabled {
+		workers.Add(1)
+		go func() {
+			defer workers.Done()
+			bannerService.RunTrashPurge(workersCtx, cfg.Trash.Retention, cfg.Trash.PurgeInterval)
+		}()
+	}
+
+	var sinks []service.EventSink
+	if cfg.Webhook.Enabled {
+		sinks = append(sinks, service.NewWebhookSink(bannerRepo))
+	}
+
+	if cfg.Events.PublisherEnabled {
+		publisher := service.NewEventPublisher(
+			bannerRepo,
+			appMetrics,
+			service.EventPublisherConfig{
+				PollInterval: cfg.Events.PollInterval,
+				BatchSize:    cfg.Events.BatchSize,
+				Retention:    cfg.Events.Retention,
+			},
+			sinks...,
+		)
+		workers.Add(1)
+		go func() {
+			defer workers.Done()
+			publisher.Run(workersCtx)
+		}()
+	}
+
+	if cfg.Webhook.Enabled {
+		dispatcher := service.NewWebhookDispatcher(
+			bannerRepo,
+			webhook.NewSender(cfg.Webhook.Timeout),
+			appMetrics,
+			service.WebhookDispatcherConfig{
+				PollInterval: cfg.Webhook.PollInterval,
+				BatchSize:    cfg.Webhook.BatchSize,
+				Timeout:      cfg.Webhook.Timeout,
+				Retry: webhook.RetryPolicy{
+					MaxAttempts: cfg.Webhook.MaxAttempts,
+					MinBackoff:  cfg.Webhook.MinBackoff,
+					MaxBackoff:  cfg.Webhook.MaxBackoff,
+				},
+				Retention: cfg.Webhook.Retention,
+			},
+		)
+		workers.Add(1)
+		go func() {
+			defer workers.Done()
+			dispatcher.Run(workersCtx)
+		}()
+	}
 
 	router := mux.NewRouter()
+	router.NotFoundHandler = middleware.NotFound()
+	router.MethodNotAllowedHandler = middleware.MethodNotAllowed()
 	register(router, &bannerHandler)
 
-	userToken, ok := os.LookupEnv("USER_TOKEN")
-	if !ok {
-		panic("no USER_TOKEN in env vars")
+	// checked by config validation
+	adminTokens, _ := cfg.Auth.Admins()
+	rateLimitPolicy, _ := cfg.RateLimit.Policy()
+
+	// buckets are shared by HTTP and gRPC APIs
+	var limiter ratelimit.Limiter
+	var handlerForUser http.Handler = router
+	if cfg.RateLimit.Enabled {
+		limiter = ratelimit.NewMemoryLimiter()
+		if cfg.RateLimit.Backend == ratelimit.BackendRedis {
+			limiter = ratelimit.NewRedisLimiter(redisClient)
+		}
+		handlerForUser = middleware.RateLimit(limiter, rateLimitPolicy, router)
+	}
+
+	appHandler := middleware.Metrics(
+		router,
+		appMetrics,
+		middleware.Tracing(
+			router,
+			middleware.RequestID(
+				middleware.AccessLog(
+					router,
+					logger,
+					middleware.AuthMiddleware(cfg.Auth.UserToken, adminTokens, handlerForUser),
+				),
+			),
+		),
+	)
+
+	compressed := appHandler
+	if cfg.Compression.Enabled {
+		compressed = middleware.Compress(cfg.Compression.MinSize, appHandler)
+	}
+
+	checker := newHealthChecker(database, redisClient, migrator)
+
+	adminServer := serveAdmin(cfg.HTTP.AdminAddr, appMetrics)
+
+	server := &http.Server{
+		Addr:              cfg.HTTP.Addr,
+		Handler:           checker.Mount(compressed),
+		ReadHeaderTimeout: cfg.HTTP.ReadHeaderTimeout,
+		ReadTimeout:       cfg.HTTP.ReadTimeout,
+		WriteTimeout:      cfg.HTTP.WriteTimeout,
+		IdleTimeout:       cfg.HTTP.IdleTimeout,
+	}
+	// shutdown waits for requests, streams would hold it until timeout
+	server.RegisterOnShutdown(bannerHandler.CloseStreams)
+
+	// gRPC is drained together with HTTP
+	grpcStopped := make(chan struct{})
+	if cfg.GRPC.Enabled {
+		grpcServer := newGRPCServer(
+			bannerService,
+			grpcapi.NewAuthenticator(cfg.Auth.UserToken, adminTokens, cfg.Auth.JWTSecret),
+			limiter,
+			rateLimitPolicy,
+			logger,
+		)
+		serveGRPC(grpcServer, cfg.GRPC.Addr)
+
+		go func() {
+			defer close(grpcStopped)
+			<-ctx.Done()
+			stopGRPC(grpcServer, cfg.HTTP.ShutdownTimeout)
+		}()
+	} else {
+		close(grpcStopped)
 	}
 
-	adminToken, ok := os.LookupEnv("ADMIN_TOKEN")
-	if !ok {
-		panic("no ADMIN_TOKEN in env vars")
+	slog.Info("starting server", "addr", cfg.HTTP.Addr)
+
+	serveErr := serve(ctx, server, cfg.HTTP.ShutdownTimeout)
+	if serveErr != nil {
+		slog.Error("serve", "err", serveErr)
 	}
 
-	appHandler := middleware.AuthMiddleware(userToken, adminToken, router)
+	// ctx is not done if HTTP server failed
+	stop()
+	<-grpcStopped
+
+	stopWorkers()
+	workers.Wait()
 
-	addr, ok := os.LookupEnv("HOST_PORT")
-	if !ok {
-		panic("no HOST_PORT in env vars")
+	adminServer.Close()
+
+	if err := migrator.Close(); err != nil {
+		slog.Error("close migrator", "err", err)
+	}
+	database.Close()
+	if redisClient != nil {
+		if err := redisClient.Close(); err != nil {
+			slog.Error("close redis", "err", err)
+		}
 	}
 
-	fmt.Println("Starting server...")
+	flushTracing()
 
-	if err := http.ListenAndServe(addr, appHandler); err != nil {
-		log.Panic(err)
+	if serveErr != nil {
+		os.Exit(1)
 	}
+	slog.Info("server stopped")
 }
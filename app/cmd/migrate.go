@@ -0,0 +1,95 @@
+package main
+
+import (
+	"banner/internal/config"
+	"banner/internal/db"
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"text/tabwriter"
+	"time"
+)
+
+const migrateUsage = "usage: banner migrate [config flags] up|down|status"
+
+// banner migrate up|down|status
+func runMigrate(ctx context.Context, args []string) {
+	cfg, rest := loadConfig("banner migrate", args, config.Config.ValidatePostgres)
+	if len(rest) != 1 {
+		fmt.Fprintln(os.Stderr, migrateUsage)
+		os.Exit(2)
+	}
+
+	migrator, err := db.NewMigrator(cfg.Postgres.DSN)
+	if err != nil {
+		fatal("migrate", err)
+	}
+	defer migrator.Close()
+
+	switch rest[0] {
+	case "up":
+		results, err := migrator.Up(ctx)
+		for _, result := range results {
+			fmt.Printf("applied %s (%v)\n", result.Source.Path, result.Duration)
+		}
+		if err != nil {
+			fatal("migrate", err)
+		}
+		if len(results) == 0 {
+			fmt.Println("no pending migrations")
+		}
+
+	case "down":
+		result, err := migrator.Down(ctx)
+		if err != nil {
+			fatal("migrate", err)
+		}
+		fmt.Printf("rolled back %s (%v)\n", result.Source.Path, result.Duration)
+
+	case "status":
+		statuses, err := migrator.Status(ctx)
+		if err != nil {
+			fatal("migrate", err)
+		}
+
+		tw := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(tw, "VERSION\tSTATE\tAPPLIED AT\tSOURCE")
+		for _, status := range statuses {
+			appliedAt := "-"
+			if !status.AppliedAt.IsZero() {
+				appliedAt = status.AppliedAt.Format(time.DateTime)
+			}
+			fmt.Fprintf(tw, "%d\t%s\t%s\t%s\n", status.Source.Version, status.State, appliedAt, status.Source.Path)
+		}
+		tw.Flush()
+
+	default:
+		fmt.Fprintln(os.Stderr, migrateUsage)
+		os.Exit(2)
+	}
+}
+
+// apply migrations if autoMigrate and refuse to start with outdated schema
+func prepareSchema(ctx context.Context, dsn string, autoMigrate bool) {
+	migrator, err := db.NewMigrator(dsn)
+	if err != nil {
+		fatal("migrate", err)
+	}
+	defer migrator.Close()
+
+	if autoMigrate {
+		results, err := migrator.Up(ctx)
+		if err != nil {
+			fatal("migrate", err)
+		}
+		for _, result := range results {
+			slog.Info("applied migration", "source", result.Source.Path, "duration", result.Duration)
+		}
+	}
+
+	err = migrator.CheckVersion(ctx)
+	if err != nil {
+		fatal("check schema version", err)
+	}
+}
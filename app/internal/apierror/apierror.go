@@ -0,0 +1,160 @@
+// error responses of banner API with stable codes and localised messages
+package apierror
+
+import (
+	bannermodels "banner/internal/models/banner"
+	"fmt"
+	"net/http"
+)
+
+// machine readable error code, must not change once published
+type Code string
+
+const (
+	CodeValidationFailed Code = "VALIDATION_FAILED"
+	CodeInvalidJSON      Code = "INVALID_JSON"
+	CodeInvalidBody      Code = "INVALID_BODY"
+	CodeUnauthorized     Code = "UNAUTHORIZED"
+	CodeForbidden        Code = "FORBIDDEN"
+	CodeBannerNotFound   Code = "BANNER_NOT_FOUND"
+	CodeRouteNotFound    Code = "ROUTE_NOT_FOUND"
+	CodeMethodNotAllowed Code = "METHOD_NOT_ALLOWED"
+	CodeSlotConflict     Code = "SLOT_CONFLICT"
+	CodeSlotNotHeld      Code = "SLOT_NOT_HELD"
+	CodeFeatureMismatch  Code = "FEATURE_MISMATCH"
+	CodeDraftNotFound    Code = "DRAFT_NOT_FOUND"
+	CodeDraftNotApproved Code = "DRAFT_NOT_APPROVED"
+	CodeSelfApproval     Code = "SELF_APPROVAL"
+	CodeRateLimited      Code = "RATE_LIMITED"
+	CodeWebhookNotFound  Code = "WEBHOOK_NOT_FOUND"
+	CodeDeliveryNotFound Code = "DELIVERY_NOT_FOUND"
+	CodeInternal         Code = "INTERNAL"
+)
+
+// problem with single field of request
+type FieldError struct {
+	Field   string
+	Message Message
+	// format args of message
+	Args []any
+}
+
+type Error struct {
+	Status  int
+	Code    Code
+	Message Message
+	Details []FieldError
+	// slots held by other banners for SLOT_CONFLICT
+	Conflicts []bannermodels.Slot
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("%v: %v", e.Code, e.Message)
+}
+
+func New(status int, code Code, msg Message) *Error {
+	return &Error{
+		Status:  status,
+		Code:    code,
+		Message: msg,
+	}
+}
+
+func Field(field string, msg Message, args ...any) FieldError {
+	return FieldError{
+		Field:   field,
+		Message: msg,
+		Args:    args,
+	}
+}
+
+func Validation(details ...FieldError) *Error {
+	return &Error{
+		Status:  http.StatusBadRequest,
+		Code:    CodeValidationFailed,
+		Message: MsgValidationFailed,
+		Details: details,
+	}
+}
+
+// validation error for single field
+func Invalid(field string, msg Message, args ...any) *Error {
+	return Validation(Field(field, msg, args...))
+}
+
+func InvalidJSON() *Error {
+	return New(http.StatusBadRequest, CodeInvalidJSON, MsgInvalidJSON)
+}
+
+func InvalidBody() *Error {
+	return New(http.StatusBadRequest, CodeInvalidBody, MsgCantReadBody)
+}
+
+func Unauthorized() *Error {
+	return New(http.StatusUnauthorized, CodeUnauthorized, MsgUnauthorized)
+}
+
+func Forbidden() *Error {
+	return New(http.StatusForbidden, CodeForbidden, MsgForbidden)
+}
+
+func BannerNotFound() *Error {
+	return New(http.StatusNotFound, CodeBannerNotFound, MsgBannerNotFound)
+}
+
+func RouteNotFound() *Error {
+	return New(http.StatusNotFound, CodeRouteNotFound, MsgRouteNotFound)
+}
+
+func MethodNotAllowed() *Error {
+	return New(http.StatusMethodNotAllowed, CodeMethodNotAllowed, MsgMethodNotAllowed)
+}
+
+func SlotConflict(conflicts []bannermodels.Slot) *Error {
+	err := New(http.StatusConflict, CodeSlotConflict, MsgSlotConflict)
+	err.Conflicts = conflicts
+	return err
+}
+
+// import is rolled back, details are lines with taken slots
+func ImportConflict(details ...FieldError) *Error {
+	err := SlotConflict(nil)
+	err.Details = details
+	return err
+}
+
+func SlotNotHeld() *Error {
+	return New(http.StatusConflict, CodeSlotNotHeld, MsgSlotNotHeld)
+}
+
+func FeatureMismatch() *Error {
+	return New(http.StatusConflict, CodeFeatureMismatch, MsgFeatureMismatch)
+}
+
+func DraftNotFound() *Error {
+	return New(http.StatusNotFound, CodeDraftNotFound, MsgDraftNotFound)
+}
+
+func DraftNotApproved() *Error {
+	return New(http.StatusConflict, CodeDraftNotApproved, MsgDraftNotApproved)
+}
+
+func SelfApproval() *Error {
+	return New(http.StatusForbidden, CodeSelfApproval, MsgSelfApproval)
+}
+
+func RateLimited() *Error {
+	return New(http.StatusTooManyRequests, CodeRateLimited, MsgRateLimited)
+}
+
+func WebhookNotFound() *Error {
+	return New(http.StatusNotFound, CodeWebhookNotFound, MsgWebhookNotFound)
+}
+
+func DeliveryNotFound() *Error {
+	return New(http.StatusNotFound, CodeDeliveryNotFound, MsgDeliveryNotFound)
+}
+
+func Internal() *Error {
+	return New(http.StatusInternalServerError, CodeInternal, MsgInternal)
+}
@@ -0,0 +1,250 @@
+package apierror
+
+import (
+	"fmt"
+	"net/http"
+
+	"golang.org/x/text/language"
+)
+
+// key of localised message
+type Message string
+
+type Lang string
+
+const (
+	LangRU Lang = "ru"
+	LangEN Lang = "en"
+
+	// service was russian only before localisation
+	DefaultLang = LangRU
+
+	headerAcceptLanguage = "Accept-Language"
+)
+
+const (
+	MsgValidationFailed Message = "validation_failed"
+	MsgInvalidJSON      Message = "invalid_json"
+	MsgCantReadBody     Message = "cant_read_body"
+	MsgUnauthorized     Message = "unauthorized"
+	MsgForbidden        Message = "forbidden"
+	MsgBannerNotFound   Message = "banner_not_found"
+	MsgRouteNotFound    Message = "route_not_found"
+	MsgMethodNotAllowed Message = "method_not_allowed"
+	MsgSlotConflict     Message = "slot_conflict"
+	MsgSlotNotHeld      Message = "slot_not_held"
+	MsgFeatureMismatch  Message = "feature_mismatch"
+	MsgDraftNotFound    Message = "draft_not_found"
+	MsgDraftNotApproved Message = "draft_not_approved"
+	MsgSelfApproval     Message = "self_approval"
+	MsgRateLimited      Message = "rate_limited"
+	MsgWebhookNotFound  Message = "webhook_not_found"
+	MsgDeliveryNotFound Message = "delivery_not_found"
+	MsgInternal         Message = "internal"
+
+	MsgBadTagID             Message = "bad_tag_id"
+	MsgBadTagIDs            Message = "bad_tag_ids"
+	MsgBadContent           Message = "bad_content"
+	MsgBadFeatureID         Message = "bad_feature_id"
+	MsgBadIsActive          Message = "bad_is_active"
+	MsgBadUseLastRevision   Message = "bad_use_last_revision"
+	MsgBadLimit             Message = "bad_limit"
+	MsgBadOffset            Message = "bad_offset"
+	MsgBadID                Message = "bad_id"
+	MsgBadQuery             Message = "bad_query"
+	MsgBadDate              Message = "bad_date"
+	MsgBadSort              Message = "bad_sort"
+	MsgBadOrder             Message = "bad_order"
+	MsgBadCursor            Message = "bad_cursor"
+	MsgBadCursorLimit       Message = "bad_cursor_limit"
+	MsgBadCursorOffset      Message = "bad_cursor_offset"
+	MsgBadWithTotal         Message = "bad_with_total"
+	MsgBadWithTotalNoCursor Message = "bad_with_total_no_cursor"
+	MsgBadOnConflict        Message = "bad_on_conflict"
+	MsgBadDryRun            Message = "bad_dry_run"
+	MsgBadContentFilter     Message = "bad_content_filter"
+	MsgBadImportLine        Message = "bad_import_line"
+	MsgBadFieldType         Message = "bad_field_type"
+	MsgBadFromBannerID      Message = "bad_from_banner_id"
+	MsgBadToBannerID        Message = "bad_to_banner_id"
+	MsgEmptyTagIDs          Message = "empty_tag_ids"
+	MsgBadTransferMode      Message = "bad_transfer_mode"
+	MsgBadPreview           Message = "bad_preview"
+	MsgBadTrashed           Message = "bad_trashed"
+	MsgToggleNoFilter       Message = "toggle_no_filter"
+	MsgIsActiveRequired     Message = "is_active_required"
+	MsgKillSwitchFeatureID  Message = "kill_switch_feature_id"
+	MsgKillSwitchTagIDs     Message = "kill_switch_tag_ids"
+	MsgKillSwitchIsActive   Message = "kill_switch_is_active"
+	MsgBadBatchSize         Message = "bad_batch_size"
+	MsgBadStreamFeatures    Message = "bad_stream_features"
+	MsgBadWebhookURL        Message = "bad_webhook_url"
+	MsgBadWebhookEvents     Message = "bad_webhook_events"
+	MsgBadWebhookSecret     Message = "bad_webhook_secret"
+	MsgBadDeliveryStatus    Message = "bad_delivery_status"
+	MsgBadAfter             Message = "bad_after"
+	MsgImportLineConflict   Message = "import_line_conflict"
+	MsgBadBannerID          Message = "bad_banner_id"
+)
+
+var catalog = map[Lang]map[Message]string{
+	LangRU: {
+		MsgValidationFailed: "запрос не прошел проверку",
+		MsgInvalidJSON:      "тело запроса не является корректным JSON",
+		MsgCantReadBody:     "не удалось прочитать тело запроса",
+		MsgUnauthorized:     "нужен действительный token",
+		MsgForbidden:        "недостаточно прав",
+		MsgBannerNotFound:   "баннер не найден",
+		MsgRouteNotFound:    "маршрут не найден",
+		MsgMethodNotAllowed: "метод не поддерживается",
+		MsgSlotConflict:     "баннер с такими feature_id и tag_id уже существует",
+		MsgSlotNotHeld:      "не все теги принадлежат баннерам переноса",
+		MsgFeatureMismatch:  "у баннеров разные feature_id",
+		MsgDraftNotFound:    "черновик не найден",
+		MsgDraftNotApproved: "черновик не одобрен",
+		MsgSelfApproval:     "автор не может одобрить свой черновик",
+		MsgRateLimited:      "слишком много запросов, повторите позже",
+		MsgWebhookNotFound:  "webhook не найден",
+		MsgDeliveryNotFound: "доставка не найдена",
+		MsgInternal:         "внутренняя ошибка сервера",
+
+		MsgBadTagID:             "tag_id должен быть целым числом",
+		MsgBadTagIDs:            "tag_ids должен быть массивом целых чисел",
+		MsgBadContent:           "content должен быть структурой",
+		MsgBadFeatureID:         "feature_id должен быть целым числом",
+		MsgBadIsActive:          "is_active должен быть типа boolean",
+		MsgBadUseLastRevision:   "use_last_revision должен быть типа boolean",
+		MsgBadLimit:             "limit должен быть целым числом >= 0",
+		MsgBadOffset:            "offset должен быть целым числом >= 0",
+		MsgBadID:                "id должен быть целым числом",
+		MsgBadQuery:             "q не должен быть пустым",
+		MsgBadDate:              "дата должна быть в формате RFC3339",
+		MsgBadSort:              "sort должен быть одним из: created_at, updated_at, id, feature_id",
+		MsgBadOrder:             "order должен быть asc или desc",
+		MsgBadCursor:            "cursor некорректен или не соответствует sort и order",
+		MsgBadCursorLimit:       "limit должен быть целым числом >= 1 при использовании cursor",
+		MsgBadCursorOffset:      "offset нельзя использовать вместе с cursor",
+		MsgBadWithTotal:         "with_total должен быть типа boolean",
+		MsgBadWithTotalNoCursor: "with_total можно использовать только вместе с cursor",
+		MsgBadOnConflict:        "on_conflict должен быть upsert или fail",
+		MsgBadDryRun:            "dry_run должен быть типа boolean",
+		MsgBadContentFilter:     "фильтр по content должен иметь вид content.<путь>=<значение> или content.<путь>[contains]=<значение>",
+		MsgBadImportLine:        "строка %d: %v",
+		MsgBadFieldType:         "поле должно быть типа %v",
+		MsgBadFromBannerID:      "from_banner_id должен быть целым числом > 0",
+		MsgBadToBannerID:        "to_banner_id должен быть целым числом > 0 и отличаться от from_banner_id",
+		MsgEmptyTagIDs:          "tag_ids должен быть непустым массивом целых чисел",
+		MsgBadTransferMode:      "mode должен быть move или swap",
+		MsgBadPreview:           "preview может быть только draft",
+		MsgBadTrashed:           "trashed должен быть типа boolean",
+		MsgToggleNoFilter:       "нужен feature_id или непустой tag_ids",
+		MsgIsActiveRequired:     "is_active обязателен",
+		MsgKillSwitchFeatureID:  "kill_switch требует feature_id",
+		MsgKillSwitchTagIDs:     "kill_switch выключает всю фичу, tag_ids не указывается",
+		MsgKillSwitchIsActive:   "kill_switch только выключает баннеры, is_active может быть только false",
+		MsgBadBatchSize:         "slots должен содержать от 1 до %d элементов",
+		MsgBadStreamFeatures:    "feature_id должен быть указан от 1 до %d раз",
+		MsgBadWebhookURL:        "url должен быть абсолютным http или https адресом",
+		MsgBadWebhookEvents:     "events должен быть массивом из: %v",
+		MsgBadWebhookSecret:     "secret должен быть от %d до %d символов",
+		MsgBadDeliveryStatus:    "status должен быть pending, delivered или dead",
+		MsgBadAfter:             "after должен быть целым числом >= 0",
+		MsgImportLineConflict:   "строка %d: слоты заняты баннерами %v",
+		MsgBadBannerID:          "banner_id должен быть целым числом > 0",
+	},
+	LangEN: {
+		MsgValidationFailed: "request validation failed",
+		MsgInvalidJSON:      "request body is not valid JSON",
+		MsgCantReadBody:     "can not read request body",
+		MsgUnauthorized:     "valid token is required",
+		MsgForbidden:        "not enough permissions",
+		MsgBannerNotFound:   "banner not found",
+		MsgRouteNotFound:    "route not found",
+		MsgMethodNotAllowed: "method not allowed",
+		MsgSlotConflict:     "banner with such feature_id and tag_id already exists",
+		MsgSlotNotHeld:      "not all tags are held by banners of transfer",
+		MsgFeatureMismatch:  "banners have different feature_id",
+		MsgDraftNotFound:    "draft not found",
+		MsgDraftNotApproved: "draft is not approved",
+		MsgSelfApproval:     "author can not approve own draft",
+		MsgRateLimited:      "too many requests, retry later",
+		MsgWebhookNotFound:  "webhook not found",
+		MsgDeliveryNotFound: "delivery not found",
+		MsgInternal:         "internal server error",
+
+		MsgBadTagID:             "tag_id must be an integer",
+		MsgBadTagIDs:            "tag_ids must be an array of integers",
+		MsgBadContent:           "content must be an object",
+		MsgBadFeatureID:         "feature_id must be an integer",
+		MsgBadIsActive:          "is_active must be a boolean",
+		MsgBadUseLastRevision:   "use_last_revision must be a boolean",
+		MsgBadLimit:             "limit must be an integer >= 0",
+		MsgBadOffset:            "offset must be an integer >= 0",
+		MsgBadID:                "id must be an integer",
+		MsgBadQuery:             "q must not be empty",
+		MsgBadDate:              "date must be in RFC3339 format",
+		MsgBadSort:              "sort must be one of: created_at, updated_at, id, feature_id",
+		MsgBadOrder:             "order must be asc or desc",
+		MsgBadCursor:            "cursor is malformed or does not match sort and order",
+		MsgBadCursorLimit:       "limit must be an integer >= 1 when cursor is used",
+		MsgBadCursorOffset:      "offset can not be used with cursor",
+		MsgBadWithTotal:         "with_total must be a boolean",
+		MsgBadWithTotalNoCursor: "with_total can be used only with cursor",
+		MsgBadOnConflict:        "on_conflict must be upsert or fail",
+		MsgBadDryRun:            "dry_run must be a boolean",
+		MsgBadContentFilter:     "content filter must look like content.<path>=<value> or content.<path>[contains]=<value>",
+		MsgBadImportLine:        "line %d: %v",
+		MsgBadFieldType:         "field must be of type %v",
+		MsgBadFromBannerID:      "from_banner_id must be an integer > 0",
+		MsgBadToBannerID:        "to_banner_id must be an integer > 0 other than from_banner_id",
+		MsgEmptyTagIDs:          "tag_ids must be a non-empty array of integers",
+		MsgBadTransferMode:      "mode must be move or swap",
+		MsgBadPreview:           "preview can only be draft",
+		MsgBadTrashed:           "trashed must be a boolean",
+		MsgToggleNoFilter:       "feature_id or non-empty tag_ids is required",
+		MsgIsActiveRequired:     "is_active is required",
+		MsgKillSwitchFeatureID:  "kill_switch requires feature_id",
+		MsgKillSwitchTagIDs:     "kill_switch turns off whole feature, tag_ids must not be set",
+		MsgKillSwitchIsActive:   "kill_switch only deactivates banners, is_active can only be false",
+		MsgBadBatchSize:         "slots must have 1 to %d items",
+		MsgBadStreamFeatures:    "feature_id must be given 1 to %d times",
+		MsgBadWebhookURL:        "url must be an absolute http or https URL",
+		MsgBadWebhookEvents:     "events must be an array of: %v",
+		MsgBadWebhookSecret:     "secret must be %d to %d characters long",
+		MsgBadDeliveryStatus:    "status must be pending, delivered or dead",
+		MsgBadAfter:             "after must be an integer >= 0",
+		MsgImportLineConflict:   "line %d: slots are taken by banners %v",
+		MsgBadBannerID:          "banner_id must be an integer > 0",
+	},
+}
+
+var (
+	supportedLangs = []Lang{LangRU, LangEN}
+	langMatcher    = language.NewMatcher([]language.Tag{language.Russian, language.English})
+)
+
+// language of response by Accept-Language header
+func LangFromRequest(r *http.Request) Lang {
+	header := r.Header.Get(headerAcceptLanguage)
+	if header == "" {
+		return DefaultLang
+	}
+
+	_, index := language.MatchStrings(langMatcher, header)
+	return supportedLangs[index]
+}
+
+func Localize(lang Lang, msg Message, args ...any) string {
+	text, ok := catalog[lang][msg]
+	if !ok {
+		text, ok = catalog[DefaultLang][msg]
+	}
+	if !ok {
+		text = string(msg)
+	}
+
+	if len(args) != 0 {
+		return fmt.Sprintf(text, args...)
+	}
+	return text
+}
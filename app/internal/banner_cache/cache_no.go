@@ -21,3 +21,7 @@ func (c *BannerNoCache) GetBanner(ctx context.Context, tagID int, featureID int)
 func (c *BannerNoCache) SetBanner(ctx context.Context, tagID int, featureID int, banner bannermodels.Banner) error {
 	return nil
 }
+
+func (c *BannerNoCache) DeleteBanner(ctx context.Context, tagID int, featureID int) error {
+	return nil
+}
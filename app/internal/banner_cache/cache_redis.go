@@ -3,49 +3,55 @@ package cache
 import (
 	bannermodels "banner/internal/models/banner"
 	"banner/internal/service"
-	"encoding/json"
+	"banner/internal/tracing"
 	"errors"
 	"time"
 
 	"context"
 
 	"github.com/redis/go-redis/v9"
+	"go.opentelemetry.io/otel/attribute"
 )
 
 // TODO change add logic
 type BannerRedisCache struct {
 	client           *redis.Client
 	bannerExpiration time.Duration
+	// write msgpack+zstd instead of JSON, both are read
+	compact bool
 }
 
-func NewBannerRedisCahe(client *redis.Client, bannerExpiration time.Duration) *BannerRedisCache {
+func NewBannerRedisCahe(client *redis.Client, bannerExpiration time.Duration, compact bool) *BannerRedisCache {
 	return &BannerRedisCache{
 		client:           client,
 		bannerExpiration: bannerExpiration,
+		compact:          compact,
 	}
 }
 
 func (c *BannerRedisCache) GetBanner(ctx context.Context, tagID int, featureID int) (bannermodels.Banner, error) {
-	data, err := c.client.Get(ctx, formKeyFromTagIDFeatureID(tagID, featureID)).Result()
+	ctx, span := startSpan(ctx, "GetBanner", tagID, featureID)
+	defer span.End()
 
+	data, err := c.client.Get(ctx, formKeyFromTagIDFeatureID(tagID, featureID)).Bytes()
+
+	span.SetAttributes(attribute.Bool("cache.hit", err == nil))
 	switch {
 	case errors.Is(err, redis.Nil):
 		return bannermodels.Banner{}, service.ErrCacheBannerNotFound
 	case err != nil:
+		tracing.RecordError(span, err)
 		return bannermodels.Banner{}, err
 	}
 
-	var banner bannermodels.Banner
-	err = json.Unmarshal([]byte(data), &banner)
-	if err != nil {
-		return bannermodels.Banner{}, err
-	}
-
-	return banner, nil
+	return decodeBanner(data)
 }
 
 func (c *BannerRedisCache) SetBanner(ctx context.Context, tagID int, featureID int, banner bannermodels.Banner) error {
-	bannerBytes, err := json.Marshal(banner)
+	ctx, span := startSpan(ctx, "SetBanner", tagID, featureID)
+	defer span.End()
+
+	bannerBytes, err := encodeBanner(banner, c.compact)
 	if err != nil {
 		return err
 	}
@@ -57,5 +63,17 @@ func (c *BannerRedisCache) SetBanner(ctx context.Context, tagID int, featureID i
 		c.bannerExpiration,
 	).Err()
 
+	tracing.RecordError(span, err)
+	return err
+}
+
+// drop cached banner of slot, so next read goes to db
+func (c *BannerRedisCache) DeleteBanner(ctx context.Context, tagID int, featureID int) error {
+	ctx, span := startSpan(ctx, "DeleteBanner", tagID, featureID)
+	defer span.End()
+
+	err := c.client.Del(ctx, formKeyFromTagIDFeatureID(tagID, featureID)).Err()
+
+	tracing.RecordError(span, err)
 	return err
 }
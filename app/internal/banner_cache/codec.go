@@ -0,0 +1,66 @@
+package cache
+
+import (
+	bannermodels "banner/internal/models/banner"
+	"bytes"
+	"encoding/json"
+	"fmt"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/vmihailenco/msgpack/v5"
+)
+
+const (
+	EncodingJSON    = "json"
+	EncodingCompact = "compact"
+
+	// compact values start with it, JSON values always start with '{'
+	compactMarker byte = 0x01
+)
+
+// encoder and decoder are safe for concurrent EncodeAll and DecodeAll
+var (
+	zstdEncoder, _ = zstd.NewWriter(nil, zstd.WithEncoderConcurrency(1))
+	zstdDecoder, _ = zstd.NewReader(nil, zstd.WithDecoderConcurrency(0))
+)
+
+// JSON or marker byte followed by zstd compressed msgpack
+func encodeBanner(banner bannermodels.Banner, compact bool) ([]byte, error) {
+	if !compact {
+		return json.Marshal(banner)
+	}
+
+	var buf bytes.Buffer
+	encoder := msgpack.NewEncoder(&buf)
+	encoder.SetCustomStructTag("json")
+	if err := encoder.Encode(banner); err != nil {
+		return nil, err
+	}
+
+	value := make([]byte, 1, 1+buf.Len()/2)
+	value[0] = compactMarker
+	return zstdEncoder.EncodeAll(buf.Bytes(), value), nil
+}
+
+// values of both encodings are read whatever encoding is configured,
+// so encoding can be switched without flushing cache
+func decodeBanner(value []byte) (bannermodels.Banner, error) {
+	var banner bannermodels.Banner
+
+	if len(value) == 0 || value[0] != compactMarker {
+		err := json.Unmarshal(value, &banner)
+		return banner, err
+	}
+
+	raw, err := zstdDecoder.DecodeAll(value[1:], nil)
+	if err != nil {
+		return bannermodels.Banner{}, fmt.Errorf("decompress cached banner: %w", err)
+	}
+
+	decoder := msgpack.NewDecoder(bytes.NewReader(raw))
+	decoder.SetCustomStructTag("json")
+	if err := decoder.Decode(&banner); err != nil {
+		return bannermodels.Banner{}, fmt.Errorf("decode cached banner: %w", err)
+	}
+	return banner, nil
+}
@@ -0,0 +1,60 @@
+package cache
+
+import (
+	bannermodels "banner/internal/models/banner"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDecodeBannerOfBothEncodings(t *testing.T) {
+	// arrange
+	at := time.Date(2024, 4, 10, 12, 0, 0, 0, time.UTC)
+	expected := bannermodels.Banner{
+		ID:        7,
+		TagIDs:    []int{1, 2},
+		FeatureID: 3,
+		Content:   map[string]interface{}{"title": "some_title"},
+		IsActive:  false,
+		CreatedAt: at,
+		UpdatedAt: at,
+	}
+
+	// value written by release before compact encoding
+	oldJSON := []byte(`{"banner_id":7,"tag_ids":[1,2],"feature_id":3,"content":{"title":"some_title"},` +
+		`"is_active":false,"created_at":"2024-04-10T12:00:00Z","updated_at":"2024-04-10T12:00:00Z"}`)
+
+	// reading does not depend on configured encoding, values of every setting
+	// must be read by replicas with either setting during rollout
+	for _, compact := range []bool{false, true} {
+		written, err := encodeBanner(expected, compact)
+		require.NoError(t, err)
+		assert.Equal(t, compact, written[0] == compactMarker)
+
+		for name, value := range map[string][]byte{"old json": oldJSON, "written": written} {
+			// act
+			banner, err := decodeBanner(value)
+
+			// assert
+			require.NoError(t, err, name)
+			assert.Equal(t, expected.ID, banner.ID, name)
+			assert.Equal(t, expected.TagIDs, banner.TagIDs, name)
+			assert.Equal(t, expected.FeatureID, banner.FeatureID, name)
+			assert.Equal(t, expected.Content, banner.Content, name)
+			assert.Equal(t, expected.IsActive, banner.IsActive, name)
+			assert.True(t, expected.UpdatedAt.Equal(banner.UpdatedAt), name)
+		}
+	}
+}
+
+func TestDecodeBannerBroken(t *testing.T) {
+	for name, value := range map[string][]byte{
+		"json":    []byte(`{"banner_id":`),
+		"compact": {compactMarker, 0x00, 0x01},
+	} {
+		_, err := decodeBanner(value)
+		assert.Error(t, err, name)
+	}
+}
@@ -1,6 +1,28 @@
 package cache
 
-import "fmt"
+import (
+	"context"
+	"fmt"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var tracer = otel.Tracer("banner/internal/banner_cache")
+
+func startSpan(ctx context.Context, method string, tagID int, featureID int) (context.Context, trace.Span) {
+	return tracer.Start(
+		ctx,
+		"BannerRedisCache."+method,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			attribute.String("db.system", "redis"),
+			attribute.Int("banner.tag_id", tagID),
+			attribute.Int("banner.feature_id", featureID),
+		),
+	)
+}
 
 func formKeyFromTagIDFeatureID(tagID int, featureID int) string {
 	return fmt.Sprintf("%d,%d", tagID, featureID)
@@ -0,0 +1,81 @@
+package cache
+
+import (
+	bannermodels "banner/internal/models/banner"
+	"banner/internal/service"
+	"context"
+	"errors"
+)
+
+const (
+	cacheOpGet = "get"
+	cacheOpSet = "set"
+	cacheOpDel = "delete"
+
+	cacheResultHit   = "hit"
+	cacheResultMiss  = "miss"
+	cacheResultOK    = "ok"
+	cacheResultError = "error"
+)
+
+type bannerCache interface {
+	GetBanner(ctx context.Context, tagID int, featureID int) (bannermodels.Banner, error)
+	SetBanner(ctx context.Context, tagID int, featureID int, banner bannermodels.Banner) error
+	DeleteBanner(ctx context.Context, tagID int, featureID int) error
+}
+
+type cacheObserver interface {
+	ObserveCacheOp(op string, result string)
+}
+
+// cache wrapper that counts hits, misses and errors of wrapped cache
+type InstrumentedCache struct {
+	cache    bannerCache
+	observer cacheObserver
+}
+
+func NewInstrumentedCache(cache bannerCache, observer cacheObserver) *InstrumentedCache {
+	return &InstrumentedCache{
+		cache:    cache,
+		observer: observer,
+	}
+}
+
+func (c *InstrumentedCache) GetBanner(ctx context.Context, tagID int, featureID int) (bannermodels.Banner, error) {
+	banner, err := c.cache.GetBanner(ctx, tagID, featureID)
+
+	switch {
+	case err == nil:
+		c.observer.ObserveCacheOp(cacheOpGet, cacheResultHit)
+	case errors.Is(err, service.ErrCacheBannerNotFound):
+		c.observer.ObserveCacheOp(cacheOpGet, cacheResultMiss)
+	default:
+		c.observer.ObserveCacheOp(cacheOpGet, cacheResultError)
+	}
+
+	return banner, err
+}
+
+func (c *InstrumentedCache) SetBanner(ctx context.Context, tagID int, featureID int, banner bannermodels.Banner) error {
+	err := c.cache.SetBanner(ctx, tagID, featureID, banner)
+
+	if err != nil {
+		c.observer.ObserveCacheOp(cacheOpSet, cacheResultError)
+	} else {
+		c.observer.ObserveCacheOp(cacheOpSet, cacheResultOK)
+	}
+
+	return err
+}
+
+func (c *InstrumentedCache) DeleteBanner(ctx context.Context, tagID int, featureID int) error {
+	err := c.cache.DeleteBanner(ctx, tagID, featureID)
+
+	if err != nil {
+		c.observer.ObserveCacheOp(cacheOpDel, cacheResultError)
+	} else {
+		c.observer.ObserveCacheOp(cacheOpDel, cacheResultOK)
+	}
+
+	return err
+}
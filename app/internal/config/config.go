@@ -0,0 +1,443 @@
+// Service configuration: defaults, YAML file, env vars and command line flags,
+// each next source overrides the previous one
+package config
+
+import (
+	cache "banner/internal/banner_cache"
+	"banner/internal/logging"
+	"banner/internal/ratelimit"
+	"banner/internal/tracing"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	usermodels "banner/internal/models/user"
+)
+
+type Config struct {
+	HTTP        HTTPConfig        `yaml:"http"`
+	GRPC        GRPCConfig        `yaml:"grpc"`
+	Postgres    PostgresConfig    `yaml:"postgres"`
+	Redis       RedisConfig       `yaml:"redis"`
+	Cache       CacheConfig       `yaml:"cache"`
+	Compression CompressionConfig `yaml:"compression"`
+	Stream      StreamConfig      `yaml:"stream"`
+	Auth        AuthConfig        `yaml:"auth"`
+	RateLimit   RateLimitConfig   `yaml:"rate_limit"`
+	Log         LogConfig         `yaml:"log"`
+	Tracing     TracingConfig     `yaml:"tracing"`
+	Trash       TrashConfig       `yaml:"trash"`
+	Events      EventsConfig      `yaml:"events"`
+	Webhook     WebhookConfig     `yaml:"webhook"`
+	Migrate     MigrateConfig     `yaml:"migrate"`
+}
+
+// zero timeout means no timeout
+type HTTPConfig struct {
+	Addr              string        `yaml:"addr"`
+	AdminAddr         string        `yaml:"admin_addr"`
+	ReadHeaderTimeout time.Duration `yaml:"read_header_timeout"`
+	ReadTimeout       time.Duration `yaml:"read_timeout"`
+	WriteTimeout      time.Duration `yaml:"write_timeout"`
+	IdleTimeout       time.Duration `yaml:"idle_timeout"`
+	// time to finish in-flight requests after SIGTERM or SIGINT
+	ShutdownTimeout time.Duration `yaml:"shutdown_timeout"`
+}
+
+// gRPC API for internal services, it is stopped with HTTP server
+type GRPCConfig struct {
+	Enabled bool   `yaml:"enabled"`
+	Addr    string `yaml:"addr"`
+}
+
+type PostgresConfig struct {
+	DSN             string        `yaml:"dsn"`
+	MaxConns        int           `yaml:"max_conns"`
+	MinConns        int           `yaml:"min_conns"`
+	MaxConnLifetime time.Duration `yaml:"max_conn_lifetime"`
+	MaxConnIdleTime time.Duration `yaml:"max_conn_idle_time"`
+}
+
+type RedisConfig struct {
+	Addr     string `yaml:"addr"`
+	Password string `yaml:"password"`
+	DB       int    `yaml:"db"`
+	// 0 is default of redis client, 10 per CPU
+	PoolSize     int           `yaml:"pool_size"`
+	DialTimeout  time.Duration `yaml:"dial_timeout"`
+	ReadTimeout  time.Duration `yaml:"read_timeout"`
+	WriteTimeout time.Duration `yaml:"write_timeout"`
+}
+
+type CacheConfig struct {
+	// banners are read from postgres on every request if disabled
+	Enabled   bool          `yaml:"enabled"`
+	BannerTTL time.Duration `yaml:"banner_ttl"`
+	// json or compact (msgpack+zstd), values of both are read
+	Encoding string `yaml:"encoding"`
+}
+
+// gzip or zstd of HTTP responses by Accept-Encoding
+type CompressionConfig struct {
+	Enabled bool `yaml:"enabled"`
+	// smaller bodies are sent uncompressed
+	MinSize int `yaml:"min_size"`
+}
+
+// server-sent events of /user_banner/stream
+type StreamConfig struct {
+	// comment sent to idle streams, so proxies do not close them
+	Heartbeat time.Duration `yaml:"heartbeat"`
+	// banners are read again this often to see changes made by other replicas, 0 disables
+	ResyncInterval time.Duration `yaml:"resync_interval"`
+}
+
+type AuthConfig struct {
+	UserToken string `yaml:"user_token"`
+	// token of admin named "admin"
+	AdminToken string `yaml:"admin_token"`
+	// named admins "name:token,name2:token2"
+	AdminTokens string `yaml:"admin_tokens"`
+	// HS256 secret of JWT accepted by gRPC API, JWT is disabled if empty
+	JWTSecret string `yaml:"jwt_secret"`
+}
+
+// rate is tokens per second, burst is bucket size
+type RateLimitConfig struct {
+	Enabled bool `yaml:"enabled"`
+	// memory limits each replica, redis shares buckets between replicas
+	Backend    string  `yaml:"backend"`
+	ReadRate   float64 `yaml:"read_rate"`
+	ReadBurst  int     `yaml:"read_burst"`
+	WriteRate  float64 `yaml:"write_rate"`
+	WriteBurst int     `yaml:"write_burst"`
+	// per identity limits "name:read_rate:read_burst:write_rate:write_burst,name2:..."
+	Overrides string `yaml:"overrides"`
+}
+
+type LogConfig struct {
+	Level string `yaml:"level"`
+}
+
+type TracingConfig struct {
+	Exporter     string  `yaml:"exporter"`
+	OTLPEndpoint string  `yaml:"otlp_endpoint"`
+	OTLPInsecure bool    `yaml:"otlp_insecure"`
+	File         string  `yaml:"file"`
+	SampleRatio  float64 `yaml:"sample_ratio"`
+}
+
+type TrashConfig struct {
+	PurgeEnabled  bool          `yaml:"purge_enabled"`
+	Retention     time.Duration `yaml:"retention"`
+	PurgeInterval time.Duration `yaml:"purge_interval"`
+}
+
+type EventsConfig struct {
+	// pass events of log to sinks and purge expired ones, log is written anyway
+	PublisherEnabled bool          `yaml:"publisher_enabled"`
+	PollInterval     time.Duration `yaml:"poll_interval"`
+	BatchSize        int           `yaml:"batch_size"`
+	Retention        time.Duration `yaml:"retention"`
+}
+
+type WebhookConfig struct {
+	// run webhook sink and dispatcher, subscriptions and deliveries are kept anyway
+	Enabled      bool          `yaml:"enabled"`
+	PollInterval time.Duration `yaml:"poll_interval"`
+	BatchSize    int           `yaml:"batch_size"`
+	Timeout      time.Duration `yaml:"timeout"`
+	MaxAttempts  int           `yaml:"max_attempts"`
+	MinBackoff   time.Duration `yaml:"min_backoff"`
+	MaxBackoff   time.Duration `yaml:"max_backoff"`
+	// time delivered deliveries are kept
+	Retention time.Duration `yaml:"retention"`
+}
+
+type MigrateConfig struct {
+	// apply pending migrations before start
+	Auto bool `yaml:"auto"`
+}
+
+func Default() Config {
+	return Config{
+		HTTP: HTTPConfig{
+			Addr:              ":9000",
+			AdminAddr:         ":9100",
+			ReadHeaderTimeout: 5 * time.Second,
+			ReadTimeout:       30 * time.Second,
+			// export streams whole banner table
+			WriteTimeout:    5 * time.Minute,
+			IdleTimeout:     2 * time.Minute,
+			ShutdownTimeout: 30 * time.Second,
+		},
+		GRPC: GRPCConfig{
+			Enabled: true,
+			Addr:    ":9090",
+		},
+		Postgres: PostgresConfig{
+			MaxConns:        10,
+			MinConns:        0,
+			MaxConnLifetime: time.Hour,
+			MaxConnIdleTime: 30 * time.Minute,
+		},
+		Redis: RedisConfig{
+			DialTimeout:  5 * time.Second,
+			ReadTimeout:  3 * time.Second,
+			WriteTimeout: 3 * time.Second,
+		},
+		Cache: CacheConfig{
+			Enabled:   true,
+			BannerTTL: 5 * time.Minute,
+			Encoding:  cache.EncodingJSON,
+		},
+		Compression: CompressionConfig{
+			Enabled: true,
+			MinSize: 1024,
+		},
+		Stream: StreamConfig{
+			Heartbeat:      15 * time.Second,
+			ResyncInterval: time.Minute,
+		},
+		RateLimit: RateLimitConfig{
+			// all users share one token and so one bucket, enable it with limit of whole user traffic
+			Enabled:    false,
+			Backend:    ratelimit.BackendMemory,
+			ReadRate:   1000,
+			ReadBurst:  2000,
+			WriteRate:  20,
+			WriteBurst: 40,
+		},
+		Log: LogConfig{
+			Level: "info",
+		},
+		Tracing: TracingConfig{
+			Exporter:     tracing.ExporterNone,
+			OTLPInsecure: false,
+			SampleRatio:  1,
+		},
+		Trash: TrashConfig{
+			PurgeEnabled:  true,
+			Retention:     30 * 24 * time.Hour,
+			PurgeInterval: time.Hour,
+		},
+		Events: EventsConfig{
+			PublisherEnabled: true,
+			PollInterval:     time.Second,
+			BatchSize:        100,
+			Retention:        30 * 24 * time.Hour,
+		},
+		Webhook: WebhookConfig{
+			Enabled:      true,
+			PollInterval: time.Second,
+			BatchSize:    50,
+			Timeout:      10 * time.Second,
+			MaxAttempts:  10,
+			MinBackoff:   10 * time.Second,
+			MaxBackoff:   time.Hour,
+			Retention:    7 * 24 * time.Hour,
+		},
+	}
+}
+
+// check all values, every problem is reported in returned error
+func (c Config) Validate() error {
+	var errs []error
+	check := func(ok bool, key string, format string, args ...any) {
+		if !ok {
+			errs = append(errs, fmt.Errorf("%s: %s", key, fmt.Sprintf(format, args...)))
+		}
+	}
+
+	check(c.HTTP.Addr != "", "http.addr", "must be set")
+	check(c.HTTP.AdminAddr != "", "http.admin_addr", "must be set")
+	check(c.HTTP.AdminAddr != c.HTTP.Addr, "http.admin_addr", "must differ from http.addr")
+	check(c.HTTP.ReadHeaderTimeout >= 0, "http.read_header_timeout", "must be >= 0")
+	check(c.HTTP.ReadTimeout >= 0, "http.read_timeout", "must be >= 0")
+	check(c.HTTP.WriteTimeout >= 0, "http.write_timeout", "must be >= 0")
+	check(c.HTTP.IdleTimeout >= 0, "http.idle_timeout", "must be >= 0")
+	check(c.HTTP.ShutdownTimeout > 0, "http.shutdown_timeout", "must be > 0")
+
+	if c.GRPC.Enabled {
+		check(c.GRPC.Addr != "", "grpc.addr", "must be set")
+		check(c.GRPC.Addr != c.HTTP.Addr && c.GRPC.Addr != c.HTTP.AdminAddr, "grpc.addr", "must differ from http.addr and http.admin_addr")
+	}
+
+	errs = append(errs, c.ValidatePostgres())
+	check(c.Postgres.MaxConns >= 1, "postgres.max_conns", "must be >= 1")
+	check(c.Postgres.MinConns >= 0 && c.Postgres.MinConns <= c.Postgres.MaxConns, "postgres.min_conns", "must be in [0, postgres.max_conns]")
+	check(c.Postgres.MaxConnLifetime >= 0, "postgres.max_conn_lifetime", "must be >= 0")
+	check(c.Postgres.MaxConnIdleTime >= 0, "postgres.max_conn_idle_time", "must be >= 0")
+
+	check(c.Redis.Addr != "" || !c.UsesRedis(), "redis.addr", "must be set if cache or redis rate limit is enabled")
+	check(c.Redis.DB >= 0, "redis.db", "must be >= 0")
+	check(c.Redis.PoolSize >= 0, "redis.pool_size", "must be >= 0")
+	check(c.Redis.DialTimeout >= 0, "redis.dial_timeout", "must be >= 0")
+	check(c.Redis.ReadTimeout >= 0, "redis.read_timeout", "must be >= 0")
+	check(c.Redis.WriteTimeout >= 0, "redis.write_timeout", "must be >= 0")
+
+	check(c.Cache.BannerTTL > 0, "cache.banner_ttl", "must be > 0")
+	check(c.Cache.Encoding == cache.EncodingJSON || c.Cache.Encoding == cache.EncodingCompact,
+		"cache.encoding", "must be json or compact, got %q", c.Cache.Encoding)
+
+	check(c.Compression.MinSize >= 0, "compression.min_size", "must be >= 0")
+
+	check(c.Stream.Heartbeat > 0, "stream.heartbeat", "must be > 0")
+	check(c.Stream.ResyncInterval >= 0, "stream.resync_interval", "must be >= 0")
+
+	check(c.Auth.UserToken != "", "auth.user_token", "must be set")
+	check(c.Auth.JWTSecret == "" || len(c.Auth.JWTSecret) >= 32, "auth.jwt_secret", "must be at least 32 bytes")
+	if _, err := c.Auth.Admins(); err != nil {
+		errs = append(errs, err)
+	}
+
+	check(c.RateLimit.Backend == ratelimit.BackendMemory || c.RateLimit.Backend == ratelimit.BackendRedis,
+		"rate_limit.backend", "must be memory or redis, got %q", c.RateLimit.Backend)
+	if _, err := c.RateLimit.Policy(); err != nil {
+		errs = append(errs, err)
+	}
+
+	if _, err := logging.ParseLevel(c.Log.Level); err != nil {
+		errs = append(errs, fmt.Errorf("log.level: %w", err))
+	}
+
+	switch c.Tracing.Exporter {
+	case tracing.ExporterNone, tracing.ExporterStdout:
+	case tracing.ExporterOTLP:
+		check(c.Tracing.OTLPEndpoint != "", "tracing.otlp_endpoint", "must be set for otlp exporter")
+	case tracing.ExporterFile:
+		check(c.Tracing.File != "", "tracing.file", "must be set for file exporter")
+	default:
+		check(false, "tracing.exporter", "must be none, otlp, stdout or file, got %q", c.Tracing.Exporter)
+	}
+	check(c.Tracing.SampleRatio >= 0 && c.Tracing.SampleRatio <= 1, "tracing.sample_ratio", "must be in [0, 1]")
+
+	check(c.Trash.Retention > 0, "trash.retention", "must be > 0")
+	check(c.Trash.PurgeInterval > 0, "trash.purge_interval", "must be > 0")
+
+	check(c.Events.PollInterval > 0, "events.poll_interval", "must be > 0")
+	check(c.Events.BatchSize >= 1, "events.batch_size", "must be >= 1")
+	check(c.Events.Retention > 0, "events.retention", "must be > 0")
+
+	check(c.Webhook.PollInterval > 0, "webhook.poll_interval", "must be > 0")
+	check(c.Webhook.BatchSize >= 1, "webhook.batch_size", "must be >= 1")
+	check(c.Webhook.Timeout > 0, "webhook.timeout", "must be > 0")
+	check(c.Webhook.MaxAttempts >= 1, "webhook.max_attempts", "must be >= 1")
+	check(c.Webhook.MinBackoff > 0, "webhook.min_backoff", "must be > 0")
+	check(c.Webhook.MaxBackoff >= c.Webhook.MinBackoff, "webhook.max_backoff", "must be >= webhook.min_backoff")
+	check(c.Webhook.Retention > 0, "webhook.retention", "must be > 0")
+
+	return errors.Join(errs...)
+}
+
+// only postgres is needed for migrate command
+func (c Config) ValidatePostgres() error {
+	if c.Postgres.DSN == "" {
+		return errors.New("postgres.dsn: must be set")
+	}
+	return nil
+}
+
+// redis client is needed by cache and shared rate limits
+func (c Config) UsesRedis() bool {
+	return c.Cache.Enabled || (c.RateLimit.Enabled && c.RateLimit.Backend == ratelimit.BackendRedis)
+}
+
+// Map admin token to admin name, admin_token is admin named "admin".
+// Names tell admins apart, e.g. for draft approval.
+func (a AuthConfig) Admins() (map[string]string, error) {
+	tokens := make(map[string]string)
+	if a.AdminToken != "" {
+		tokens[a.AdminToken] = usermodels.NameAdmin
+	}
+
+	if a.AdminTokens != "" {
+		for _, pair := range strings.Split(a.AdminTokens, ",") {
+			name, token, ok := strings.Cut(strings.TrimSpace(pair), ":")
+			if !ok || name == "" || token == "" {
+				return nil, fmt.Errorf("auth.admin_tokens: admin token must be name:token, got %q", pair)
+			}
+			if _, dup := tokens[token]; dup {
+				return nil, fmt.Errorf("auth.admin_tokens: admin token of %q is already used", name)
+			}
+			tokens[token] = name
+		}
+	}
+
+	if len(tokens) == 0 {
+		return nil, errors.New("auth.admin_token: admin_token or admin_tokens must be set")
+	}
+	return tokens, nil
+}
+
+func (r RateLimitConfig) Policy() (ratelimit.Policy, error) {
+	policy := ratelimit.Policy{
+		Read:      ratelimit.Limit{Rate: r.ReadRate, Burst: r.ReadBurst},
+		Write:     ratelimit.Limit{Rate: r.WriteRate, Burst: r.WriteBurst},
+		Overrides: make(map[string]ratelimit.Override),
+	}
+
+	if err := checkLimit(policy.Read); err != nil {
+		return ratelimit.Policy{}, fmt.Errorf("rate_limit.read_rate, rate_limit.read_burst: %w", err)
+	}
+	if err := checkLimit(policy.Write); err != nil {
+		return ratelimit.Policy{}, fmt.Errorf("rate_limit.write_rate, rate_limit.write_burst: %w", err)
+	}
+
+	if r.Overrides == "" {
+		return policy, nil
+	}
+	for _, item := range strings.Split(r.Overrides, ",") {
+		parts := strings.Split(strings.TrimSpace(item), ":")
+		if len(parts) != 5 || parts[0] == "" {
+			return ratelimit.Policy{}, fmt.Errorf("rate_limit.overrides: override must be name:read_rate:read_burst:write_rate:write_burst, got %q", item)
+		}
+
+		read, readErr := parseLimit(parts[1], parts[2])
+		write, writeErr := parseLimit(parts[3], parts[4])
+		if readErr != nil || writeErr != nil {
+			return ratelimit.Policy{}, fmt.Errorf("rate_limit.overrides: override must be name:read_rate:read_burst:write_rate:write_burst, got %q", item)
+		}
+
+		if err := checkLimit(read); err != nil {
+			return ratelimit.Policy{}, fmt.Errorf("rate_limit.overrides: %s: read: %w", parts[0], err)
+		}
+		if err := checkLimit(write); err != nil {
+			return ratelimit.Policy{}, fmt.Errorf("rate_limit.overrides: %s: write: %w", parts[0], err)
+		}
+		policy.Overrides[parts[0]] = ratelimit.Override{Read: read, Write: write}
+	}
+
+	return policy, nil
+}
+
+func parseLimit(rate string, burst string) (ratelimit.Limit, error) {
+	rateValue, err := strconv.ParseFloat(rate, 64)
+	if err != nil {
+		return ratelimit.Limit{}, err
+	}
+	burstValue, err := strconv.Atoi(burst)
+	if err != nil {
+		return ratelimit.Limit{}, err
+	}
+	return ratelimit.Limit{Rate: rateValue, Burst: burstValue}, nil
+}
+
+func checkLimit(limit ratelimit.Limit) error {
+	if limit.Rate <= 0 || limit.Burst < 1 {
+		return errors.New("rate must be > 0 and burst >= 1")
+	}
+	return nil
+}
+
+func (t TracingConfig) SetupConfig() tracing.Config {
+	return tracing.Config{
+		Exporter:     t.Exporter,
+		OTLPEndpoint: t.OTLPEndpoint,
+		OTLPInsecure: t.OTLPInsecure,
+		FilePath:     t.File,
+		SampleRatio:  t.SampleRatio,
+	}
+}
@@ -0,0 +1,229 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	configFlagName = "config"
+	configEnv      = "CONFIG_FILE"
+)
+
+// knob of config, key is flag name and path in YAML file
+type option struct {
+	key    string
+	env    string
+	secret bool
+	usage  string
+	bind   func(fs *flag.FlagSet, c *Config, key string, usage string)
+}
+
+func stringOpt(key string, env string, usage string, field func(c *Config) *string) option {
+	return option{key: key, env: env, usage: usage, bind: func(fs *flag.FlagSet, c *Config, key string, usage string) {
+		p := field(c)
+		fs.StringVar(p, key, *p, usage)
+	}}
+}
+
+func secretOpt(key string, env string, usage string, field func(c *Config) *string) option {
+	opt := stringOpt(key, env, usage, field)
+	opt.secret = true
+	return opt
+}
+
+func intOpt(key string, env string, usage string, field func(c *Config) *int) option {
+	return option{key: key, env: env, usage: usage, bind: func(fs *flag.FlagSet, c *Config, key string, usage string) {
+		p := field(c)
+		fs.IntVar(p, key, *p, usage)
+	}}
+}
+
+func boolOpt(key string, env string, usage string, field func(c *Config) *bool) option {
+	return option{key: key, env: env, usage: usage, bind: func(fs *flag.FlagSet, c *Config, key string, usage string) {
+		p := field(c)
+		fs.BoolVar(p, key, *p, usage)
+	}}
+}
+
+func floatOpt(key string, env string, usage string, field func(c *Config) *float64) option {
+	return option{key: key, env: env, usage: usage, bind: func(fs *flag.FlagSet, c *Config, key string, usage string) {
+		p := field(c)
+		fs.Float64Var(p, key, *p, usage)
+	}}
+}
+
+func durationOpt(key string, env string, usage string, field func(c *Config) *time.Duration) option {
+	return option{key: key, env: env, usage: usage, bind: func(fs *flag.FlagSet, c *Config, key string, usage string) {
+		p := field(c)
+		fs.DurationVar(p, key, *p, usage)
+	}}
+}
+
+// env names of knobs that existed before config file are kept
+var options = []option{
+	stringOpt("http.addr", "HOST_PORT", "listen address of public API", func(c *Config) *string { return &c.HTTP.Addr }),
+	stringOpt("http.admin_addr", "ADMIN_PORT", "listen address of metrics", func(c *Config) *string { return &c.HTTP.AdminAddr }),
+	durationOpt("http.read_header_timeout", "HTTP_READ_HEADER_TIMEOUT", "time to read request headers", func(c *Config) *time.Duration { return &c.HTTP.ReadHeaderTimeout }),
+	durationOpt("http.read_timeout", "HTTP_READ_TIMEOUT", "time to read whole request", func(c *Config) *time.Duration { return &c.HTTP.ReadTimeout }),
+	durationOpt("http.write_timeout", "HTTP_WRITE_TIMEOUT", "time to write response", func(c *Config) *time.Duration { return &c.HTTP.WriteTimeout }),
+	durationOpt("http.idle_timeout", "HTTP_IDLE_TIMEOUT", "keep-alive idle time", func(c *Config) *time.Duration { return &c.HTTP.IdleTimeout }),
+	durationOpt("http.shutdown_timeout", "HTTP_SHUTDOWN_TIMEOUT", "time to finish in-flight requests on shutdown", func(c *Config) *time.Duration { return &c.HTTP.ShutdownTimeout }),
+
+	boolOpt("grpc.enabled", "GRPC_ENABLED", "serve gRPC API", func(c *Config) *bool { return &c.GRPC.Enabled }),
+	stringOpt("grpc.addr", "GRPC_ADDR", "listen address of gRPC API", func(c *Config) *string { return &c.GRPC.Addr }),
+
+	secretOpt("postgres.dsn", "POSTGRES_DB_DSN", "postgres connection string", func(c *Config) *string { return &c.Postgres.DSN }),
+	intOpt("postgres.max_conns", "POSTGRES_MAX_CONNS", "max size of connection pool", func(c *Config) *int { return &c.Postgres.MaxConns }),
+	intOpt("postgres.min_conns", "POSTGRES_MIN_CONNS", "min size of connection pool", func(c *Config) *int { return &c.Postgres.MinConns }),
+	durationOpt("postgres.max_conn_lifetime", "POSTGRES_MAX_CONN_LIFETIME", "connection is closed after this time", func(c *Config) *time.Duration { return &c.Postgres.MaxConnLifetime }),
+	durationOpt("postgres.max_conn_idle_time", "POSTGRES_MAX_CONN_IDLE_TIME", "idle connection is closed after this time", func(c *Config) *time.Duration { return &c.Postgres.MaxConnIdleTime }),
+
+	stringOpt("redis.addr", "REDIS_ADDR", "redis host:port", func(c *Config) *string { return &c.Redis.Addr }),
+	secretOpt("redis.password", "REDIS_PASSWORD", "redis password", func(c *Config) *string { return &c.Redis.Password }),
+	intOpt("redis.db", "REDIS_DB", "redis database number", func(c *Config) *int { return &c.Redis.DB }),
+	intOpt("redis.pool_size", "REDIS_POOL_SIZE", "redis connection pool size, 0 for client default", func(c *Config) *int { return &c.Redis.PoolSize }),
+	durationOpt("redis.dial_timeout", "REDIS_DIAL_TIMEOUT", "redis dial timeout", func(c *Config) *time.Duration { return &c.Redis.DialTimeout }),
+	durationOpt("redis.read_timeout", "REDIS_READ_TIMEOUT", "redis read timeout", func(c *Config) *time.Duration { return &c.Redis.ReadTimeout }),
+	durationOpt("redis.write_timeout", "REDIS_WRITE_TIMEOUT", "redis write timeout", func(c *Config) *time.Duration { return &c.Redis.WriteTimeout }),
+
+	boolOpt("cache.enabled", "CACHE_ENABLED", "cache user banners in redis", func(c *Config) *bool { return &c.Cache.Enabled }),
+	stringOpt("cache.encoding", "CACHE_ENCODING", "json or compact (msgpack+zstd) values in redis", func(c *Config) *string { return &c.Cache.Encoding }),
+	durationOpt("cache.banner_ttl", "CACHE_BANNER_TTL", "time user banner lives in cache", func(c *Config) *time.Duration { return &c.Cache.BannerTTL }),
+
+	boolOpt("compression.enabled", "COMPRESSION_ENABLED", "gzip or zstd responses by Accept-Encoding", func(c *Config) *bool { return &c.Compression.Enabled }),
+	intOpt("compression.min_size", "COMPRESSION_MIN_SIZE", "smaller response bodies are not compressed", func(c *Config) *int { return &c.Compression.MinSize }),
+
+	durationOpt("stream.heartbeat", "STREAM_HEARTBEAT", "interval of comments sent to idle banner streams", func(c *Config) *time.Duration { return &c.Stream.Heartbeat }),
+	durationOpt("stream.resync_interval", "STREAM_RESYNC_INTERVAL", "interval of reading streamed banners again, 0 disables", func(c *Config) *time.Duration { return &c.Stream.ResyncInterval }),
+
+	secretOpt("auth.user_token", "USER_TOKEN", "token of users", func(c *Config) *string { return &c.Auth.UserToken }),
+	secretOpt("auth.admin_token", "ADMIN_TOKEN", "token of admin named admin", func(c *Config) *string { return &c.Auth.AdminToken }),
+	secretOpt("auth.admin_tokens", "ADMIN_TOKENS", "named admin tokens name:token,name2:token2", func(c *Config) *string { return &c.Auth.AdminTokens }),
+	secretOpt("auth.jwt_secret", "JWT_SECRET", "HS256 secret of JWT for gRPC API", func(c *Config) *string { return &c.Auth.JWTSecret }),
+
+	boolOpt("rate_limit.enabled", "RATE_LIMIT_ENABLED", "limit requests per token", func(c *Config) *bool { return &c.RateLimit.Enabled }),
+	stringOpt("rate_limit.backend", "RATE_LIMIT_BACKEND", "memory for limits per replica or redis for shared ones", func(c *Config) *string { return &c.RateLimit.Backend }),
+	floatOpt("rate_limit.read_rate", "RATE_LIMIT_READ_RATE", "read requests per second of each token", func(c *Config) *float64 { return &c.RateLimit.ReadRate }),
+	intOpt("rate_limit.read_burst", "RATE_LIMIT_READ_BURST", "burst of reads of each token", func(c *Config) *int { return &c.RateLimit.ReadBurst }),
+	floatOpt("rate_limit.write_rate", "RATE_LIMIT_WRITE_RATE", "write requests per second of each token", func(c *Config) *float64 { return &c.RateLimit.WriteRate }),
+	intOpt("rate_limit.write_burst", "RATE_LIMIT_WRITE_BURST", "burst of writes of each token", func(c *Config) *int { return &c.RateLimit.WriteBurst }),
+	stringOpt("rate_limit.overrides", "RATE_LIMIT_OVERRIDES", "limits of named tokens name:read_rate:read_burst:write_rate:write_burst,name2:...", func(c *Config) *string { return &c.RateLimit.Overrides }),
+
+	stringOpt("log.level", "LOG_LEVEL", "debug, info, warn or error", func(c *Config) *string { return &c.Log.Level }),
+
+	stringOpt("tracing.exporter", "TRACING_EXPORTER", "none, otlp, stdout or file", func(c *Config) *string { return &c.Tracing.Exporter }),
+	stringOpt("tracing.otlp_endpoint", "TRACING_OTLP_ENDPOINT", "host:port of OTLP/HTTP collector", func(c *Config) *string { return &c.Tracing.OTLPEndpoint }),
+	boolOpt("tracing.otlp_insecure", "TRACING_OTLP_INSECURE", "send traces without TLS", func(c *Config) *bool { return &c.Tracing.OTLPInsecure }),
+	stringOpt("tracing.file", "TRACING_FILE", "output of file exporter", func(c *Config) *string { return &c.Tracing.File }),
+	floatOpt("tracing.sample_ratio", "TRACING_SAMPLE_RATIO", "part of new traces to sample", func(c *Config) *float64 { return &c.Tracing.SampleRatio }),
+
+	boolOpt("trash.purge_enabled", "TRASH_PURGE_ENABLED", "purge expired banners from trash", func(c *Config) *bool { return &c.Trash.PurgeEnabled }),
+	durationOpt("trash.retention", "TRASH_RETENTION", "time banner stays in trash", func(c *Config) *time.Duration { return &c.Trash.Retention }),
+	durationOpt("trash.purge_interval", "TRASH_PURGE_INTERVAL", "interval of trash purge", func(c *Config) *time.Duration { return &c.Trash.PurgeInterval }),
+
+	boolOpt("events.publisher_enabled", "EVENTS_PUBLISHER_ENABLED", "pass banner events to sinks", func(c *Config) *bool { return &c.Events.PublisherEnabled }),
+	durationOpt("events.poll_interval", "EVENTS_POLL_INTERVAL", "interval of polling new events", func(c *Config) *time.Duration { return &c.Events.PollInterval }),
+	intOpt("events.batch_size", "EVENTS_BATCH_SIZE", "events passed to sink at once", func(c *Config) *int { return &c.Events.BatchSize }),
+	durationOpt("events.retention", "EVENTS_RETENTION", "time events are kept", func(c *Config) *time.Duration { return &c.Events.Retention }),
+
+	boolOpt("webhook.enabled", "WEBHOOK_ENABLED", "write and send webhook deliveries", func(c *Config) *bool { return &c.Webhook.Enabled }),
+	durationOpt("webhook.poll_interval", "WEBHOOK_POLL_INTERVAL", "interval of polling due deliveries", func(c *Config) *time.Duration { return &c.Webhook.PollInterval }),
+	intOpt("webhook.batch_size", "WEBHOOK_BATCH_SIZE", "deliveries sent concurrently", func(c *Config) *int { return &c.Webhook.BatchSize }),
+	durationOpt("webhook.timeout", "WEBHOOK_TIMEOUT", "timeout of webhook request", func(c *Config) *time.Duration { return &c.Webhook.Timeout }),
+	intOpt("webhook.max_attempts", "WEBHOOK_MAX_ATTEMPTS", "attempts before delivery is dead", func(c *Config) *int { return &c.Webhook.MaxAttempts }),
+	durationOpt("webhook.min_backoff", "WEBHOOK_MIN_BACKOFF", "delay before first retry", func(c *Config) *time.Duration { return &c.Webhook.MinBackoff }),
+	durationOpt("webhook.max_backoff", "WEBHOOK_MAX_BACKOFF", "max delay between retries", func(c *Config) *time.Duration { return &c.Webhook.MaxBackoff }),
+	durationOpt("webhook.retention", "WEBHOOK_RETENTION", "time delivered deliveries are kept", func(c *Config) *time.Duration { return &c.Webhook.Retention }),
+
+	boolOpt("migrate.auto", "AUTO_MIGRATE", "apply pending migrations before start", func(c *Config) *bool { return &c.Migrate.Auto }),
+}
+
+// flag set bound to fields of c, it also sets values of env vars and file keys
+func newFlagSet(name string, c *Config) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	for _, opt := range options {
+		usage := opt.usage
+		if opt.env != "" {
+			usage += " (env " + opt.env + ")"
+		}
+		opt.bind(fs, c, opt.key, usage)
+	}
+	return fs
+}
+
+// Load config for command name from defaults, YAML file, env vars and args, in that order.
+// File is given by -config flag or CONFIG_FILE env var. Config is not validated.
+// Positional args after flags are returned.
+func Load(name string, args []string, output io.Writer) (Config, []string, error) {
+	// flags are parsed first to find config file, but applied last,
+	// scratch has defaults only to show them in usage
+	scratch := Default()
+	fs := newFlagSet(name, &scratch)
+	fs.SetOutput(output)
+	configPath := fs.String(configFlagName, os.Getenv(configEnv), "YAML config file (env "+configEnv+")")
+
+	err := fs.Parse(args)
+	if err != nil {
+		return Config{}, nil, err
+	}
+
+	cfg := Default()
+
+	if *configPath != "" {
+		err = loadFile(*configPath, &cfg)
+		if err != nil {
+			return Config{}, nil, err
+		}
+	}
+
+	bound := newFlagSet(name, &cfg)
+
+	for _, opt := range options {
+		value, ok := os.LookupEnv(opt.env)
+		if !ok {
+			continue
+		}
+		if err := bound.Set(opt.key, value); err != nil {
+			return Config{}, nil, fmt.Errorf("env %s: %w", opt.env, err)
+		}
+	}
+
+	fs.Visit(func(f *flag.Flag) {
+		if err != nil || f.Name == configFlagName {
+			return
+		}
+		if setErr := bound.Set(f.Name, f.Value.String()); setErr != nil {
+			err = fmt.Errorf("flag -%s: %w", f.Name, setErr)
+		}
+	})
+	if err != nil {
+		return Config{}, nil, err
+	}
+
+	return cfg, fs.Args(), nil
+}
+
+// unknown keys are errors, so typos are not silently ignored
+func loadFile(path string, cfg *Config) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("config file: %w", err)
+	}
+
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+
+	err = decoder.Decode(cfg)
+	if err != nil && !errors.Is(err, io.EOF) {
+		return fmt.Errorf("config file %s: %w", path, err)
+	}
+	return nil
+}
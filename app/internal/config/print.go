@@ -0,0 +1,32 @@
+package config
+
+import (
+	"io"
+
+	"gopkg.in/yaml.v3"
+)
+
+const redacted = "<redacted>"
+
+// copy of config with secrets replaced, empty secrets are kept empty
+func (c Config) Redacted() Config {
+	fs := newFlagSet("redacted", &c)
+	for _, opt := range options {
+		if !opt.secret {
+			continue
+		}
+		if f := fs.Lookup(opt.key); f != nil && f.Value.String() != "" {
+			fs.Set(opt.key, redacted)
+		}
+	}
+	return c
+}
+
+// write config as YAML with secrets redacted
+func (c Config) Print(w io.Writer) error {
+	encoder := yaml.NewEncoder(w)
+	encoder.SetIndent(2)
+	defer encoder.Close()
+
+	return encoder.Encode(c.Redacted())
+}
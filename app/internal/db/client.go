@@ -2,12 +2,43 @@ package db
 
 import (
 	"context"
+	"time"
 
 	"github.com/jackc/pgx/v4/pgxpool"
 )
 
+// zero fields keep pgxpool defaults
+type PoolConfig struct {
+	MaxConns        int
+	MinConns        int
+	MaxConnLifetime time.Duration
+	MaxConnIdleTime time.Duration
+}
+
 func NewDB(ctx context.Context, dsn string) (*Database, error) {
-	pool, err := pgxpool.Connect(ctx, dsn)
+	return NewDBWithPool(ctx, dsn, PoolConfig{})
+}
+
+func NewDBWithPool(ctx context.Context, dsn string, poolCfg PoolConfig) (*Database, error) {
+	cfg, err := pgxpool.ParseConfig(dsn)
+	if err != nil {
+		return nil, err
+	}
+
+	if poolCfg.MaxConns != 0 {
+		cfg.MaxConns = int32(poolCfg.MaxConns)
+	}
+	if poolCfg.MinConns != 0 {
+		cfg.MinConns = int32(poolCfg.MinConns)
+	}
+	if poolCfg.MaxConnLifetime != 0 {
+		cfg.MaxConnLifetime = poolCfg.MaxConnLifetime
+	}
+	if poolCfg.MaxConnIdleTime != 0 {
+		cfg.MaxConnIdleTime = poolCfg.MaxConnIdleTime
+	}
+
+	pool, err := pgxpool.ConnectConfig(ctx, cfg)
 	if err != nil {
 		return nil, err
 	}
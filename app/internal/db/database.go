@@ -42,3 +42,15 @@ func (db Database) Exec(ctx context.Context, query string, args ...interface{})
 func (db Database) QueryRow(ctx context.Context, query string, args ...interface{}) pgx.Row {
 	return db.pool.QueryRow(ctx, query, args...)
 }
+
+func (db Database) Query(ctx context.Context, query string, args ...interface{}) (pgx.Rows, error) {
+	return db.pool.Query(ctx, query, args...)
+}
+
+func (db *Database) Stat() *pgxpool.Stat {
+	return db.pool.Stat()
+}
+
+func (db *Database) Ping(ctx context.Context) error {
+	return db.pool.Ping(ctx)
+}
@@ -0,0 +1,98 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/pressly/goose/v3"
+	"github.com/pressly/goose/v3/lock"
+
+	// registers pgx driver for database/sql
+	_ "github.com/jackc/pgx/v4/stdlib"
+
+	"banner/internal/db/migrations"
+)
+
+var ErrSchemaBehind = errors.New("database schema version is behind the code")
+
+// Applies embedded migrations. Every operation holds postgres advisory lock,
+// so replicas started at the same time do not migrate concurrently.
+type Migrator struct {
+	provider *goose.Provider
+}
+
+func NewMigrator(dsn string) (*Migrator, error) {
+	sqlDB, err := sql.Open("pgx", dsn)
+	if err != nil {
+		return nil, err
+	}
+
+	locker, err := lock.NewPostgresSessionLocker()
+	if err != nil {
+		sqlDB.Close()
+		return nil, err
+	}
+
+	provider, err := goose.NewProvider(
+		goose.DialectPostgres,
+		sqlDB,
+		migrations.FS,
+		goose.WithSessionLocker(locker),
+	)
+	if err != nil {
+		sqlDB.Close()
+		return nil, err
+	}
+
+	return &Migrator{provider: provider}, nil
+}
+
+func (m *Migrator) Close() error {
+	return m.provider.Close()
+}
+
+// apply all pending migrations
+func (m *Migrator) Up(ctx context.Context) ([]*goose.MigrationResult, error) {
+	return m.provider.Up(ctx)
+}
+
+// roll back the last applied migration
+func (m *Migrator) Down(ctx context.Context) (*goose.MigrationResult, error) {
+	return m.provider.Down(ctx)
+}
+
+func (m *Migrator) Status(ctx context.Context) ([]*goose.MigrationStatus, error) {
+	return m.provider.Status(ctx)
+}
+
+// version of the latest embedded migration
+func (m *Migrator) ExpectedVersion() int64 {
+	var version int64
+	for _, source := range m.provider.ListSources() {
+		if source.Version > version {
+			version = source.Version
+		}
+	}
+	return version
+}
+
+func (m *Migrator) Version(ctx context.Context) (int64, error) {
+	return m.provider.GetDBVersion(ctx)
+}
+
+// return ErrSchemaBehind if some embedded migrations are not applied,
+// newer schema is allowed so that previous release can run during rollout
+func (m *Migrator) CheckVersion(ctx context.Context) error {
+	version, err := m.Version(ctx)
+	if err != nil {
+		return err
+	}
+
+	expected := m.ExpectedVersion()
+	if version < expected {
+		return fmt.Errorf("%w: got %d, expected %d", ErrSchemaBehind, version, expected)
+	}
+	return nil
+}
@@ -0,0 +1,23 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+
+-- full-text search over string values of content (q param)
+CREATE INDEX IF NOT EXISTS banner_content_tsv
+    ON banner USING GIN (jsonb_to_tsvector('simple', content, '["string"]'));
+
+-- equality predicates on content paths (content @> '{"url": "..."}')
+CREATE INDEX IF NOT EXISTS banner_content_path
+    ON banner USING GIN (content jsonb_path_ops);
+
+-- prefilter for substring predicates on content paths
+CREATE INDEX IF NOT EXISTS banner_content_trgm
+    ON banner USING GIN ((content::text) gin_trgm_ops);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS banner_content_trgm;
+DROP INDEX IF EXISTS banner_content_path;
+DROP INDEX IF EXISTS banner_content_tsv;
+-- +goose StatementEnd
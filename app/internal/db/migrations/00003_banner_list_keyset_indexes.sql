@@ -0,0 +1,14 @@
+-- +goose Up
+-- +goose StatementBegin
+-- keyset pagination of banner list, (sort key, id) for every sort option
+CREATE INDEX IF NOT EXISTS banner_created_at_id ON banner (created_at, id);
+CREATE INDEX IF NOT EXISTS banner_updated_at_id ON banner (updated_at, id);
+CREATE INDEX IF NOT EXISTS banner_feature_id_id ON banner (feature_id, id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS banner_feature_id_id;
+DROP INDEX IF EXISTS banner_updated_at_id;
+DROP INDEX IF EXISTS banner_created_at_id;
+-- +goose StatementEnd
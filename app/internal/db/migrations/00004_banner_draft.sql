@@ -0,0 +1,35 @@
+-- +goose Up
+-- +goose StatementBegin
+-- unpublished copy of banner, at most one per banner
+CREATE TABLE IF NOT EXISTS banner_draft (
+	banner_id INT PRIMARY KEY REFERENCES banner ON DELETE CASCADE,
+	tag_ids INT[] NOT NULL,
+	feature_id INT NOT NULL,
+	is_active BOOL NOT NULL,
+	content jsonb NOT NULL,
+	author TEXT NOT NULL,
+	approved_by TEXT,
+	approved_at TIMESTAMP,
+	created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+	updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- preview lookup by (tag_id, feature_id)
+CREATE INDEX IF NOT EXISTS banner_draft_feature_tags ON banner_draft USING GIN (tag_ids);
+
+ALTER TABLE banner
+	ADD COLUMN IF NOT EXISTS published_by TEXT,
+	ADD COLUMN IF NOT EXISTS approved_by TEXT,
+	ADD COLUMN IF NOT EXISTS published_at TIMESTAMP;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE banner
+	DROP COLUMN IF EXISTS published_at,
+	DROP COLUMN IF EXISTS approved_by,
+	DROP COLUMN IF EXISTS published_by;
+
+DROP INDEX IF EXISTS banner_draft_feature_tags;
+DROP TABLE IF EXISTS banner_draft;
+-- +goose StatementEnd
@@ -0,0 +1,19 @@
+-- +goose Up
+-- +goose StatementBegin
+-- deleted banner stays in trash without relations until it is restored or purged
+ALTER TABLE banner
+	ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMP,
+	ADD COLUMN IF NOT EXISTS deleted_by TEXT;
+
+-- trash listing and purge of expired banners
+CREATE INDEX IF NOT EXISTS banner_deleted_at ON banner (deleted_at) WHERE deleted_at IS NOT NULL;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS banner_deleted_at;
+
+ALTER TABLE banner
+	DROP COLUMN IF EXISTS deleted_by,
+	DROP COLUMN IF EXISTS deleted_at;
+-- +goose StatementEnd
@@ -0,0 +1,12 @@
+-- +goose Up
+-- +goose StatementBegin
+-- banner the content was copied from, lineage is kept until source is purged
+ALTER TABLE banner
+	ADD COLUMN IF NOT EXISTS cloned_from INT REFERENCES banner ON DELETE SET NULL;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE banner
+	DROP COLUMN IF EXISTS cloned_from;
+-- +goose StatementEnd
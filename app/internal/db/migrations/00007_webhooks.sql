@@ -0,0 +1,42 @@
+-- +goose Up
+-- +goose StatementBegin
+-- subscriptions of admins to banner changes, null feature_id or tag_id matches any
+CREATE TABLE IF NOT EXISTS webhook (
+	id SERIAL PRIMARY KEY,
+	url TEXT NOT NULL,
+	secret TEXT NOT NULL,
+	feature_id INT,
+	tag_id INT,
+	-- empty matches any event
+	events TEXT[] NOT NULL DEFAULT '{}',
+	created_by TEXT NOT NULL,
+	created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- outbox of webhook requests, rows are written in transaction of banner change
+CREATE TABLE IF NOT EXISTS webhook_delivery (
+	id BIGSERIAL PRIMARY KEY,
+	webhook_id INT NOT NULL REFERENCES webhook ON DELETE CASCADE,
+	event TEXT NOT NULL,
+	payload jsonb NOT NULL,
+	-- pending, delivered or dead
+	status TEXT NOT NULL DEFAULT 'pending',
+	attempts INT NOT NULL DEFAULT 0,
+	next_attempt_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+	last_error TEXT,
+	last_status_code INT,
+	created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+	delivered_at TIMESTAMP
+);
+
+-- dispatcher takes due pending deliveries
+CREATE INDEX IF NOT EXISTS webhook_delivery_pending ON webhook_delivery (next_attempt_at) WHERE status = 'pending';
+-- deliveries of webhook, newest first
+CREATE INDEX IF NOT EXISTS webhook_delivery_webhook ON webhook_delivery (webhook_id, id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS webhook_delivery;
+DROP TABLE IF EXISTS webhook;
+-- +goose StatementEnd
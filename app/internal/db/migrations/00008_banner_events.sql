@@ -0,0 +1,37 @@
+-- +goose Up
+-- +goose StatementBegin
+-- log of committed banner changes, rows are written in transaction of change
+-- in commit order, so id is a cursor for readers
+CREATE TABLE IF NOT EXISTS banner_events (
+	id BIGSERIAL PRIMARY KEY,
+	type TEXT NOT NULL,
+	banner_id INT NOT NULL,
+	-- states of banner before and after change
+	payload jsonb NOT NULL,
+	occurred_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- purge of expired events
+CREATE INDEX IF NOT EXISTS banner_events_occurred_at ON banner_events (occurred_at);
+
+-- last event handled by each sink of publisher
+CREATE TABLE IF NOT EXISTS banner_event_cursor (
+	sink TEXT PRIMARY KEY,
+	last_event_id BIGINT NOT NULL DEFAULT 0,
+	updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- deliveries are written by webhook sink, event is enqueued once for webhook
+-- even if sink gets it again
+ALTER TABLE webhook_delivery ADD COLUMN IF NOT EXISTS event_id BIGINT;
+CREATE UNIQUE INDEX IF NOT EXISTS webhook_delivery_event ON webhook_delivery (webhook_id, event_id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS webhook_delivery_event;
+ALTER TABLE webhook_delivery DROP COLUMN IF EXISTS event_id;
+
+DROP TABLE IF EXISTS banner_event_cursor;
+DROP TABLE IF EXISTS banner_events;
+-- +goose StatementEnd
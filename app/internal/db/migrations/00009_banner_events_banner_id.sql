@@ -0,0 +1,10 @@
+-- +goose Up
+-- +goose StatementBegin
+-- history of one banner in /events?banner_id=
+CREATE INDEX IF NOT EXISTS banner_events_banner_id ON banner_events (banner_id, id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS banner_events_banner_id;
+-- +goose StatementEnd
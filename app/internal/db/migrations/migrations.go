@@ -0,0 +1,7 @@
+// SQL migrations of the service database, embedded into the binary
+package migrations
+
+import "embed"
+
+//go:embed *.sql
+var FS embed.FS
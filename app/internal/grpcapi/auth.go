@@ -0,0 +1,126 @@
+package grpcapi
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/golang-jwt/jwt/v5"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	usermodels "banner/internal/models/user"
+)
+
+const (
+	metadataToken         = "token"
+	metadataAuthorization = "authorization"
+	bearerPrefix          = "Bearer "
+
+	roleAdmin = "admin"
+	roleUser  = "user"
+)
+
+type userKeyT string
+
+const userKey userKeyT = "grpc user"
+
+// claims of JWT signed with shared HS256 secret
+type claims struct {
+	Role string `json:"role"`
+	jwt.RegisteredClaims
+}
+
+// Same static tokens as HTTP API, and JWT if secret is set.
+// JWT subject is user name, role is "admin" or "user".
+type Authenticator struct {
+	userToken   string
+	adminTokens map[string]string
+	jwtSecret   []byte
+}
+
+// adminTokens maps token to admin name, empty jwtSecret disables JWT
+func NewAuthenticator(userToken string, adminTokens map[string]string, jwtSecret string) *Authenticator {
+	return &Authenticator{
+		userToken:   userToken,
+		adminTokens: adminTokens,
+		jwtSecret:   []byte(jwtSecret),
+	}
+}
+
+func (a *Authenticator) authenticate(md metadata.MD) (usermodels.User, error) {
+	if values := md.Get(metadataAuthorization); len(values) != 0 && len(a.jwtSecret) != 0 {
+		raw, ok := strings.CutPrefix(values[0], bearerPrefix)
+		if !ok {
+			return usermodels.User{}, errors.New("authorization must be Bearer token")
+		}
+		return a.parseJWT(raw)
+	}
+
+	values := md.Get(metadataToken)
+	if len(values) == 0 || values[0] == "" {
+		return usermodels.User{}, errors.New("token or authorization metadata is required")
+	}
+
+	token := values[0]
+	if name, ok := a.adminTokens[token]; ok {
+		return usermodels.User{Name: name, IsAdmin: true}, nil
+	}
+	if token == a.userToken {
+		return usermodels.User{Name: usermodels.NameUser}, nil
+	}
+	return usermodels.User{}, errors.New("unknown token")
+}
+
+func (a *Authenticator) parseJWT(raw string) (usermodels.User, error) {
+	var c claims
+	_, err := jwt.ParseWithClaims(
+		raw,
+		&c,
+		func(*jwt.Token) (any, error) { return a.jwtSecret, nil },
+		jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}),
+		jwt.WithExpirationRequired(),
+	)
+	if err != nil {
+		return usermodels.User{}, err
+	}
+
+	if c.Subject == "" {
+		return usermodels.User{}, errors.New("jwt subject is required")
+	}
+	switch c.Role {
+	case roleAdmin:
+		return usermodels.User{Name: c.Subject, IsAdmin: true}, nil
+	case roleUser:
+		return usermodels.User{Name: c.Subject}, nil
+	default:
+		return usermodels.User{}, errors.New("jwt role must be admin or user")
+	}
+}
+
+// Put authenticated user to context, methods not in public need admin.
+func (a *Authenticator) UnaryInterceptor(public map[string]bool) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		md, _ := metadata.FromIncomingContext(ctx)
+
+		user, err := a.authenticate(md)
+		if err != nil {
+			return nil, status.Error(codes.Unauthenticated, err.Error())
+		}
+		if !user.IsAdmin && !public[info.FullMethod] {
+			return nil, status.Error(codes.PermissionDenied, "admin token is required")
+		}
+
+		return handler(context.WithValue(ctx, userKey, user), req)
+	}
+}
+
+func userFromContext(ctx context.Context) (usermodels.User, error) {
+	user, ok := ctx.Value(userKey).(usermodels.User)
+	if !ok {
+		return usermodels.User{}, status.Error(codes.Internal, "user is not authenticated")
+	}
+	return user, nil
+}
@@ -0,0 +1,42 @@
+package grpcapi
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"banner/internal/apierror"
+	"banner/internal/service"
+)
+
+// map service error to status like sendError of HTTP handler maps it to API error
+func toStatus(ctx context.Context, err error) error {
+	var conflictErr *service.SlotConflictError
+	switch {
+	case err == nil:
+		return nil
+	case errors.Is(err, service.ErrBannerNotFound):
+		return status.Error(codes.NotFound, localize(apierror.MsgBannerNotFound))
+	case errors.As(err, &conflictErr), errors.Is(err, service.ErrBannerAlreadyExists):
+		return status.Error(codes.AlreadyExists, localize(apierror.MsgSlotConflict))
+	case errors.Is(err, context.Canceled):
+		return status.Error(codes.Canceled, err.Error())
+	case errors.Is(err, context.DeadlineExceeded):
+		return status.Error(codes.DeadlineExceeded, err.Error())
+	default:
+		slog.ErrorContext(ctx, "internal error", "err", err)
+		return status.Error(codes.Internal, localize(apierror.MsgInternal))
+	}
+}
+
+func invalidArgument(msg apierror.Message, args ...any) error {
+	return status.Error(codes.InvalidArgument, localize(msg, args...))
+}
+
+// gRPC clients are services, so messages are in English
+func localize(msg apierror.Message, args ...any) string {
+	return apierror.Localize(apierror.LangEN, msg, args...)
+}
@@ -0,0 +1,62 @@
+package grpcapi
+
+import (
+	"context"
+	"log/slog"
+	"math"
+	"strconv"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	"banner/internal/ratelimit"
+)
+
+// same buckets as HTTP API, must run after auth interceptor
+func RateLimitInterceptor(limiter ratelimit.Limiter, policy ratelimit.Policy) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		user, err := userFromContext(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		key, limit := policy.For(user.Name, !readMethods[info.FullMethod])
+		result, err := limiter.Allow(ctx, key, limit)
+		if err != nil {
+			slog.WarnContext(ctx, "rate limit", "err", err)
+			return handler(ctx, req)
+		}
+
+		if !result.Allowed {
+			retryAfter := strconv.Itoa(int(math.Ceil(result.RetryAfter.Seconds())))
+			_ = grpc.SetHeader(ctx, metadata.Pairs("retry-after", retryAfter))
+			return nil, status.Error(codes.ResourceExhausted, "rate limit exceeded, retry after "+retryAfter+"s")
+		}
+
+		return handler(ctx, req)
+	}
+}
+
+// log every call like access log of HTTP API
+func LogInterceptor(logger *slog.Logger) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		start := time.Now()
+		resp, err := handler(ctx, req)
+
+		code := status.Code(err)
+		level := slog.LevelInfo
+		if code == codes.Internal || code == codes.Unknown {
+			level = slog.LevelError
+		}
+
+		logger.Log(ctx, level, "grpc call",
+			"method", info.FullMethod,
+			"code", code.String(),
+			"duration", time.Since(start),
+		)
+		return resp, err
+	}
+}
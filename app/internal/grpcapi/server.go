@@ -0,0 +1,232 @@
+// gRPC API of banner service, implemented on the same service layer as HTTP API
+package grpcapi
+
+import (
+	"context"
+	"errors"
+
+	"google.golang.org/protobuf/types/known/structpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"banner/internal/apierror"
+	"banner/internal/grpcapi/bannerpb"
+	bannermodels "banner/internal/models/banner"
+	usermodels "banner/internal/models/user"
+	"banner/internal/service"
+)
+
+const (
+	defaultLimit = 10
+	// slots of one BatchGetUserBanners call
+	maxBatchSize = 100
+)
+
+// methods users may call, all others need admin
+var PublicMethods = map[string]bool{
+	bannerpb.BannerService_GetUserBanner_FullMethodName:       true,
+	bannerpb.BannerService_BatchGetUserBanners_FullMethodName: true,
+}
+
+// methods limited as reads, all others as writes
+var readMethods = map[string]bool{
+	bannerpb.BannerService_GetUserBanner_FullMethodName:       true,
+	bannerpb.BannerService_BatchGetUserBanners_FullMethodName: true,
+	bannerpb.BannerService_ListBanners_FullMethodName:         true,
+}
+
+type bannerServicer interface {
+	GetUserBanner(ctx context.Context, user usermodels.User, tagID int, featureID int, useLastRevision bool) (bannermodels.UserBanner, error)
+	BannerList(ctx context.Context, filter bannermodels.FilterSchema) ([]bannermodels.Banner, error)
+	CreateBanner(ctx context.Context, banner bannermodels.Banner) (int, error)
+	PartialUpdateBanner(ctx context.Context, id int, bannerPartial bannermodels.BannerPartialUpdate) error
+	DeleteBanner(ctx context.Context, id int, deletedBy string) error
+}
+
+type Server struct {
+	bannerpb.UnimplementedBannerServiceServer
+	service bannerServicer
+}
+
+func NewServer(service bannerServicer) *Server {
+	return &Server{service: service}
+}
+
+func (s *Server) GetUserBanner(ctx context.Context, req *bannerpb.GetUserBannerRequest) (*bannerpb.GetUserBannerResponse, error) {
+	user, err := userFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	banner, err := s.service.GetUserBanner(ctx, user, int(req.GetTagId()), int(req.GetFeatureId()), req.GetUseLastRevision())
+	if err != nil {
+		return nil, toStatus(ctx, err)
+	}
+
+	content, err := structpb.NewStruct(banner.Content)
+	if err != nil {
+		return nil, toStatus(ctx, err)
+	}
+	return &bannerpb.GetUserBannerResponse{Content: content}, nil
+}
+
+func (s *Server) BatchGetUserBanners(ctx context.Context, req *bannerpb.BatchGetUserBannersRequest) (*bannerpb.BatchGetUserBannersResponse, error) {
+	if len(req.GetSlots()) == 0 || len(req.GetSlots()) > maxBatchSize {
+		return nil, invalidArgument(apierror.MsgBadBatchSize, maxBatchSize)
+	}
+
+	user, err := userFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &bannerpb.BatchGetUserBannersResponse{
+		Results: make([]*bannerpb.UserBannerResult, 0, len(req.GetSlots())),
+	}
+	for _, slot := range req.GetSlots() {
+		result := &bannerpb.UserBannerResult{Slot: slot}
+
+		banner, err := s.service.GetUserBanner(ctx, user, int(slot.GetTagId()), int(slot.GetFeatureId()), req.GetUseLastRevision())
+		switch {
+		case errors.Is(err, service.ErrBannerNotFound):
+		case err != nil:
+			return nil, toStatus(ctx, err)
+		default:
+			result.Found = true
+			result.Content, err = structpb.NewStruct(banner.Content)
+			if err != nil {
+				return nil, toStatus(ctx, err)
+			}
+		}
+
+		resp.Results = append(resp.Results, result)
+	}
+
+	return resp, nil
+}
+
+func (s *Server) ListBanners(ctx context.Context, req *bannerpb.ListBannersRequest) (*bannerpb.ListBannersResponse, error) {
+	limit := defaultLimit
+	if req.Limit != nil {
+		limit = int(req.GetLimit())
+	}
+	if limit < 0 {
+		return nil, invalidArgument(apierror.MsgBadLimit)
+	}
+	if req.GetOffset() < 0 {
+		return nil, invalidArgument(apierror.MsgBadOffset)
+	}
+
+	filter := bannermodels.NewFilerSchema(limit, int(req.GetOffset()))
+	if req.FeatureId != nil {
+		filter.SetFeatureID(int(req.GetFeatureId()))
+	}
+	if req.TagId != nil {
+		filter.SetTagID(int(req.GetTagId()))
+	}
+
+	banners, err := s.service.BannerList(ctx, filter)
+	if err != nil {
+		return nil, toStatus(ctx, err)
+	}
+
+	resp := &bannerpb.ListBannersResponse{Banners: make([]*bannerpb.Banner, 0, len(banners))}
+	for _, banner := range banners {
+		pbBanner, err := toProtoBanner(banner)
+		if err != nil {
+			return nil, toStatus(ctx, err)
+		}
+		resp.Banners = append(resp.Banners, pbBanner)
+	}
+	return resp, nil
+}
+
+func (s *Server) CreateBanner(ctx context.Context, req *bannerpb.CreateBannerRequest) (*bannerpb.CreateBannerResponse, error) {
+	if len(req.GetTagIds()) == 0 {
+		return nil, invalidArgument(apierror.MsgEmptyTagIDs)
+	}
+	if req.GetContent() == nil {
+		return nil, invalidArgument(apierror.MsgBadContent)
+	}
+
+	id, err := s.service.CreateBanner(ctx, bannermodels.Banner{
+		TagIDs:    toInts(req.GetTagIds()),
+		FeatureID: int(req.GetFeatureId()),
+		Content:   req.GetContent().AsMap(),
+		IsActive:  req.GetIsActive(),
+	})
+	if err != nil {
+		return nil, toStatus(ctx, err)
+	}
+
+	return &bannerpb.CreateBannerResponse{BannerId: int64(id)}, nil
+}
+
+func (s *Server) UpdateBanner(ctx context.Context, req *bannerpb.UpdateBannerRequest) (*bannerpb.UpdateBannerResponse, error) {
+	var partial bannermodels.BannerPartialUpdate
+	if req.TagIds != nil {
+		if len(req.GetTagIds().GetIds()) == 0 {
+			return nil, invalidArgument(apierror.MsgEmptyTagIDs)
+		}
+		partial.TagIDs = toInts(req.GetTagIds().GetIds())
+	}
+	if req.FeatureId != nil {
+		partial.FeatureID = int(req.GetFeatureId())
+	}
+	if req.Content != nil {
+		partial.Content = req.GetContent().AsMap()
+	}
+	if req.IsActive != nil {
+		partial.IsActive = req.GetIsActive()
+	}
+
+	err := s.service.PartialUpdateBanner(ctx, int(req.GetBannerId()), partial)
+	if err != nil {
+		return nil, toStatus(ctx, err)
+	}
+
+	return &bannerpb.UpdateBannerResponse{}, nil
+}
+
+func (s *Server) DeleteBanner(ctx context.Context, req *bannerpb.DeleteBannerRequest) (*bannerpb.DeleteBannerResponse, error) {
+	user, err := userFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.service.DeleteBanner(ctx, int(req.GetBannerId()), user.Name)
+	if err != nil {
+		return nil, toStatus(ctx, err)
+	}
+
+	return &bannerpb.DeleteBannerResponse{}, nil
+}
+
+func toProtoBanner(banner bannermodels.Banner) (*bannerpb.Banner, error) {
+	content, err := structpb.NewStruct(banner.Content)
+	if err != nil {
+		return nil, err
+	}
+
+	tagIDs := make([]int64, len(banner.TagIDs))
+	for i, tagID := range banner.TagIDs {
+		tagIDs[i] = int64(tagID)
+	}
+
+	return &bannerpb.Banner{
+		BannerId:  int64(banner.ID),
+		TagIds:    tagIDs,
+		FeatureId: int64(banner.FeatureID),
+		Content:   content,
+		IsActive:  banner.IsActive,
+		CreatedAt: timestamppb.New(banner.CreatedAt),
+		UpdatedAt: timestamppb.New(banner.UpdatedAt),
+	}, nil
+}
+
+func toInts(ids []int64) []int {
+	ints := make([]int, len(ids))
+	for i, id := range ids {
+		ints[i] = int(id)
+	}
+	return ints
+}
@@ -1,52 +1,90 @@
 package handler
 
 import (
-	"banner/internal/constants"
-	"banner/internal/middleware"
+	"banner/internal/apierror"
 	bannermodels "banner/internal/models/banner"
 	usermodels "banner/internal/models/user"
+	webhookmodels "banner/internal/models/webhook"
 
 	"banner/internal/sending"
 	"banner/internal/service"
 	"context"
-	"encoding/json"
 	"errors"
-	"io"
+	"log/slog"
 	"net/http"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/gorilla/mux"
 )
 
 type bannerServicer interface {
-	GetUserBanner(ctx context.Context, user usermodels.User, tagID int, featureID int, useLastRevision bool) ([]byte, error)
+	GetUserBanner(ctx context.Context, user usermodels.User, tagID int, featureID int, useLastRevision bool) (bannermodels.UserBanner, error)
 	BannerList(ctx context.Context, filter bannermodels.FilterSchema) ([]bannermodels.Banner, error)
+	BannerListPage(ctx context.Context, filter bannermodels.FilterSchema, withTotal bool) (bannermodels.BannerPage, error)
+	ExportBanners(ctx context.Context, filter bannermodels.FilterSchema, fn func(bannermodels.Banner) error) error
+	ImportBanners(ctx context.Context, reader bannermodels.BannerReader, policy bannermodels.ImportPolicy, dryRun bool) (bannermodels.ImportReport, error)
 	CreateBanner(ctx context.Context, banner bannermodels.Banner) (int, error)
+	CloneBanner(ctx context.Context, sourceID int, clone bannermodels.BannerClone) (int, error)
 	PartialUpdateBanner(ctx context.Context, id int, bannerPartial bannermodels.BannerPartialUpdate) error
-	DeleteBanner(ctx context.Context, id int) error
+	DeleteBanner(ctx context.Context, id int, deletedBy string) error
+	RestoreBanner(ctx context.Context, id int) error
+	FeatureSlots(ctx context.Context, featureID int) ([]bannermodels.Slot, error)
+	TransferSlots(ctx context.Context, transfer bannermodels.SlotTransfer) (bannermodels.TransferResult, error)
+	ToggleBanners(ctx context.Context, toggle bannermodels.BannerToggle, dryRun bool) (bannermodels.ToggleResult, error)
+	PreviewUserBanner(ctx context.Context, tagID int, featureID int) (map[string]interface{}, error)
+	SaveDraft(ctx context.Context, bannerID int, author string, banner bannermodels.Banner) (bannermodels.Draft, error)
+	GetDraft(ctx context.Context, bannerID int) (bannermodels.Draft, error)
+	DeleteDraft(ctx context.Context, bannerID int) error
+	ApproveDraft(ctx context.Context, bannerID int, approver string) (bannermodels.Draft, error)
+	PublishDraft(ctx context.Context, bannerID int, publisher string) (bannermodels.PublishResult, error)
+	WatchUserBanners(tagID int, featureIDs []int) *service.SlotWatch
+	CreateWebhook(ctx context.Context, hook webhookmodels.Webhook) (webhookmodels.Webhook, error)
+	ListWebhooks(ctx context.Context) ([]webhookmodels.Webhook, error)
+	DeleteWebhook(ctx context.Context, id int) error
+	WebhookDeliveries(ctx context.Context, filter webhookmodels.DeliveryFilter) ([]webhookmodels.Delivery, error)
+	RedeliverWebhook(ctx context.Context, webhookID int, deliveryID int64) (webhookmodels.Delivery, error)
+	BannerEvents(ctx context.Context, after int64, limit int, bannerID int) ([]bannermodels.BannerChange, error)
 }
 
 type BannerHandler struct {
 	service bannerServicer
+	// how long user banner may be stale, it is max-age of /user_banner
+	userBannerMaxAge time.Duration
+
+	stream StreamConfig
+	// done when streams must be closed on shutdown
+	streamsCtx   context.Context
+	closeStreams context.CancelFunc
 }
 
-func NewBannerHandler(service bannerServicer) BannerHandler {
+func NewBannerHandler(service bannerServicer, userBannerMaxAge time.Duration, stream StreamConfig) BannerHandler {
+	streamsCtx, closeStreams := context.WithCancel(context.Background())
 	return BannerHandler{
-		service: service,
+		service:          service,
+		userBannerMaxAge: userBannerMaxAge,
+		stream:           stream,
+		streamsCtx:       streamsCtx,
+		closeStreams:     closeStreams,
 	}
 }
 
 func (h *BannerHandler) GetUserBanner(w http.ResponseWriter, r *http.Request) {
+	r, span := startSpan(r, "GetUserBanner")
+	defer span.End()
+
 	queryParams := r.URL.Query()
 
 	tagID, err := tagIDFromQuery(queryParams)
 	if err != nil {
-		sending.SendErrorMsg(w, http.StatusBadRequest, badTagIDMsg)
+		h.sendError(w, r, apierror.Invalid(tagIDParamName, apierror.MsgBadTagID))
 		return
 	}
 
 	featureID, err := featureIDFromQuery(queryParams)
 	if err != nil {
-		sending.SendErrorMsg(w, http.StatusBadRequest, badFeatureIDMsg)
+		h.sendError(w, r, apierror.Invalid(featureIDParamName, apierror.MsgBadFeatureID))
 		return
 	}
 
@@ -54,178 +92,490 @@ func (h *BannerHandler) GetUserBanner(w http.ResponseWriter, r *http.Request) {
 	if queryParams.Has(useLastRevisionParamName) {
 		useLastRevision, err = useLastRevisionFromQuery(queryParams)
 		if err != nil {
-			sending.SendErrorMsg(w, http.StatusBadRequest, badUseLastRevision)
+			h.sendError(w, r, apierror.Invalid(useLastRevisionParamName, apierror.MsgBadUseLastRevision))
+			return
 		}
 	}
 
-	user, ok := r.Context().Value(middleware.UserKey).(usermodels.User)
-	if !ok {
-		sending.SendErrorMsg(w, http.StatusInternalServerError, constants.ErrMsgUserNotFoundInCTX)
+	preview, err := previewFromQuery(r)
+	if err != nil {
+		h.sendError(w, r, err)
 		return
 	}
 
-	bannerJSON, err := h.service.GetUserBanner(r.Context(), user, tagID, featureID, useLastRevision)
+	user, err := userFromRequest(r)
 	if err != nil {
-		h.handleServiceError(w, err)
+		h.sendError(w, r, err)
 		return
 	}
 
-	sending.SendJSONBytes(w, http.StatusOK, bannerJSON)
+	if preview && !user.IsAdmin {
+		h.sendError(w, r, apierror.Forbidden())
+		return
+	}
+
+	if preview {
+		content, err := h.service.PreviewUserBanner(r.Context(), tagID, featureID)
+		if err != nil {
+			h.sendError(w, r, err)
+			return
+		}
+		sendCacheable(w, r, content, cacheControlPrivateNoCache)
+		return
+	}
+
+	banner, err := h.service.GetUserBanner(r.Context(), user, tagID, featureID, useLastRevision)
+	if err != nil {
+		h.sendError(w, r, err)
+		return
+	}
+
+	cacheControl := publicCacheControl(h.userBannerMaxAge)
+	switch {
+	case !banner.IsActive:
+		// users get 404 for it, so shared caches must not keep it
+		cacheControl = cacheControlPrivateNoCache
+	case useLastRevision:
+		cacheControl = cacheControlNoCache
+	}
+
+	sendCacheable(w, r, banner.Content, cacheControl)
 }
 
 func (h *BannerHandler) BannerList(w http.ResponseWriter, r *http.Request) {
+	r, span := startSpan(r, "BannerList")
+	defer span.End()
+
 	queryParams := r.URL.Query()
 
-	limit := defaultLimit
-	if queryParams.Has(limitParamName) {
-		limitUint, err := StrToUint(queryParams.Get(limitParamName))
+	filter, err := filterFromQuery(queryParams)
+	if err != nil {
+		h.sendError(w, r, err)
+		return
+	}
+
+	withTotal := defaultWithTotal
+	if queryParams.Has(withTotalParamName) {
+		withTotal, err = strconv.ParseBool(queryParams.Get(withTotalParamName))
 		if err != nil {
-			sending.SendErrorMsg(w, http.StatusBadRequest, badLimitMsg)
+			h.sendError(w, r, apierror.Invalid(withTotalParamName, apierror.MsgBadWithTotal))
 			return
 		}
-		limit = int(limitUint)
 	}
 
-	offset := defaultOffset
-	if queryParams.Has(offsetParamName) {
-		offsetUint, err := StrToUint(queryParams.Get(offsetParamName))
+	// cursor param (even empty) switches response to page envelope
+	if queryParams.Has(cursorParamName) {
+		page, err := h.service.BannerListPage(r.Context(), filter, withTotal)
 		if err != nil {
-			sending.SendErrorMsg(w, http.StatusBadRequest, badOfssetMsg)
+			h.sendError(w, r, err)
 			return
 		}
-		offset = int(offsetUint)
+
+		sending.MarshalAndSend(w, r, http.StatusOK, page)
+		return
 	}
 
-	filter := bannermodels.NewFilerSchema(limit, offset)
+	if withTotal {
+		h.sendError(w, r, apierror.Invalid(withTotalParamName, apierror.MsgBadWithTotalNoCursor))
+		return
+	}
 
-	if queryParams.Has(featureIDParamName) {
-		featureID, err := featureIDFromQuery(queryParams)
-		if err != nil {
-			sending.SendErrorMsg(w, http.StatusBadRequest, badFeatureIDMsg)
-			return
+	banners, err := h.service.BannerList(r.Context(), filter)
+	if err != nil {
+		h.sendError(w, r, err)
+		return
+	}
+
+	sending.MarshalAndSend(w, r, http.StatusOK, banners)
+}
+
+// stream banners matching list filters as NDJSON, limit, offset and cursor are ignored
+func (h *BannerHandler) ExportBanners(w http.ResponseWriter, r *http.Request) {
+	r, span := startSpan(r, "ExportBanners")
+	defer span.End()
+
+	filter, err := filterFromQuery(r.URL.Query())
+	if err != nil {
+		h.sendError(w, r, err)
+		return
+	}
+
+	var stream *sending.NDJSONWriter
+	err = h.service.ExportBanners(r.Context(), filter, func(banner bannermodels.Banner) error {
+		// status is sent with the first banner, so query errors still can be reported
+		if stream == nil {
+			stream = sending.NewNDJSONWriter(w, http.StatusOK)
 		}
-		filter.SetFeatureID(featureID)
+		return stream.Write(banner)
+	})
+
+	switch {
+	case err != nil && stream == nil:
+		h.sendError(w, r, err)
+	case err != nil:
+		// response is already started, client gets truncated stream
+		slog.ErrorContext(r.Context(), "export interrupted", "err", err)
+	case stream == nil:
+		sending.NewNDJSONWriter(w, http.StatusOK)
+	default:
+		stream.Flush()
 	}
+}
 
-	if queryParams.Has(tagIDParamName) {
-		tagID, err := tagIDFromQuery(queryParams)
-		if err != nil {
-			sending.SendErrorMsg(w, http.StatusBadRequest, badTagIDMsg)
+func (h *BannerHandler) ImportBanners(w http.ResponseWriter, r *http.Request) {
+	r, span := startSpan(r, "ImportBanners")
+	defer span.End()
+
+	queryParams := r.URL.Query()
+
+	policy := defaultImportPolicy
+	if queryParams.Has(onConflictParamName) {
+		policy = bannermodels.ImportPolicy(queryParams.Get(onConflictParamName))
+		if !policy.Valid() {
+			h.sendError(w, r, apierror.Invalid(onConflictParamName, apierror.MsgBadOnConflict))
 			return
 		}
-		filter.SetTagID(tagID)
 	}
 
-	banners, err := h.service.BannerList(r.Context(), filter)
+	dryRun, err := dryRunFromQuery(queryParams)
 	if err != nil {
-		h.handleServiceError(w, err)
+		h.sendError(w, r, err)
 		return
 	}
 
-	sending.JSONMarshallAndSend(w, http.StatusOK, banners)
-}
-
-func (h *BannerHandler) CreateBanner(w http.ResponseWriter, r *http.Request) {
-	body, err := io.ReadAll(r.Body)
+	report, err := h.service.ImportBanners(r.Context(), bannermodels.NewNDJSONReader(r.Body), policy, dryRun)
+	var lineErr *bannermodels.ImportLineError
+	if errors.As(err, &lineErr) {
+		h.sendError(w, r, apierror.Invalid(bodyFieldName, apierror.MsgBadImportLine, lineErr.Line, lineErr.Reason))
+		return
+	}
 	if err != nil {
-		sending.SendErrorMsg(w, http.StatusInternalServerError, errMsgCantReadBody)
+		h.sendError(w, r, err)
+		return
+	}
+
+	if !report.Applied && !report.DryRun {
+		h.sendError(w, r, importConflictError(report))
 		return
 	}
 
+	sending.JSONMarshallAndSend(w, r, http.StatusOK, report)
+}
+
+// lines of rolled back import that conflict with existing banners
+func importConflictError(report bannermodels.ImportReport) *apierror.Error {
+	var details []apierror.FieldError
+	for _, result := range report.Results {
+		if result.Action != bannermodels.ImportConflict {
+			continue
+		}
+
+		holders := make([]string, len(result.ConflictBannerIDs))
+		for i, id := range result.ConflictBannerIDs {
+			holders[i] = strconv.Itoa(id)
+		}
+		details = append(details, apierror.Field(bodyFieldName, apierror.MsgImportLineConflict, result.Line, strings.Join(holders, ",")))
+	}
+	return apierror.ImportConflict(details...)
+}
+
+func (h *BannerHandler) CreateBanner(w http.ResponseWriter, r *http.Request) {
+	r, span := startSpan(r, "CreateBanner")
+	defer span.End()
+
 	var bannerReq bannermodels.BannerRequest
-	err = json.Unmarshal(body, &bannerReq)
+	err := decodeJSONBody(r, &bannerReq)
 	if err != nil {
-		sending.SendErrorMsg(w, http.StatusBadRequest, err.Error()) // TODO normal err msg
+		h.sendError(w, r, err)
 		return
 	}
 
 	id, err := h.service.CreateBanner(r.Context(), bannerReq.ToBanner())
 	if err != nil {
-		h.handleServiceError(w, err)
+		h.sendError(w, r, err)
 		return
 	}
 
-	sending.JSONMarshallAndSend(w, http.StatusCreated, BannerIdMsg{ID: id})
+	sending.JSONMarshallAndSend(w, r, http.StatusCreated, BannerIdMsg{ID: id})
 }
 
-func (h *BannerHandler) UpdatePatial(w http.ResponseWriter, r *http.Request) {
-	vars := mux.Vars(r)
+// create banner with content of banner id, body has optional overrides
+func (h *BannerHandler) CloneBanner(w http.ResponseWriter, r *http.Request) {
+	r, span := startSpan(r, "CloneBanner")
+	defer span.End()
 
-	id, err := IDFromVars(vars)
+	id, err := IDFromVars(mux.Vars(r))
 	if err != nil {
-		sending.SendErrorMsg(w, http.StatusBadRequest, err.Error())
+		h.sendError(w, r, err)
 		return
 	}
 
-	body, err := io.ReadAll(r.Body)
+	var clone bannermodels.BannerClone
+	err = decodeJSONBody(r, &clone)
 	if err != nil {
-		sending.SendErrorMsg(w, http.StatusInternalServerError, errMsgCantReadBody)
+		h.sendError(w, r, err)
+		return
+	}
+
+	if clone.TagIDs != nil && len(clone.TagIDs) == 0 {
+		h.sendError(w, r, apierror.Invalid(tagIDsFieldName, apierror.MsgEmptyTagIDs))
+		return
+	}
+
+	cloneID, err := h.service.CloneBanner(r.Context(), id, clone)
+	if err != nil {
+		h.sendError(w, r, err)
+		return
+	}
+
+	sending.JSONMarshallAndSend(w, r, http.StatusCreated, BannerIdMsg{ID: cloneID})
+}
+
+func (h *BannerHandler) UpdatePatial(w http.ResponseWriter, r *http.Request) {
+	r, span := startSpan(r, "UpdatePatial")
+	defer span.End()
+
+	vars := mux.Vars(r)
+
+	id, err := IDFromVars(vars)
+	if err != nil {
+		h.sendError(w, r, err)
 		return
 	}
 
 	var bannerPartial bannermodels.BannerPartialUpdate
-	err = json.Unmarshal(body, &bannerPartial)
+	err = decodeJSONBody(r, &bannerPartial)
 	if err != nil {
-		sending.SendErrorMsg(w, http.StatusBadRequest, err.Error()) // TODO normal err msg
+		h.sendError(w, r, err)
 		return
 	}
 
 	bannerPartial, err = h.checkAndSetCorrectTypesToBannerPartial(bannerPartial)
 	if err != nil {
-		sending.SendErrorMsg(w, http.StatusBadRequest, err.Error())
+		h.sendError(w, r, err)
 		return
 	}
 
 	err = h.service.PartialUpdateBanner(r.Context(), id, bannerPartial)
 	if err != nil {
-		h.handleServiceError(w, err)
+		h.sendError(w, r, err)
 		return
 	}
 
 	w.WriteHeader(http.StatusOK)
 }
 
+// move banner to trash
 func (h BannerHandler) DeleteBanner(w http.ResponseWriter, r *http.Request) {
+	r, span := startSpan(r, "DeleteBanner")
+	defer span.End()
+
 	vars := mux.Vars(r)
 
 	id, err := IDFromVars(vars)
 	if err != nil {
-		sending.SendErrorMsg(w, http.StatusBadRequest, err.Error())
+		h.sendError(w, r, err)
 		return
 	}
 
-	err = h.service.DeleteBanner(r.Context(), id)
+	user, err := userFromRequest(r)
 	if err != nil {
-		h.handleServiceError(w, err)
+		h.sendError(w, r, err)
+		return
+	}
+
+	err = h.service.DeleteBanner(r.Context(), id, user.Name)
+	if err != nil {
+		h.sendError(w, r, err)
 		return
 	}
 
 	w.WriteHeader(http.StatusNoContent)
 }
 
-func (h *BannerHandler) handleServiceError(w http.ResponseWriter, err error) {
+// take banner out of trash back to its slots
+func (h BannerHandler) RestoreBanner(w http.ResponseWriter, r *http.Request) {
+	r, span := startSpan(r, "RestoreBanner")
+	defer span.End()
+
+	id, err := IDFromVars(mux.Vars(r))
+	if err != nil {
+		h.sendError(w, r, err)
+		return
+	}
+
+	err = h.service.RestoreBanner(r.Context(), id)
+	if err != nil {
+		h.sendError(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// tag to banner occupancy map of feature
+func (h *BannerHandler) FeatureSlots(w http.ResponseWriter, r *http.Request) {
+	r, span := startSpan(r, "FeatureSlots")
+	defer span.End()
+
+	featureID, err := featureIDFromQuery(r.URL.Query())
+	if err != nil {
+		h.sendError(w, r, apierror.Invalid(featureIDParamName, apierror.MsgBadFeatureID))
+		return
+	}
+
+	slots, err := h.service.FeatureSlots(r.Context(), featureID)
+	if err != nil {
+		h.sendError(w, r, err)
+		return
+	}
+
+	sending.JSONMarshallAndSend(w, r, http.StatusOK, slots)
+}
+
+// move or swap slots between two banners of one feature
+func (h *BannerHandler) TransferSlots(w http.ResponseWriter, r *http.Request) {
+	r, span := startSpan(r, "TransferSlots")
+	defer span.End()
+
+	var transfer bannermodels.SlotTransfer
+	err := decodeJSONBody(r, &transfer)
+	if err != nil {
+		h.sendError(w, r, err)
+		return
+	}
+
+	if transfer.Mode == "" {
+		transfer.Mode = defaultTransferMode
+	}
+
+	var details []apierror.FieldError
+	if transfer.FromBannerID <= 0 {
+		details = append(details, apierror.Field(fromBannerIDFieldName, apierror.MsgBadFromBannerID))
+	}
+	if transfer.ToBannerID <= 0 || transfer.ToBannerID == transfer.FromBannerID {
+		details = append(details, apierror.Field(toBannerIDFieldName, apierror.MsgBadToBannerID))
+	}
+	if len(transfer.TagIDs) == 0 {
+		details = append(details, apierror.Field(tagIDsFieldName, apierror.MsgEmptyTagIDs))
+	}
+	if !transfer.Mode.Valid() {
+		details = append(details, apierror.Field(modeFieldName, apierror.MsgBadTransferMode))
+	}
+	if len(details) != 0 {
+		h.sendError(w, r, apierror.Validation(details...))
+		return
+	}
+
+	result, err := h.service.TransferSlots(r.Context(), transfer)
+	if err != nil {
+		h.sendError(w, r, err)
+		return
+	}
+
+	sending.JSONMarshallAndSend(w, r, http.StatusOK, result)
+}
+
+// activate or deactivate all banners of feature and/or tags
+func (h *BannerHandler) ToggleBanners(w http.ResponseWriter, r *http.Request) {
+	r, span := startSpan(r, "ToggleBanners")
+	defer span.End()
+
+	dryRun, err := dryRunFromQuery(r.URL.Query())
+	if err != nil {
+		h.sendError(w, r, err)
+		return
+	}
+
+	var toggle bannermodels.BannerToggle
+	err = decodeJSONBody(r, &toggle)
+	if err != nil {
+		h.sendError(w, r, err)
+		return
+	}
+
+	var details []apierror.FieldError
+	if toggle.KillSwitch {
+		if toggle.FeatureID == nil {
+			details = append(details, apierror.Field(featureIDFieldName, apierror.MsgKillSwitchFeatureID))
+		}
+		if toggle.TagIDs != nil {
+			details = append(details, apierror.Field(tagIDsFieldName, apierror.MsgKillSwitchTagIDs))
+		}
+		if toggle.IsActive != nil && *toggle.IsActive {
+			details = append(details, apierror.Field(isActiveFieldName, apierror.MsgKillSwitchIsActive))
+		}
+
+		isActive := false
+		toggle.IsActive = &isActive
+	} else {
+		if toggle.FeatureID == nil && len(toggle.TagIDs) == 0 {
+			details = append(details, apierror.Field(featureIDFieldName, apierror.MsgToggleNoFilter))
+		}
+		if toggle.IsActive == nil {
+			details = append(details, apierror.Field(isActiveFieldName, apierror.MsgIsActiveRequired))
+		}
+	}
+	if len(details) != 0 {
+		h.sendError(w, r, apierror.Validation(details...))
+		return
+	}
+
+	result, err := h.service.ToggleBanners(r.Context(), toggle, dryRun)
+	if err != nil {
+		h.sendError(w, r, err)
+		return
+	}
+
+	sending.JSONMarshallAndSend(w, r, http.StatusOK, result)
+}
+
+// map err to api error, internal errors are logged with cause
+// and client gets generic message
+func (h *BannerHandler) sendError(w http.ResponseWriter, r *http.Request, err error) {
+	var apiErr *apierror.Error
+	var conflictErr *service.SlotConflictError
 	switch {
+	case errors.As(err, &apiErr):
 	case errors.Is(err, service.ErrBannerNotFound):
-		sending.SendErrorMsg(w, http.StatusBadRequest, errMsgBannerNotFound)
+		apiErr = apierror.BannerNotFound()
+	case errors.As(err, &conflictErr):
+		apiErr = apierror.SlotConflict(conflictErr.Slots)
 	case errors.Is(err, service.ErrBannerAlreadyExists):
-		sending.SendErrorMsg(w, http.StatusBadRequest, errMsgBannerAlreadyExists)
-	case err != nil:
-		sending.SendErrorMsg(w, http.StatusInternalServerError, err.Error())
+		apiErr = apierror.SlotConflict(nil)
+	case errors.Is(err, service.ErrSlotNotHeld):
+		apiErr = apierror.SlotNotHeld()
+	case errors.Is(err, service.ErrFeatureMismatch):
+		apiErr = apierror.FeatureMismatch()
+	case errors.Is(err, service.ErrDraftNotFound):
+		apiErr = apierror.DraftNotFound()
+	case errors.Is(err, service.ErrDraftNotApproved):
+		apiErr = apierror.DraftNotApproved()
+	case errors.Is(err, service.ErrSelfApproval):
+		apiErr = apierror.SelfApproval()
+	case errors.Is(err, service.ErrWebhookNotFound):
+		apiErr = apierror.WebhookNotFound()
+	case errors.Is(err, service.ErrDeliveryNotFound):
+		apiErr = apierror.DeliveryNotFound()
+	default:
+		slog.ErrorContext(r.Context(), "internal error", "err", err)
+		apiErr = apierror.Internal()
 	}
+	sending.SendError(w, r, apiErr)
 }
 
 func (h *BannerHandler) checkAndSetCorrectTypesToBannerPartial(bannerPartial bannermodels.BannerPartialUpdate) (bannermodels.BannerPartialUpdate, error) {
 	if bannerPartial.IsActive != nil {
 		_, ok := bannerPartial.IsActive.(bool)
 		if !ok {
-			return bannerPartial, errors.New(badIsActive)
+			return bannerPartial, apierror.Invalid(isActiveParamName, apierror.MsgBadIsActive)
 		}
 	}
 
 	if bannerPartial.FeatureID != nil {
 		featureID, ok := bannerPartial.FeatureID.(float64)
 		if !ok {
-			return bannerPartial, errors.New(badFeatureIDMsg)
+			return bannerPartial, apierror.Invalid(featureIDParamName, apierror.MsgBadFeatureID)
 		}
 		bannerPartial.FeatureID = int(featureID)
 	}
@@ -233,14 +583,14 @@ func (h *BannerHandler) checkAndSetCorrectTypesToBannerPartial(bannerPartial ban
 	if bannerPartial.TagIDs != nil {
 		tagIDsInterface, ok := bannerPartial.TagIDs.([]interface{})
 		if !ok {
-			return bannerPartial, errors.New(badTagIDsMsg)
+			return bannerPartial, apierror.Invalid(tagIDsFieldName, apierror.MsgBadTagIDs)
 		}
 
 		tagIDs := make([]int, len(tagIDsInterface))
 		for i, id := range tagIDsInterface {
 			tagID, ok := id.(float64)
 			if !ok {
-				return bannerPartial, errors.New(badTagIDsMsg)
+				return bannerPartial, apierror.Invalid(tagIDsFieldName, apierror.MsgBadTagIDs)
 			}
 
 			tagIDs[i] = int(tagID)
@@ -252,7 +602,7 @@ func (h *BannerHandler) checkAndSetCorrectTypesToBannerPartial(bannerPartial ban
 	if bannerPartial.Content != nil {
 		_, ok := bannerPartial.Content.(map[string]interface{})
 		if !ok {
-			return bannerPartial, errors.New(badContentMsg)
+			return bannerPartial, apierror.Invalid(contentFieldName, apierror.MsgBadContent)
 		}
 	}
 
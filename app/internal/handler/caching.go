@@ -0,0 +1,88 @@
+package handler
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"banner/internal/sending"
+)
+
+const (
+	headerETag         = "ETag"
+	headerCacheControl = "Cache-Control"
+	headerIfNoneMatch  = "If-None-Match"
+	headerVary         = "Vary"
+	headerContentType  = "Content-Type"
+
+	// Auth token and format of body, shared caches must not give response
+	// to request without token or in other format. Added to Vary of compression.
+	varyUserBanner = "token, Accept"
+
+	cacheControlNoCache = "no-cache"
+	// only browser of admin may keep it, and it must revalidate
+	cacheControlPrivateNoCache = "private, no-cache"
+)
+
+// strong ETag of response body, equal content gives equal tag
+// whichever revision or cache it is read from
+func contentETag(body []byte) string {
+	sum := sha256.Sum256(body)
+	return `"` + hex.EncodeToString(sum[:16]) + `"`
+}
+
+// If-None-Match uses weak comparison, so W/ prefix is ignored
+func etagMatches(ifNoneMatch string, etag string) bool {
+	if ifNoneMatch == "" {
+		return false
+	}
+	for _, candidate := range strings.Split(ifNoneMatch, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || strings.TrimPrefix(candidate, "W/") == etag {
+			return true
+		}
+	}
+	return false
+}
+
+// public max-age equal to staleness of banner cache, no-cache if banners are not cached
+func publicCacheControl(maxAge time.Duration) string {
+	seconds := int(maxAge.Seconds())
+	if seconds <= 0 {
+		return cacheControlNoCache
+	}
+	return "public, max-age=" + strconv.Itoa(seconds)
+}
+
+// Send obj in negotiated format with ETag and Cache-Control, or 304 without body
+// if client already has it. Each format has own ETag.
+func sendCacheable(w http.ResponseWriter, r *http.Request, obj any, cacheControl string) {
+	body, contentType, err := sending.Marshal(r, obj)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "marshal response", "err", err)
+		sending.SendError(w, r, nil)
+		return
+	}
+
+	etag := contentETag(body)
+
+	header := w.Header()
+	header.Set(headerETag, etag)
+	header.Set(headerCacheControl, cacheControl)
+	header.Add(headerVary, varyUserBanner)
+
+	if etagMatches(r.Header.Get(headerIfNoneMatch), etag) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	header.Set(headerContentType, contentType)
+	w.WriteHeader(http.StatusOK)
+
+	// status is already sent, client has gone if write failed
+	_, _ = w.Write(body)
+}
@@ -0,0 +1,146 @@
+package handler
+
+import (
+	"banner/internal/apierror"
+	bannermodels "banner/internal/models/banner"
+	"banner/internal/sending"
+	"net/http"
+
+	"github.com/gorilla/mux"
+)
+
+// create or replace draft of banner, body is the same as for banner creation
+func (h *BannerHandler) SaveDraft(w http.ResponseWriter, r *http.Request) {
+	r, span := startSpan(r, "SaveDraft")
+	defer span.End()
+
+	id, err := IDFromVars(mux.Vars(r))
+	if err != nil {
+		h.sendError(w, r, err)
+		return
+	}
+
+	user, err := userFromRequest(r)
+	if err != nil {
+		h.sendError(w, r, err)
+		return
+	}
+
+	var bannerReq bannermodels.BannerRequest
+	err = decodeJSONBody(r, &bannerReq)
+	if err != nil {
+		h.sendError(w, r, err)
+		return
+	}
+
+	draft, err := h.service.SaveDraft(r.Context(), id, user.Name, bannerReq.ToBanner())
+	if err != nil {
+		h.sendError(w, r, err)
+		return
+	}
+
+	sending.JSONMarshallAndSend(w, r, http.StatusOK, draft)
+}
+
+func (h *BannerHandler) GetDraft(w http.ResponseWriter, r *http.Request) {
+	r, span := startSpan(r, "GetDraft")
+	defer span.End()
+
+	id, err := IDFromVars(mux.Vars(r))
+	if err != nil {
+		h.sendError(w, r, err)
+		return
+	}
+
+	draft, err := h.service.GetDraft(r.Context(), id)
+	if err != nil {
+		h.sendError(w, r, err)
+		return
+	}
+
+	sending.JSONMarshallAndSend(w, r, http.StatusOK, draft)
+}
+
+func (h *BannerHandler) DeleteDraft(w http.ResponseWriter, r *http.Request) {
+	r, span := startSpan(r, "DeleteDraft")
+	defer span.End()
+
+	id, err := IDFromVars(mux.Vars(r))
+	if err != nil {
+		h.sendError(w, r, err)
+		return
+	}
+
+	err = h.service.DeleteDraft(r.Context(), id)
+	if err != nil {
+		h.sendError(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// approve draft by admin other than its author
+func (h *BannerHandler) ApproveDraft(w http.ResponseWriter, r *http.Request) {
+	r, span := startSpan(r, "ApproveDraft")
+	defer span.End()
+
+	id, err := IDFromVars(mux.Vars(r))
+	if err != nil {
+		h.sendError(w, r, err)
+		return
+	}
+
+	user, err := userFromRequest(r)
+	if err != nil {
+		h.sendError(w, r, err)
+		return
+	}
+
+	draft, err := h.service.ApproveDraft(r.Context(), id, user.Name)
+	if err != nil {
+		h.sendError(w, r, err)
+		return
+	}
+
+	sending.JSONMarshallAndSend(w, r, http.StatusOK, draft)
+}
+
+// replace live banner with approved draft
+func (h *BannerHandler) PublishDraft(w http.ResponseWriter, r *http.Request) {
+	r, span := startSpan(r, "PublishDraft")
+	defer span.End()
+
+	id, err := IDFromVars(mux.Vars(r))
+	if err != nil {
+		h.sendError(w, r, err)
+		return
+	}
+
+	user, err := userFromRequest(r)
+	if err != nil {
+		h.sendError(w, r, err)
+		return
+	}
+
+	result, err := h.service.PublishDraft(r.Context(), id, user.Name)
+	if err != nil {
+		h.sendError(w, r, err)
+		return
+	}
+
+	sending.JSONMarshallAndSend(w, r, http.StatusOK, result)
+}
+
+// preview=draft shows banner as it will be after publishing, admins only
+func previewFromQuery(r *http.Request) (bool, error) {
+	queryParams := r.URL.Query()
+	if !queryParams.Has(previewParamName) {
+		return false, nil
+	}
+
+	if queryParams.Get(previewParamName) != previewDraft {
+		return false, apierror.Invalid(previewParamName, apierror.MsgBadPreview)
+	}
+	return true, nil
+}
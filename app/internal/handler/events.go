@@ -0,0 +1,65 @@
+package handler
+
+import (
+	"banner/internal/apierror"
+	"banner/internal/sending"
+	"net/http"
+	"strconv"
+)
+
+const (
+	afterParamName    = "after"
+	bannerIDParamName = "banner_id"
+
+	defaultEventsLimit = 100
+	maxEventsLimit     = 1000
+)
+
+// Events of banner log after given event id, oldest first, banner_id narrows them to one banner.
+// Consumer passes id of last event it got as after of next request.
+func (h *BannerHandler) BannerEvents(w http.ResponseWriter, r *http.Request) {
+	r, span := startSpan(r, "BannerEvents")
+	defer span.End()
+
+	queryParams := r.URL.Query()
+
+	var after int64
+	if queryParams.Has(afterParamName) {
+		var err error
+		after, err = strconv.ParseInt(queryParams.Get(afterParamName), 10, 64)
+		if err != nil || after < 0 {
+			h.sendError(w, r, apierror.Invalid(afterParamName, apierror.MsgBadAfter))
+			return
+		}
+	}
+
+	limit := defaultEventsLimit
+	if queryParams.Has(limitParamName) {
+		limitUint, err := StrToUint(queryParams.Get(limitParamName))
+		if err != nil {
+			h.sendError(w, r, apierror.Invalid(limitParamName, apierror.MsgBadLimit))
+			return
+		}
+		if limitUint != 0 {
+			limit = min(int(limitUint), maxEventsLimit)
+		}
+	}
+
+	var bannerID int
+	if queryParams.Has(bannerIDParamName) {
+		var err error
+		bannerID, err = strconv.Atoi(queryParams.Get(bannerIDParamName))
+		if err != nil || bannerID <= 0 {
+			h.sendError(w, r, apierror.Invalid(bannerIDParamName, apierror.MsgBadBannerID))
+			return
+		}
+	}
+
+	events, err := h.service.BannerEvents(r.Context(), after, limit, bannerID)
+	if err != nil {
+		h.sendError(w, r, err)
+		return
+	}
+
+	sending.JSONMarshallAndSend(w, r, http.StatusOK, events)
+}
@@ -0,0 +1,147 @@
+package handler
+
+import (
+	"banner/internal/apierror"
+	bannermodels "banner/internal/models/banner"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// form filter for banner list from query params,
+// error message is ready to be sent to client
+func filterFromQuery(queryParams url.Values) (bannermodels.FilterSchema, error) {
+	limit := defaultLimit
+	if queryParams.Has(limitParamName) {
+		limitUint, err := StrToUint(queryParams.Get(limitParamName))
+		if err != nil {
+			return bannermodels.FilterSchema{}, apierror.Invalid(limitParamName, apierror.MsgBadLimit)
+		}
+		limit = int(limitUint)
+	}
+
+	offset := defaultOffset
+	if queryParams.Has(offsetParamName) {
+		offsetUint, err := StrToUint(queryParams.Get(offsetParamName))
+		if err != nil {
+			return bannermodels.FilterSchema{}, apierror.Invalid(offsetParamName, apierror.MsgBadOffset)
+		}
+		offset = int(offsetUint)
+	}
+
+	filter := bannermodels.NewFilerSchema(limit, offset)
+
+	if queryParams.Has(featureIDParamName) {
+		featureID, err := featureIDFromQuery(queryParams)
+		if err != nil {
+			return bannermodels.FilterSchema{}, apierror.Invalid(featureIDParamName, apierror.MsgBadFeatureID)
+		}
+		filter.SetFeatureID(featureID)
+	}
+
+	if queryParams.Has(tagIDParamName) {
+		tagID, err := tagIDFromQuery(queryParams)
+		if err != nil {
+			return bannermodels.FilterSchema{}, apierror.Invalid(tagIDParamName, apierror.MsgBadTagID)
+		}
+		filter.SetTagID(tagID)
+	}
+
+	if queryParams.Has(isActiveParamName) {
+		isActive, err := strconv.ParseBool(queryParams.Get(isActiveParamName))
+		if err != nil {
+			return bannermodels.FilterSchema{}, apierror.Invalid(isActiveParamName, apierror.MsgBadIsActive)
+		}
+		filter.SetIsActive(isActive)
+	}
+
+	if queryParams.Has(trashedParamName) {
+		trashed, err := strconv.ParseBool(queryParams.Get(trashedParamName))
+		if err != nil {
+			return bannermodels.FilterSchema{}, apierror.Invalid(trashedParamName, apierror.MsgBadTrashed)
+		}
+		filter.SetTrashed(trashed)
+	}
+
+	dateParams := []struct {
+		name string
+		dest **time.Time
+	}{
+		{createdFromParamName, &filter.CreatedFrom},
+		{createdToParamName, &filter.CreatedTo},
+		{updatedFromParamName, &filter.UpdatedFrom},
+		{updatedToParamName, &filter.UpdatedTo},
+	}
+	for _, param := range dateParams {
+		if !queryParams.Has(param.name) {
+			continue
+		}
+
+		date, err := time.Parse(time.RFC3339, queryParams.Get(param.name))
+		if err != nil {
+			return bannermodels.FilterSchema{}, apierror.Invalid(param.name, apierror.MsgBadDate)
+		}
+		// timestamps in db are stored in UTC without time zone
+		date = date.UTC()
+		*param.dest = &date
+	}
+
+	if queryParams.Has(queryParamName) {
+		query := strings.TrimSpace(queryParams.Get(queryParamName))
+		if query == "" {
+			return bannermodels.FilterSchema{}, apierror.Invalid(queryParamName, apierror.MsgBadQuery)
+		}
+		filter.SetQuery(query)
+	}
+
+	contentFilters, err := contentFiltersFromQuery(queryParams)
+	if err != nil {
+		return bannermodels.FilterSchema{}, err
+	}
+	for _, contentFilter := range contentFilters {
+		filter.AddContentFilter(contentFilter)
+	}
+
+	sort := bannermodels.DefaultSortField
+	if queryParams.Has(sortParamName) {
+		sort = bannermodels.SortField(queryParams.Get(sortParamName))
+		if !sort.Valid() {
+			return bannermodels.FilterSchema{}, apierror.Invalid(sortParamName, apierror.MsgBadSort)
+		}
+	}
+
+	desc := bannermodels.DefaultSortDesc
+	if queryParams.Has(orderParamName) {
+		switch queryParams.Get(orderParamName) {
+		case orderAsc:
+			desc = false
+		case orderDesc:
+			desc = true
+		default:
+			return bannermodels.FilterSchema{}, apierror.Invalid(orderParamName, apierror.MsgBadOrder)
+		}
+	}
+
+	filter.SetSort(sort, desc)
+
+	if queryParams.Has(cursorParamName) {
+		if queryParams.Has(offsetParamName) {
+			return bannermodels.FilterSchema{}, apierror.Invalid(offsetParamName, apierror.MsgBadCursorOffset)
+		}
+		if filter.Limit < 1 {
+			return bannermodels.FilterSchema{}, apierror.Invalid(limitParamName, apierror.MsgBadCursorLimit)
+		}
+
+		// empty cursor is the first page
+		if cursorStr := queryParams.Get(cursorParamName); cursorStr != "" {
+			cursor, err := bannermodels.DecodeCursor(cursorStr)
+			if err != nil || cursor.Sort != sort || cursor.Desc != desc {
+				return bannermodels.FilterSchema{}, apierror.Invalid(cursorParamName, apierror.MsgBadCursor)
+			}
+			filter.SetCursor(cursor)
+		}
+	}
+
+	return filter, nil
+}
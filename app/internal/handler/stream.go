@@ -0,0 +1,265 @@
+package handler
+
+import (
+	"banner/internal/apierror"
+	usermodels "banner/internal/models/user"
+	"banner/internal/sending"
+	"banner/internal/service"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"time"
+)
+
+const (
+	headerLastEventID = "Last-Event-ID"
+
+	streamEventBanner = "banner"
+	// banner is deleted, deactivated for users or there is no banner for slot
+	streamEventRemoved = "removed"
+
+	streamVersionRemoved = "removed"
+
+	maxStreamFeatures = 50
+)
+
+type StreamConfig struct {
+	// comment sent to idle streams, so proxies do not close them
+	Heartbeat time.Duration
+	// banners are read again this often to see changes made by other replicas, 0 disables
+	ResyncInterval time.Duration
+}
+
+type streamSlot struct {
+	TagID     int `json:"tag_id"`
+	FeatureID int `json:"feature_id"`
+}
+
+type streamBanner struct {
+	streamSlot
+	Content map[string]interface{} `json:"content"`
+	// false only for admins, users get removed event for inactive banner
+	IsActive bool `json:"is_active"`
+}
+
+type streamEvent struct {
+	event string
+	data  any
+}
+
+// state of streamed banners of one tag, versions are the same on every replica
+type bannerStream struct {
+	service         bannerServicer
+	user            usermodels.User
+	tagID           int
+	featureIDs      []int
+	useLastRevision bool
+
+	// by feature, feature is missing if its banner could not be read
+	versions map[int]string
+}
+
+// read banners of features and return events of changed ones
+func (bs *bannerStream) read(ctx context.Context, featureIDs []int) []streamEvent {
+	var events []streamEvent
+	for _, featureID := range featureIDs {
+		slot := streamSlot{TagID: bs.tagID, FeatureID: featureID}
+
+		banner, err := bs.service.GetUserBanner(ctx, bs.user, bs.tagID, featureID, bs.useLastRevision)
+
+		var event streamEvent
+		var version string
+		switch {
+		case errors.Is(err, service.ErrBannerNotFound):
+			event = streamEvent{event: streamEventRemoved, data: slot}
+			version = streamVersionRemoved
+		case err != nil:
+			// sent again on next change or resync
+			if ctx.Err() == nil {
+				slog.WarnContext(ctx, "read streamed banner", "tag_id", bs.tagID, "feature_id", featureID, "err", err)
+			}
+			delete(bs.versions, featureID)
+			continue
+		default:
+			data := streamBanner{streamSlot: slot, Content: banner.Content, IsActive: banner.IsActive}
+			body, err := json.Marshal(data)
+			if err != nil {
+				slog.ErrorContext(ctx, "marshal streamed banner", "err", err)
+				delete(bs.versions, featureID)
+				continue
+			}
+			event = streamEvent{event: streamEventBanner, data: data}
+			version = contentETag(body)
+		}
+
+		if previous, ok := bs.versions[featureID]; ok && previous == version {
+			continue
+		}
+		bs.versions[featureID] = version
+		events = append(events, event)
+	}
+	return events
+}
+
+// id of last event, client that has it has current banners of all features
+func (bs *bannerStream) lastEventID() string {
+	hash := sha256.New()
+	for _, featureID := range bs.featureIDs {
+		fmt.Fprintf(hash, "%d=%s\n", featureID, bs.versions[featureID])
+	}
+	return hex.EncodeToString(hash.Sum(nil)[:16])
+}
+
+// only last event has id, so client that lost connection in the middle gets all of them again
+func (bs *bannerStream) send(sse *sending.SSEWriter, events []streamEvent) error {
+	for i, event := range events {
+		var id string
+		if i == len(events)-1 {
+			id = bs.lastEventID()
+		}
+
+		err := sse.Event(id, event.event, event.data)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// unique feature_id values of query, sorted
+func featureIDsFromQuery(queryParams url.Values) ([]int, error) {
+	values := queryParams[featureIDParamName]
+
+	featureIDs := make([]int, 0, len(values))
+	for _, value := range values {
+		featureID, err := strconv.Atoi(value)
+		if err != nil {
+			return nil, apierror.Invalid(featureIDParamName, apierror.MsgBadFeatureID)
+		}
+		featureIDs = append(featureIDs, featureID)
+	}
+
+	slices.Sort(featureIDs)
+	featureIDs = slices.Compact(featureIDs)
+
+	if len(featureIDs) == 0 || len(featureIDs) > maxStreamFeatures {
+		return nil, apierror.Invalid(featureIDParamName, apierror.MsgBadStreamFeatures, maxStreamFeatures)
+	}
+	return featureIDs, nil
+}
+
+// End all streams, they are not in-flight requests that shutdown can wait for.
+func (h *BannerHandler) CloseStreams() {
+	h.closeStreams()
+}
+
+// Server-sent events with banners of tag and one or more features.
+// Current banners are sent at once, then banners are sent again after they are changed.
+// Client that reconnects with Last-Event-ID of current banners gets only later changes.
+func (h *BannerHandler) StreamUserBanners(w http.ResponseWriter, r *http.Request) {
+	r, span := startSpan(r, "StreamUserBanners")
+	defer span.End()
+
+	queryParams := r.URL.Query()
+
+	tagID, err := tagIDFromQuery(queryParams)
+	if err != nil {
+		h.sendError(w, r, apierror.Invalid(tagIDParamName, apierror.MsgBadTagID))
+		return
+	}
+
+	featureIDs, err := featureIDsFromQuery(queryParams)
+	if err != nil {
+		h.sendError(w, r, err)
+		return
+	}
+
+	useLastRevision := defaultUseLastVersion
+	if queryParams.Has(useLastRevisionParamName) {
+		useLastRevision, err = useLastRevisionFromQuery(queryParams)
+		if err != nil {
+			h.sendError(w, r, apierror.Invalid(useLastRevisionParamName, apierror.MsgBadUseLastRevision))
+			return
+		}
+	}
+
+	user, err := userFromRequest(r)
+	if err != nil {
+		h.sendError(w, r, err)
+		return
+	}
+
+	// stream lives longer than write timeout of server
+	err = http.NewResponseController(w).SetWriteDeadline(time.Time{})
+	if err != nil {
+		h.sendError(w, r, err)
+		return
+	}
+
+	// watch before first read, so changes between them are not lost
+	watch := h.service.WatchUserBanners(tagID, featureIDs)
+	defer watch.Stop()
+
+	ctx := r.Context()
+	stream := &bannerStream{
+		service:         h.service,
+		user:            user,
+		tagID:           tagID,
+		featureIDs:      featureIDs,
+		useLastRevision: useLastRevision,
+		versions:        make(map[int]string, len(featureIDs)),
+	}
+
+	sse := sending.NewSSEWriter(w)
+
+	events := stream.read(ctx, featureIDs)
+	lastEventID := r.Header.Get(headerLastEventID)
+	if lastEventID != "" && lastEventID == stream.lastEventID() {
+		events = nil
+	}
+	if stream.send(sse, events) != nil {
+		return
+	}
+
+	heartbeat := time.NewTicker(h.stream.Heartbeat)
+	defer heartbeat.Stop()
+
+	var resync <-chan time.Time
+	if h.stream.ResyncInterval > 0 {
+		ticker := time.NewTicker(h.stream.ResyncInterval)
+		defer ticker.Stop()
+		resync = ticker.C
+	}
+
+	for {
+		var changed []int
+		select {
+		case <-ctx.Done():
+			return
+		case <-h.streamsCtx.Done():
+			return
+		case <-heartbeat.C:
+			if sse.Comment("heartbeat") != nil {
+				return
+			}
+			continue
+		case <-resync:
+			changed = featureIDs
+		case <-watch.C:
+			changed = watch.Changed()
+		}
+
+		// client has gone if write failed
+		if stream.send(sse, stream.read(ctx, changed)) != nil {
+			return
+		}
+	}
+}
@@ -1,9 +1,18 @@
 package handler
 
 import (
+	"banner/internal/apierror"
+	"banner/internal/constants"
+	"banner/internal/middleware"
+	bannermodels "banner/internal/models/banner"
+	usermodels "banner/internal/models/user"
+	"encoding/json"
 	"errors"
+	"io"
+	"net/http"
 	"net/url"
 	"strconv"
+	"strings"
 )
 
 const (
@@ -13,33 +22,92 @@ const (
 	limitParamName           = "limit"
 	offsetParamName          = "offset"
 	idParamName              = "id"
+	queryParamName           = "q"
+	isActiveParamName        = "is_active"
+	createdFromParamName     = "created_from"
+	createdToParamName       = "created_to"
+	updatedFromParamName     = "updated_from"
+	updatedToParamName       = "updated_to"
+	sortParamName            = "sort"
+	orderParamName           = "order"
+	cursorParamName          = "cursor"
+	withTotalParamName       = "with_total"
+	onConflictParamName      = "on_conflict"
+	dryRunParamName          = "dry_run"
+	previewParamName         = "preview"
+	trashedParamName         = "trashed"
 
-	badTagIDMsg        = "tag_id должен быть целым числом"
-	badTagIDsMsg       = "tag_ids должен быть массивом целых чисел"
-	badContentMsg      = "content должен быть структурой"
-	badFeatureIDMsg    = "feature_id должен быть целым числом"
-	badIsActive        = "is_active должен быть типа bool"
-	badUseLastRevision = "use_last_revision должен быть типа boolean"
-	badLimitMsg        = "limit должен быть целым числом >= 0"
-	badOfssetMsg       = "offset должен быть целым числом >= 0"
-	badIDMsg           = "id должен быть целым числом"
+	previewDraft = "draft"
 
-	noIDinParamsMsg = "нужно указать id"
+	tagIDsFieldName    = "tag_ids"
+	featureIDFieldName = "feature_id"
+	isActiveFieldName  = "is_active"
+	contentFieldName   = "content"
+	bodyFieldName      = "body"
 
-	errMsgCantReadBody = "can not read body"
+	fromBannerIDFieldName = "from_banner_id"
+	toBannerIDFieldName   = "to_banner_id"
+	modeFieldName         = "mode"
 
-	errMsgBannerNotFound      = "баннер не найден"
-	errMsgBannerAlreadyExists = "баннер с такими feature_id и tag_id уже существует"
+	orderAsc  = "asc"
+	orderDesc = "desc"
+
+	// content.<path> for equality, content.<path>[contains] for substring
+	contentFilterParamPrefix = "content."
+	contentFilterOpContains  = "[contains]"
 
 	defaultLimit          = 10
 	defaultOffset         = 0
 	defaultUseLastVersion = false
+	defaultWithTotal      = false
+	defaultImportPolicy   = bannermodels.ImportFailOnConflict
+	defaultDryRun         = false
+	defaultTransferMode   = bannermodels.TransferMove
 )
 
 type BannerIdMsg struct {
 	ID int `json:"banner_id"`
 }
 
+// decode JSON request body to dest, errors are api errors
+func decodeJSONBody(r *http.Request, dest any) error {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		return apierror.InvalidBody()
+	}
+
+	err = json.Unmarshal(body, dest)
+
+	var typeErr *json.UnmarshalTypeError
+	switch {
+	case errors.As(err, &typeErr):
+		return apierror.Invalid(typeErr.Field, apierror.MsgBadFieldType, typeErr.Type.String())
+	case err != nil:
+		return apierror.InvalidJSON()
+	}
+	return nil
+}
+
+func userFromRequest(r *http.Request) (usermodels.User, error) {
+	user, ok := r.Context().Value(middleware.UserKey).(usermodels.User)
+	if !ok {
+		return usermodels.User{}, errors.New(constants.ErrMsgUserNotFoundInCTX)
+	}
+	return user, nil
+}
+
+func dryRunFromQuery(queryParams url.Values) (bool, error) {
+	if !queryParams.Has(dryRunParamName) {
+		return defaultDryRun, nil
+	}
+
+	dryRun, err := strconv.ParseBool(queryParams.Get(dryRunParamName))
+	if err != nil {
+		return false, apierror.Invalid(dryRunParamName, apierror.MsgBadDryRun)
+	}
+	return dryRun, nil
+}
+
 func featureIDFromQuery(queryParams url.Values) (int, error) {
 	return strconv.Atoi(queryParams.Get(featureIDParamName))
 }
@@ -52,6 +120,44 @@ func useLastRevisionFromQuery(queryParams url.Values) (bool, error) {
 	return strconv.ParseBool(queryParams.Get(useLastRevisionParamName))
 }
 
+// parse params like content.url=value and content.url[contains]=value
+func contentFiltersFromQuery(queryParams url.Values) ([]bannermodels.ContentFilter, error) {
+	var filters []bannermodels.ContentFilter
+
+	for name, values := range queryParams {
+		if !strings.HasPrefix(name, contentFilterParamPrefix) {
+			continue
+		}
+
+		pathStr := strings.TrimPrefix(name, contentFilterParamPrefix)
+		op := bannermodels.ContentFilterEq
+		if strings.HasSuffix(pathStr, contentFilterOpContains) {
+			pathStr = strings.TrimSuffix(pathStr, contentFilterOpContains)
+			op = bannermodels.ContentFilterContains
+		}
+
+		path := strings.Split(pathStr, ".")
+		for _, key := range path {
+			if key == "" {
+				return nil, apierror.Invalid(name, apierror.MsgBadContentFilter)
+			}
+		}
+
+		for _, value := range values {
+			if op == bannermodels.ContentFilterContains && value == "" {
+				return nil, apierror.Invalid(name, apierror.MsgBadContentFilter)
+			}
+			filters = append(filters, bannermodels.ContentFilter{
+				Path:  path,
+				Op:    op,
+				Value: value,
+			})
+		}
+	}
+
+	return filters, nil
+}
+
 func StrToUint(str string) (uint, error) {
 	val, err := strconv.ParseUint(str, 10, 32)
 	if err != nil {
@@ -63,12 +169,12 @@ func StrToUint(str string) (uint, error) {
 func IDFromVars(vars map[string]string) (int, error) {
 	idStr, ok := vars[idParamName]
 	if !ok {
-		return 0, errors.New(noIDinParamsMsg)
+		return 0, apierror.Invalid(idParamName, apierror.MsgBadID)
 	}
 
 	id, err := strconv.Atoi(idStr)
 	if err != nil {
-		return 0, errors.New(badIDMsg)
+		return 0, apierror.Invalid(idParamName, apierror.MsgBadID)
 	}
 
 	return id, nil
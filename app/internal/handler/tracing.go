@@ -0,0 +1,16 @@
+package handler
+
+import (
+	"net/http"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var tracer = otel.Tracer("banner/internal/handler")
+
+// start span for handler method, returned request carries span context
+func startSpan(r *http.Request, method string) (*http.Request, trace.Span) {
+	ctx, span := tracer.Start(r.Context(), "BannerHandler."+method)
+	return r.WithContext(ctx), span
+}
@@ -0,0 +1,195 @@
+package handler
+
+import (
+	"banner/internal/apierror"
+	bannermodels "banner/internal/models/banner"
+	webhookmodels "banner/internal/models/webhook"
+	"banner/internal/sending"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/gorilla/mux"
+)
+
+const (
+	deliveryIDParamName = "delivery_id"
+	statusParamName     = "status"
+
+	urlFieldName    = "url"
+	secretFieldName = "secret"
+	eventsFieldName = "events"
+
+	minWebhookSecretLen = 16
+	maxWebhookSecretLen = 256
+
+	defaultDeliveriesLimit = 100
+	maxDeliveriesLimit     = 1000
+)
+
+// absolute http(s) url, unknown events and too short secrets are rejected
+func validateWebhookRequest(req webhookmodels.WebhookRequest) error {
+	target, err := url.Parse(req.URL)
+	if err != nil || (target.Scheme != "http" && target.Scheme != "https") || target.Host == "" {
+		return apierror.Invalid(urlFieldName, apierror.MsgBadWebhookURL)
+	}
+
+	for _, event := range req.Events {
+		if !bannermodels.ChangeType(event).Valid() {
+			return apierror.Invalid(eventsFieldName, apierror.MsgBadWebhookEvents, changeTypesList())
+		}
+	}
+
+	if req.Secret != "" && (len(req.Secret) < minWebhookSecretLen || len(req.Secret) > maxWebhookSecretLen) {
+		return apierror.Invalid(secretFieldName, apierror.MsgBadWebhookSecret, minWebhookSecretLen, maxWebhookSecretLen)
+	}
+
+	return nil
+}
+
+func changeTypesList() string {
+	types := make([]string, len(bannermodels.ChangeTypes))
+	for i, t := range bannermodels.ChangeTypes {
+		types[i] = string(t)
+	}
+	return strings.Join(types, ", ")
+}
+
+// subscription is returned with secret, it is not shown again
+func (h *BannerHandler) CreateWebhook(w http.ResponseWriter, r *http.Request) {
+	r, span := startSpan(r, "CreateWebhook")
+	defer span.End()
+
+	user, err := userFromRequest(r)
+	if err != nil {
+		h.sendError(w, r, err)
+		return
+	}
+
+	var webhookReq webhookmodels.WebhookRequest
+	err = decodeJSONBody(r, &webhookReq)
+	if err != nil {
+		h.sendError(w, r, err)
+		return
+	}
+
+	err = validateWebhookRequest(webhookReq)
+	if err != nil {
+		h.sendError(w, r, err)
+		return
+	}
+
+	hook, err := h.service.CreateWebhook(r.Context(), webhookReq.ToWebhook(user.Name))
+	if err != nil {
+		h.sendError(w, r, err)
+		return
+	}
+
+	sending.JSONMarshallAndSend(w, r, http.StatusCreated, hook)
+}
+
+func (h *BannerHandler) ListWebhooks(w http.ResponseWriter, r *http.Request) {
+	r, span := startSpan(r, "ListWebhooks")
+	defer span.End()
+
+	hooks, err := h.service.ListWebhooks(r.Context())
+	if err != nil {
+		h.sendError(w, r, err)
+		return
+	}
+
+	sending.JSONMarshallAndSend(w, r, http.StatusOK, hooks)
+}
+
+func (h *BannerHandler) DeleteWebhook(w http.ResponseWriter, r *http.Request) {
+	r, span := startSpan(r, "DeleteWebhook")
+	defer span.End()
+
+	id, err := IDFromVars(mux.Vars(r))
+	if err != nil {
+		h.sendError(w, r, err)
+		return
+	}
+
+	err = h.service.DeleteWebhook(r.Context(), id)
+	if err != nil {
+		h.sendError(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// deliveries of one webhook, or of all webhooks on route without id
+func (h *BannerHandler) WebhookDeliveries(w http.ResponseWriter, r *http.Request) {
+	r, span := startSpan(r, "WebhookDeliveries")
+	defer span.End()
+
+	var filter webhookmodels.DeliveryFilter
+	if _, ok := mux.Vars(r)[idParamName]; ok {
+		id, err := IDFromVars(mux.Vars(r))
+		if err != nil {
+			h.sendError(w, r, err)
+			return
+		}
+		filter.WebhookID = id
+	}
+
+	queryParams := r.URL.Query()
+
+	filter.Limit = defaultDeliveriesLimit
+	if queryParams.Has(limitParamName) {
+		limit, err := StrToUint(queryParams.Get(limitParamName))
+		if err != nil {
+			h.sendError(w, r, apierror.Invalid(limitParamName, apierror.MsgBadLimit))
+			return
+		}
+		if limit != 0 {
+			filter.Limit = min(int(limit), maxDeliveriesLimit)
+		}
+	}
+
+	if queryParams.Has(statusParamName) {
+		filter.Status = webhookmodels.DeliveryStatus(queryParams.Get(statusParamName))
+		if !filter.Status.Valid() {
+			h.sendError(w, r, apierror.Invalid(statusParamName, apierror.MsgBadDeliveryStatus))
+			return
+		}
+	}
+
+	deliveries, err := h.service.WebhookDeliveries(r.Context(), filter)
+	if err != nil {
+		h.sendError(w, r, err)
+		return
+	}
+
+	sending.JSONMarshallAndSend(w, r, http.StatusOK, deliveries)
+}
+
+// delivery is sent again with fresh attempts, dead one too
+func (h *BannerHandler) RedeliverWebhook(w http.ResponseWriter, r *http.Request) {
+	r, span := startSpan(r, "RedeliverWebhook")
+	defer span.End()
+
+	vars := mux.Vars(r)
+	id, err := IDFromVars(vars)
+	if err != nil {
+		h.sendError(w, r, err)
+		return
+	}
+
+	deliveryID, err := strconv.ParseInt(vars[deliveryIDParamName], 10, 64)
+	if err != nil {
+		h.sendError(w, r, apierror.Invalid(deliveryIDParamName, apierror.MsgBadID))
+		return
+	}
+
+	delivery, err := h.service.RedeliverWebhook(r.Context(), id, deliveryID)
+	if err != nil {
+		h.sendError(w, r, err)
+		return
+	}
+
+	sending.JSONMarshallAndSend(w, r, http.StatusAccepted, delivery)
+}
@@ -0,0 +1,125 @@
+// Liveness and readiness probes for orchestrators
+package health
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+
+	"banner/internal/sending"
+)
+
+const (
+	LivenessPath  = "/healthz"
+	ReadinessPath = "/readyz"
+
+	StatusOK   = "ok"
+	StatusFail = "fail"
+)
+
+// check of one dependency, nil error means it is ready
+type Check func(ctx context.Context) error
+
+type namedCheck struct {
+	name  string
+	check Check
+}
+
+type CheckResult struct {
+	Status     string `json:"status"`
+	Error      string `json:"error,omitempty"`
+	DurationMS int64  `json:"duration_ms"`
+}
+
+type Report struct {
+	Status string                 `json:"status"`
+	Checks map[string]CheckResult `json:"checks,omitempty"`
+}
+
+// runs all checks concurrently, each is limited by timeout
+type Checker struct {
+	timeout time.Duration
+	checks  []namedCheck
+}
+
+func NewChecker(timeout time.Duration) *Checker {
+	return &Checker{timeout: timeout}
+}
+
+// add readiness check, must be called before serving
+func (c *Checker) Add(name string, check Check) {
+	c.checks = append(c.checks, namedCheck{name: name, check: check})
+}
+
+func (c *Checker) Check(ctx context.Context) Report {
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+
+	report := Report{
+		Status: StatusOK,
+		Checks: make(map[string]CheckResult, len(c.checks)),
+	}
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for _, nc := range c.checks {
+		wg.Add(1)
+		go func(nc namedCheck) {
+			defer wg.Done()
+
+			start := time.Now()
+			err := nc.check(ctx)
+
+			result := CheckResult{Status: StatusOK, DurationMS: time.Since(start).Milliseconds()}
+			if err != nil {
+				result.Status = StatusFail
+				result.Error = err.Error()
+			}
+
+			mu.Lock()
+			defer mu.Unlock()
+			report.Checks[nc.name] = result
+			if err != nil {
+				report.Status = StatusFail
+			}
+		}(nc)
+	}
+	wg.Wait()
+
+	return report
+}
+
+// process is alive while it can answer
+func (c *Checker) Liveness(w http.ResponseWriter, r *http.Request) {
+	sending.JSONMarshallAndSend(w, r, http.StatusOK, Report{Status: StatusOK})
+}
+
+// 503 if any dependency is not ready
+func (c *Checker) Readiness(w http.ResponseWriter, r *http.Request) {
+	report := c.Check(r.Context())
+
+	status := http.StatusOK
+	if report.Status != StatusOK {
+		status = http.StatusServiceUnavailable
+	}
+	sending.JSONMarshallAndSend(w, r, status, report)
+}
+
+// Serve probes before next, so they need no token and are not logged,
+// traced or counted in request metrics.
+func (c *Checker) Mount(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodGet || r.Method == http.MethodHead {
+			switch r.URL.Path {
+			case LivenessPath:
+				c.Liveness(w, r)
+				return
+			case ReadinessPath:
+				c.Readiness(w, r)
+				return
+			}
+		}
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,149 @@
+// Prometheus metrics of the service
+package metrics
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "banner"
+
+// buckets are denser around 50ms latency SLI
+var latencyBuckets = []float64{.001, .0025, .005, .01, .025, .05, .075, .1, .25, .5, 1, 2.5}
+
+type Metrics struct {
+	registry *prometheus.Registry
+
+	httpRequests *prometheus.CounterVec
+	httpDuration *prometheus.HistogramVec
+
+	cacheOps *prometheus.CounterVec
+
+	queryDuration *prometheus.HistogramVec
+
+	webhookDeliveries *prometheus.CounterVec
+
+	eventsPublished *prometheus.CounterVec
+	eventSinkErrors *prometheus.CounterVec
+}
+
+func New() *Metrics {
+	m := &Metrics{
+		registry: prometheus.NewRegistry(),
+
+		httpRequests: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Namespace: namespace,
+				Name:      "http_requests_total",
+				Help:      "Number of HTTP requests by route, method and status code.",
+			},
+			[]string{"route", "method", "code"},
+		),
+		httpDuration: prometheus.NewHistogramVec(
+			prometheus.HistogramOpts{
+				Namespace: namespace,
+				Name:      "http_request_duration_seconds",
+				Help:      "Latency of HTTP requests by route, method and status code.",
+				Buckets:   latencyBuckets,
+			},
+			[]string{"route", "method", "code"},
+		),
+
+		cacheOps: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Namespace: namespace,
+				Name:      "cache_operations_total",
+				Help:      "Banner cache operations by result: hit, miss or error for get, ok or error for set.",
+			},
+			[]string{"op", "result"},
+		),
+
+		queryDuration: prometheus.NewHistogramVec(
+			prometheus.HistogramOpts{
+				Namespace: namespace,
+				Name:      "repo_query_duration_seconds",
+				Help:      "Duration of repository queries by statement.",
+				Buckets:   latencyBuckets,
+			},
+			[]string{"statement"},
+		),
+
+		webhookDeliveries: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Namespace: namespace,
+				Name:      "webhook_deliveries_total",
+				Help:      "Webhook delivery attempts by result: delivered, retry or dead.",
+			},
+			[]string{"result"},
+		),
+
+		eventsPublished: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Namespace: namespace,
+				Name:      "events_published_total",
+				Help:      "Banner events handled by sink.",
+			},
+			[]string{"sink"},
+		),
+		eventSinkErrors: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Namespace: namespace,
+				Name:      "event_sink_errors_total",
+				Help:      "Failed batches of banner events by sink, they are passed to sink again.",
+			},
+			[]string{"sink"},
+		),
+	}
+
+	m.registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		m.httpRequests,
+		m.httpDuration,
+		m.cacheOps,
+		m.queryDuration,
+		m.webhookDeliveries,
+		m.eventsPublished,
+		m.eventSinkErrors,
+	)
+
+	return m
+}
+
+// register additional collector, e.g. pool stats
+func (m *Metrics) MustRegister(cs ...prometheus.Collector) {
+	m.registry.MustRegister(cs...)
+}
+
+func (m *Metrics) Handler() http.Handler {
+	return promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{})
+}
+
+func (m *Metrics) ObserveHTTPRequest(route string, method string, code string, duration time.Duration) {
+	m.httpRequests.WithLabelValues(route, method, code).Inc()
+	m.httpDuration.WithLabelValues(route, method, code).Observe(duration.Seconds())
+}
+
+func (m *Metrics) ObserveCacheOp(op string, result string) {
+	m.cacheOps.WithLabelValues(op, result).Inc()
+}
+
+func (m *Metrics) ObserveQuery(statement string, duration time.Duration) {
+	m.queryDuration.WithLabelValues(statement).Observe(duration.Seconds())
+}
+
+func (m *Metrics) ObserveWebhookDelivery(result string) {
+	m.webhookDeliveries.WithLabelValues(result).Inc()
+}
+
+func (m *Metrics) ObserveEventsPublished(sink string, count int) {
+	m.eventsPublished.WithLabelValues(sink).Add(float64(count))
+}
+
+func (m *Metrics) ObserveEventSinkError(sink string) {
+	m.eventSinkErrors.WithLabelValues(sink).Inc()
+}
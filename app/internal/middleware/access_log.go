@@ -0,0 +1,53 @@
+package middleware
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+const anonymousActor = "anonymous"
+
+type actorKeyT string
+
+const actorKey actorKeyT = "actor"
+
+// Logs every request after it is served. Auth runs inside, so it reports
+// actor through holder put to context.
+func AccessLog(router *mux.Router, logger *slog.Logger, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		recorder := newStatusRecorder(w)
+
+		actor := anonymousActor
+		ctx := context.WithValue(r.Context(), actorKey, &actor)
+
+		next.ServeHTTP(recorder, r.WithContext(ctx))
+
+		level := slog.LevelInfo
+		if recorder.status >= http.StatusInternalServerError {
+			level = slog.LevelError
+		}
+
+		logger.LogAttrs(
+			ctx,
+			level,
+			"request",
+			slog.String("method", r.Method),
+			slog.String("route", routeTemplate(router, r)),
+			slog.String("path", r.URL.Path),
+			slog.Int("status", recorder.status),
+			slog.Duration("latency", time.Since(start)),
+			slog.String("actor", actor),
+		)
+	})
+}
+
+func setActor(ctx context.Context, name string) {
+	if actor, ok := ctx.Value(actorKey).(*string); ok {
+		*actor = name
+	}
+}
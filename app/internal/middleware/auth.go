@@ -4,7 +4,9 @@ import (
 	"context"
 	"net/http"
 
+	"banner/internal/apierror"
 	usermodels "banner/internal/models/user"
+	"banner/internal/sending"
 )
 
 const headerTokenName = "token"
@@ -13,21 +15,27 @@ type userKeyT string
 
 const UserKey userKeyT = "user key"
 
-func AuthMiddleware(userToken string, adminToken string, next http.Handler) http.Handler {
+// adminTokens maps token to admin name
+func AuthMiddleware(userToken string, adminTokens map[string]string, next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		var user usermodels.User
 
 		token := r.Header.Get(headerTokenName)
-		switch token {
-		case userToken:
-			user = usermodels.User{IsAdmin: false}
-		case adminToken:
-			user = usermodels.User{IsAdmin: true}
+		adminName, isAdmin := adminTokens[token]
+		switch {
+		case token == "":
+			sending.SendError(w, r, apierror.Unauthorized())
+			return
+		case isAdmin:
+			user = usermodels.User{Name: adminName, IsAdmin: true}
+		case token == userToken:
+			user = usermodels.User{Name: usermodels.NameUser, IsAdmin: false}
 		default:
-			w.WriteHeader(http.StatusUnauthorized)
+			sending.SendError(w, r, apierror.Unauthorized())
 			return
 		}
 
+		setActor(r.Context(), user.Name)
 		ctx := context.WithValue(r.Context(), UserKey, user)
 
 		next.ServeHTTP(w, r.WithContext(ctx))
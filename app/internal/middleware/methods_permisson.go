@@ -1,9 +1,11 @@
 package middleware
 
 import (
+	"banner/internal/apierror"
 	"banner/internal/constants"
 	usermodels "banner/internal/models/user"
 	"banner/internal/sending"
+	"log/slog"
 	"net/http"
 )
 
@@ -11,12 +13,13 @@ func OnlyAdmin(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		user, ok := r.Context().Value(UserKey).(usermodels.User)
 		if !ok {
-			sending.SendErrorMsg(w, http.StatusInternalServerError, constants.ErrMsgUserNotFoundInCTX)
+			slog.ErrorContext(r.Context(), constants.ErrMsgUserNotFoundInCTX)
+			sending.SendError(w, r, apierror.Internal())
 			return
 		}
 
 		if !user.IsAdmin {
-			w.WriteHeader(http.StatusForbidden)
+			sending.SendError(w, r, apierror.Forbidden())
 			return
 		}
 
@@ -0,0 +1,46 @@
+package middleware
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+const unmatchedRoute = "unmatched"
+
+type httpObserver interface {
+	ObserveHTTPRequest(route string, method string, code string, duration time.Duration)
+}
+
+// Observes every request by route template of router, e.g. /banner/{id:[0-9]+}.
+// Wraps the whole handler chain, so requests rejected by auth are counted too.
+func Metrics(router *mux.Router, observer httpObserver, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		recorder := newStatusRecorder(w)
+
+		next.ServeHTTP(recorder, r)
+
+		observer.ObserveHTTPRequest(
+			routeTemplate(router, r),
+			r.Method,
+			strconv.Itoa(recorder.status),
+			time.Since(start),
+		)
+	})
+}
+
+func routeTemplate(router *mux.Router, r *http.Request) string {
+	var match mux.RouteMatch
+	if !router.Match(r, &match) || match.Route == nil {
+		return unmatchedRoute
+	}
+
+	template, err := match.Route.GetPathTemplate()
+	if err != nil {
+		return unmatchedRoute
+	}
+	return template
+}
@@ -0,0 +1,22 @@
+package middleware
+
+import (
+	"net/http"
+
+	"banner/internal/apierror"
+	"banner/internal/sending"
+)
+
+// for router.NotFoundHandler
+func NotFound() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		sending.SendError(w, r, apierror.RouteNotFound())
+	})
+}
+
+// for router.MethodNotAllowedHandler
+func MethodNotAllowed() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		sending.SendError(w, r, apierror.MethodNotAllowed())
+	})
+}
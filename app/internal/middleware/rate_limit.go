@@ -0,0 +1,65 @@
+package middleware
+
+import (
+	"log/slog"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"banner/internal/apierror"
+	"banner/internal/constants"
+	usermodels "banner/internal/models/user"
+	"banner/internal/ratelimit"
+	"banner/internal/sending"
+)
+
+const (
+	headerRateLimitLimit     = "RateLimit-Limit"
+	headerRateLimitRemaining = "RateLimit-Remaining"
+	headerRateLimitReset     = "RateLimit-Reset"
+	headerRetryAfter         = "Retry-After"
+)
+
+// Token buckets of reads and writes per authenticated identity, must be wrapped by AuthMiddleware.
+// Requests are allowed if limiter fails, so redis outage does not stop the API.
+func RateLimit(limiter ratelimit.Limiter, policy ratelimit.Policy, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		user, ok := r.Context().Value(UserKey).(usermodels.User)
+		if !ok {
+			slog.ErrorContext(r.Context(), constants.ErrMsgUserNotFoundInCTX)
+			sending.SendError(w, r, apierror.Internal())
+			return
+		}
+
+		key, limit := policy.For(user.Name, isWrite(r.Method))
+		result, err := limiter.Allow(r.Context(), key, limit)
+		if err != nil {
+			slog.WarnContext(r.Context(), "rate limit", "err", err)
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		header := w.Header()
+		header.Set(headerRateLimitLimit, strconv.Itoa(result.Limit))
+		header.Set(headerRateLimitRemaining, strconv.Itoa(result.Remaining))
+		header.Set(headerRateLimitReset, ceilSeconds(result.Reset))
+
+		if !result.Allowed {
+			header.Set(headerRetryAfter, ceilSeconds(result.RetryAfter))
+			sending.SendError(w, r, apierror.RateLimited())
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+func isWrite(method string) bool {
+	return method != http.MethodGet && method != http.MethodHead && method != http.MethodOptions
+}
+
+// headers take whole seconds, waiting less than asked is rejected again
+func ceilSeconds(d time.Duration) string {
+	return strconv.Itoa(int(math.Ceil(d.Seconds())))
+}
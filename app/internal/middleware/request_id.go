@@ -0,0 +1,51 @@
+package middleware
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+
+	"banner/internal/logging"
+)
+
+const (
+	HeaderRequestID = "X-Request-ID"
+
+	maxRequestIDLen = 128
+)
+
+// Takes request id from X-Request-ID or generates new one,
+// puts it to request context and echoes it in response.
+func RequestID(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requestID := r.Header.Get(HeaderRequestID)
+		if !validRequestID(requestID) {
+			requestID = newRequestID()
+		}
+
+		w.Header().Set(HeaderRequestID, requestID)
+		ctx := logging.WithRequestID(r.Context(), requestID)
+
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// client ids go to logs and headers, so only short printable ascii is accepted
+func validRequestID(requestID string) bool {
+	if requestID == "" || len(requestID) > maxRequestIDLen {
+		return false
+	}
+	for i := 0; i < len(requestID); i++ {
+		if requestID[i] < '!' || requestID[i] > '~' {
+			return false
+		}
+	}
+	return true
+}
+
+func newRequestID() string {
+	b := make([]byte, 16)
+	// crypto/rand.Read never fails on supported platforms
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
@@ -12,6 +12,18 @@ type Banner struct {
 	IsActive  bool                   `json:"is_active"`
 	CreatedAt time.Time              `json:"created_at"`
 	UpdatedAt time.Time              `json:"updated_at"`
+	// id of banner this one is cloned from
+	ClonedFrom *int `json:"cloned_from,omitempty"`
+	// set only for banners in trash
+	DeletedAt *time.Time `json:"deleted_at,omitempty"`
+	DeletedBy *string    `json:"deleted_by,omitempty"`
+}
+
+// content of banner as user gets it
+type UserBanner struct {
+	Content map[string]interface{}
+	// inactive banners are shown only to admins
+	IsActive bool
 }
 
 func UpdatedBanner(banner Banner, bannerPartial BannerPartialUpdate) (Banner, error) {
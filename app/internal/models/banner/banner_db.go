@@ -6,23 +6,29 @@ import (
 )
 
 type BannerDB struct {
-	ID        int       `db:"id"`
-	TagIDs    []int     `db:"tag_ids"`
-	FeatureID int       `db:"feature_id"`
-	Content   []byte    `db:"content"`
-	IsActive  bool      `db:"is_active"`
-	CreatedAt time.Time `db:"created_at"`
-	UpdatedAt time.Time `db:"updated_at"`
+	ID         int        `db:"id"`
+	TagIDs     []int      `db:"tag_ids"`
+	FeatureID  int        `db:"feature_id"`
+	Content    []byte     `db:"content"`
+	IsActive   bool       `db:"is_active"`
+	CreatedAt  time.Time  `db:"created_at"`
+	UpdatedAt  time.Time  `db:"updated_at"`
+	ClonedFrom *int       `db:"cloned_from"`
+	DeletedAt  *time.Time `db:"deleted_at"`
+	DeletedBy  *string    `db:"deleted_by"`
 }
 
 func (bDB BannerDB) ToBanner() (Banner, error) {
 	b := Banner{
-		ID:        bDB.ID,
-		TagIDs:    bDB.TagIDs,
-		FeatureID: bDB.FeatureID,
-		IsActive:  bDB.IsActive,
-		CreatedAt: bDB.CreatedAt,
-		UpdatedAt: bDB.UpdatedAt,
+		ID:         bDB.ID,
+		TagIDs:     bDB.TagIDs,
+		FeatureID:  bDB.FeatureID,
+		IsActive:   bDB.IsActive,
+		CreatedAt:  bDB.CreatedAt,
+		UpdatedAt:  bDB.UpdatedAt,
+		ClonedFrom: bDB.ClonedFrom,
+		DeletedAt:  bDB.DeletedAt,
+		DeletedBy:  bDB.DeletedBy,
 	}
 
 	err := json.Unmarshal(bDB.Content, &b.Content)
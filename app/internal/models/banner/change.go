@@ -0,0 +1,48 @@
+package banner
+
+import "time"
+
+type ChangeType string
+
+const (
+	ChangeCreated ChangeType = "banner.created"
+	ChangeUpdated ChangeType = "banner.updated"
+	// banner is moved to trash
+	ChangeDeleted  ChangeType = "banner.deleted"
+	ChangeRestored ChangeType = "banner.restored"
+)
+
+var ChangeTypes = []ChangeType{ChangeCreated, ChangeUpdated, ChangeDeleted, ChangeRestored}
+
+func (t ChangeType) Valid() bool {
+	switch t {
+	case ChangeCreated, ChangeUpdated, ChangeDeleted, ChangeRestored:
+		return true
+	}
+	return false
+}
+
+// Committed change of banner, event of banner_events log. Before and After are live states of banner,
+// Before is nil for created and restored banner, After is nil for deleted one.
+type BannerChange struct {
+	// id in banner_events, 0 until change is appended
+	ID         int64      `json:"event_id"`
+	Type       ChangeType `json:"event"`
+	BannerID   int        `json:"banner_id"`
+	OccurredAt time.Time  `json:"occurred_at"`
+	Before     *Banner    `json:"before"`
+	After      *Banner    `json:"after"`
+}
+
+// features and tags of banner before and after change
+func (c BannerChange) Slots() ([]int, []int) {
+	var featureIDs, tagIDs []int
+	for _, b := range []*Banner{c.Before, c.After} {
+		if b == nil {
+			continue
+		}
+		featureIDs = append(featureIDs, b.FeatureID)
+		tagIDs = append(tagIDs, b.TagIDs...)
+	}
+	return featureIDs, tagIDs
+}
@@ -0,0 +1,31 @@
+package banner
+
+// overrides for copy of banner, nil fields are taken from source
+type BannerClone struct {
+	TagIDs    []int `json:"tag_ids"`
+	FeatureID *int  `json:"feature_id"`
+	IsActive  *bool `json:"is_active"`
+}
+
+// new banner with content of source and overridden fields
+func (bc BannerClone) Apply(source Banner) Banner {
+	clone := Banner{
+		TagIDs:     source.TagIDs,
+		FeatureID:  source.FeatureID,
+		Content:    source.Content,
+		IsActive:   source.IsActive,
+		ClonedFrom: &source.ID,
+	}
+
+	if bc.TagIDs != nil {
+		clone.TagIDs = bc.TagIDs
+	}
+	if bc.FeatureID != nil {
+		clone.FeatureID = *bc.FeatureID
+	}
+	if bc.IsActive != nil {
+		clone.IsActive = *bc.IsActive
+	}
+
+	return clone
+}
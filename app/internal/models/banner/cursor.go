@@ -0,0 +1,62 @@
+package banner
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"time"
+)
+
+var ErrBadCursor = errors.New("bad cursor")
+
+// position in banner list: sort key of last returned banner and its id
+type Cursor struct {
+	Sort      SortField `json:"s"`
+	Desc      bool      `json:"d"`
+	Time      time.Time `json:"t,omitempty"`
+	FeatureID int       `json:"f,omitempty"`
+	ID        int       `json:"id"`
+}
+
+func CursorAfter(banner Banner, sort SortField, desc bool) Cursor {
+	c := Cursor{
+		Sort: sort,
+		Desc: desc,
+		ID:   banner.ID,
+	}
+
+	switch sort {
+	case SortByCreatedAt:
+		c.Time = banner.CreatedAt
+	case SortByUpdatedAt:
+		c.Time = banner.UpdatedAt
+	case SortByFeatureID:
+		c.FeatureID = banner.FeatureID
+	}
+
+	return c
+}
+
+// opaque string representation for clients
+func (c Cursor) Encode() (string, error) {
+	data, err := json.Marshal(c)
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(data), nil
+}
+
+func DecodeCursor(s string) (Cursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return Cursor{}, ErrBadCursor
+	}
+
+	var c Cursor
+	err = json.Unmarshal(data, &c)
+	if err != nil || !c.Sort.Valid() {
+		return Cursor{}, ErrBadCursor
+	}
+
+	return c, nil
+}
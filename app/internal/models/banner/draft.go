@@ -0,0 +1,43 @@
+package banner
+
+import "time"
+
+// unpublished copy of banner, live banner is not changed until draft is published
+type Draft struct {
+	BannerID   int                    `json:"banner_id"`
+	TagIDs     []int                  `json:"tag_ids"`
+	FeatureID  int                    `json:"feature_id"`
+	Content    map[string]interface{} `json:"content"`
+	IsActive   bool                   `json:"is_active"`
+	Author     string                 `json:"author"`
+	ApprovedBy *string                `json:"approved_by"`
+	ApprovedAt *time.Time             `json:"approved_at"`
+	CreatedAt  time.Time              `json:"created_at"`
+	UpdatedAt  time.Time              `json:"updated_at"`
+}
+
+func (d Draft) Approved() bool {
+	return d.ApprovedBy != nil
+}
+
+// banner as it will be after publishing
+func (d Draft) ToBanner() Banner {
+	return Banner{
+		ID:        d.BannerID,
+		TagIDs:    d.TagIDs,
+		FeatureID: d.FeatureID,
+		Content:   d.Content,
+		IsActive:  d.IsActive,
+		UpdatedAt: d.UpdatedAt,
+	}
+}
+
+type PublishResult struct {
+	BannerID    int       `json:"banner_id"`
+	TagIDs      []int     `json:"tag_ids"`
+	FeatureID   int       `json:"feature_id"`
+	Author      string    `json:"author"`
+	ApprovedBy  string    `json:"approved_by"`
+	PublishedBy string    `json:"published_by"`
+	PublishedAt time.Time `json:"published_at"`
+}
@@ -1,5 +1,44 @@
 package banner
 
+import "time"
+
+type SortField string
+
+const (
+	SortByCreatedAt SortField = "created_at"
+	SortByUpdatedAt SortField = "updated_at"
+	SortByID        SortField = "id"
+	SortByFeatureID SortField = "feature_id"
+
+	DefaultSortField = SortByCreatedAt
+	DefaultSortDesc  = true
+)
+
+func (sf SortField) Valid() bool {
+	switch sf {
+	case SortByCreatedAt, SortByUpdatedAt, SortByID, SortByFeatureID:
+		return true
+	}
+	return false
+}
+
+type ContentFilterOp string
+
+const (
+	// value at path equals to filter value
+	ContentFilterEq ContentFilterOp = "eq"
+	// string at path contains filter value as substring (case insensitive)
+	ContentFilterContains ContentFilterOp = "contains"
+)
+
+// predicate on banner content, Path is keys from root of content,
+// e.g. content.links.url -> ["links", "url"]
+type ContentFilter struct {
+	Path  []string
+	Op    ContentFilterOp
+	Value string
+}
+
 type FilterSchema struct {
 	HasFeatureID bool
 	FeatureID    int
@@ -7,12 +46,38 @@ type FilterSchema struct {
 	HasTagID bool
 	TagID    int
 
+	// full-text search over string values of content
+	HasQuery bool
+	Query    string
+
+	ContentFilters []ContentFilter
+
+	HasIsActive bool
+	IsActive    bool
+
+	// banners in trash instead of live ones
+	Trashed bool
+
+	// inclusive bounds, nil if not set
+	CreatedFrom *time.Time
+	CreatedTo   *time.Time
+	UpdatedFrom *time.Time
+	UpdatedTo   *time.Time
+
+	Sort SortField
+	Desc bool
+
+	// keyset pagination, banners after Cursor in Sort order
+	Cursor *Cursor
+
 	Limit  int
 	Offset int
 }
 
 func NewFilerSchema(limit int, offset int) FilterSchema {
 	return FilterSchema{
+		Sort:   DefaultSortField,
+		Desc:   DefaultSortDesc,
 		Limit:  limit,
 		Offset: offset,
 	}
@@ -27,3 +92,30 @@ func (fs *FilterSchema) SetTagID(tagID int) {
 	fs.HasTagID = true
 	fs.TagID = tagID
 }
+
+func (fs *FilterSchema) SetQuery(query string) {
+	fs.HasQuery = true
+	fs.Query = query
+}
+
+func (fs *FilterSchema) AddContentFilter(filter ContentFilter) {
+	fs.ContentFilters = append(fs.ContentFilters, filter)
+}
+
+func (fs *FilterSchema) SetIsActive(isActive bool) {
+	fs.HasIsActive = true
+	fs.IsActive = isActive
+}
+
+func (fs *FilterSchema) SetTrashed(trashed bool) {
+	fs.Trashed = trashed
+}
+
+func (fs *FilterSchema) SetSort(sort SortField, desc bool) {
+	fs.Sort = sort
+	fs.Desc = desc
+}
+
+func (fs *FilterSchema) SetCursor(cursor Cursor) {
+	fs.Cursor = &cursor
+}
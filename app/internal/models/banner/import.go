@@ -0,0 +1,164 @@
+package banner
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+)
+
+var ErrBadImportLine = errors.New("bad import line")
+
+type ImportPolicy string
+
+const (
+	// update banner that holds the slots, create if slots are free
+	ImportUpsertBySlot ImportPolicy = "upsert"
+	// abort import if any slot is already taken
+	ImportFailOnConflict ImportPolicy = "fail"
+)
+
+func (p ImportPolicy) Valid() bool {
+	return p == ImportUpsertBySlot || p == ImportFailOnConflict
+}
+
+type ImportAction string
+
+const (
+	ImportCreated   ImportAction = "created"
+	ImportUpdated   ImportAction = "updated"
+	ImportUnchanged ImportAction = "unchanged"
+	ImportConflict  ImportAction = "conflict"
+)
+
+type ImportResult struct {
+	Line     int          `json:"line"`
+	Action   ImportAction `json:"action"`
+	BannerID int          `json:"banner_id,omitempty"`
+	// banners that hold slots of the line, set for conflicts
+	ConflictBannerIDs []int `json:"conflict_banner_ids,omitempty"`
+	// slots released and taken by the line
+	Slots []Slot `json:"-"`
+}
+
+type ImportReport struct {
+	DryRun bool `json:"dry_run"`
+	// false if import was rolled back because of dry run or conflicts
+	Applied   bool           `json:"applied"`
+	Created   int            `json:"created"`
+	Updated   int            `json:"updated"`
+	Unchanged int            `json:"unchanged"`
+	Conflicts int            `json:"conflicts"`
+	Results   []ImportResult `json:"results"`
+}
+
+func (r *ImportReport) Add(result ImportResult) {
+	switch result.Action {
+	case ImportCreated:
+		r.Created++
+	case ImportUpdated:
+		r.Updated++
+	case ImportUnchanged:
+		r.Unchanged++
+	case ImportConflict:
+		r.Conflicts++
+	}
+	r.Results = append(r.Results, result)
+}
+
+// line of import that can not be read as banner, is ErrBadImportLine
+type ImportLineError struct {
+	Line   int
+	Reason string
+}
+
+func (e *ImportLineError) Error() string {
+	return fmt.Sprintf("%v %d: %v", ErrBadImportLine, e.Line, e.Reason)
+}
+
+func (e *ImportLineError) Unwrap() error {
+	return ErrBadImportLine
+}
+
+// reads banners from NDJSON stream, one banner per line in export format
+type NDJSONReader struct {
+	r    *bufio.Reader
+	line int
+}
+
+func NewNDJSONReader(r io.Reader) *NDJSONReader {
+	return &NDJSONReader{r: bufio.NewReader(r)}
+}
+
+// return io.EOF after last banner, blank lines are skipped but counted
+func (r *NDJSONReader) Next() (Banner, error) {
+	for {
+		raw, err := r.r.ReadBytes('\n')
+		if len(raw) == 0 && errors.Is(err, io.EOF) {
+			return Banner{}, io.EOF
+		}
+		if err != nil && !errors.Is(err, io.EOF) {
+			return Banner{}, err
+		}
+
+		r.line++
+		raw = bytes.TrimSpace(raw)
+		if len(raw) == 0 {
+			continue
+		}
+
+		return r.decodeLine(raw)
+	}
+}
+
+// line must hold exactly one JSON object
+func (r *NDJSONReader) decodeLine(raw []byte) (Banner, error) {
+	var banner Banner
+
+	dec := json.NewDecoder(bytes.NewReader(raw))
+	err := dec.Decode(&banner)
+	if err != nil {
+		return Banner{}, &ImportLineError{Line: r.line, Reason: err.Error()}
+	}
+	if dec.InputOffset() != int64(len(raw)) {
+		return Banner{}, &ImportLineError{Line: r.line, Reason: "line must hold one JSON object"}
+	}
+	if reason := importLineReason(banner); reason != "" {
+		return Banner{}, &ImportLineError{Line: r.line, Reason: reason}
+	}
+
+	return banner, nil
+}
+
+// line must set slots of banner like create request, empty reason if line is valid
+func importLineReason(banner Banner) string {
+	switch {
+	case banner.FeatureID <= 0:
+		return "feature_id must be positive"
+	case len(banner.TagIDs) == 0:
+		return "tag_ids must be non-empty"
+	case banner.Content == nil:
+		return "content is required"
+	}
+	for _, tagID := range banner.TagIDs {
+		if tagID <= 0 {
+			return "tag_ids must be positive"
+		}
+	}
+	return ""
+}
+
+// number of last read line
+func (r *NDJSONReader) Line() int {
+	return r.line
+}
+
+// source of banners for import
+type BannerReader interface {
+	// return io.EOF after last banner
+	Next() (Banner, error)
+	// number of last read line
+	Line() int
+}
@@ -0,0 +1,10 @@
+package banner
+
+// page of banner list for cursor pagination
+type BannerPage struct {
+	Items []Banner `json:"items"`
+	// null if there are no more banners
+	NextCursor *string `json:"next_cursor"`
+	// set only if total count was requested
+	Total *int `json:"total,omitempty"`
+}
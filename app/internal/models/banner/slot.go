@@ -0,0 +1,8 @@
+package banner
+
+// pair (tag_id, feature_id) held by banner, every slot belongs to one banner at most
+type Slot struct {
+	TagID     int `json:"tag_id" db:"tag_id"`
+	FeatureID int `json:"feature_id" db:"feature_id"`
+	BannerID  int `json:"banner_id" db:"banner_id"`
+}
@@ -0,0 +1,17 @@
+package banner
+
+// set is_active of all banners holding slots of feature and/or tags
+type BannerToggle struct {
+	FeatureID *int  `json:"feature_id"`
+	TagIDs    []int `json:"tag_ids"`
+	IsActive  *bool `json:"is_active"`
+	// deactivate whole feature and drop cache of all its slots
+	KillSwitch bool `json:"kill_switch"`
+}
+
+type ToggleResult struct {
+	// banners whose state is changed or would be changed in dry run
+	BannerIDs []int `json:"banner_ids"`
+	IsActive  bool  `json:"is_active"`
+	DryRun    bool  `json:"dry_run"`
+}
@@ -0,0 +1,27 @@
+package banner
+
+type TransferMode string
+
+const (
+	// slots of from banner go to banner to
+	TransferMove TransferMode = "move"
+	// slots held by one of banners go to the other
+	TransferSwap TransferMode = "swap"
+)
+
+func (m TransferMode) Valid() bool {
+	return m == TransferMove || m == TransferSwap
+}
+
+// reassign slots (tag_id, feature_id) of common feature between two banners
+type SlotTransfer struct {
+	FromBannerID int          `json:"from_banner_id"`
+	ToBannerID   int          `json:"to_banner_id"`
+	TagIDs       []int        `json:"tag_ids"`
+	Mode         TransferMode `json:"mode"`
+}
+
+type TransferResult struct {
+	// transferred slots with their new holders
+	Slots []Slot `json:"slots"`
+}
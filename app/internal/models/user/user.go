@@ -1,5 +1,12 @@
 package user
 
+const (
+	NameUser  = "user"
+	NameAdmin = "admin"
+)
+
 type User struct {
+	// shown in logs as actor
+	Name    string
 	IsAdmin bool
 }
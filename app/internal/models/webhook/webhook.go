@@ -0,0 +1,90 @@
+package webhook
+
+import (
+	"encoding/json"
+	"time"
+)
+
+type DeliveryStatus string
+
+const (
+	StatusPending   DeliveryStatus = "pending"
+	StatusDelivered DeliveryStatus = "delivered"
+	// attempts are exhausted, delivery is sent again only by redelivery
+	StatusDead DeliveryStatus = "dead"
+)
+
+func (s DeliveryStatus) Valid() bool {
+	return s == StatusPending || s == StatusDelivered || s == StatusDead
+}
+
+// Subscription to banner changes, nil FeatureID and TagID match any banner,
+// empty Events match any change.
+type Webhook struct {
+	ID  int    `json:"webhook_id" db:"id"`
+	URL string `json:"url" db:"url"`
+	// HMAC key of payloads, it is shown only on creation
+	Secret    string    `json:"secret,omitempty" db:"secret"`
+	FeatureID *int      `json:"feature_id" db:"feature_id"`
+	TagID     *int      `json:"tag_id" db:"tag_id"`
+	Events    []string  `json:"events" db:"events"`
+	CreatedBy string    `json:"created_by" db:"created_by"`
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+}
+
+type WebhookRequest struct {
+	URL       string   `json:"url"`
+	Secret    string   `json:"secret"`
+	FeatureID *int     `json:"feature_id"`
+	TagID     *int     `json:"tag_id"`
+	Events    []string `json:"events"`
+}
+
+func (wr WebhookRequest) ToWebhook(createdBy string) Webhook {
+	return Webhook{
+		URL:       wr.URL,
+		Secret:    wr.Secret,
+		FeatureID: wr.FeatureID,
+		TagID:     wr.TagID,
+		Events:    wr.Events,
+		CreatedBy: createdBy,
+	}
+}
+
+type Delivery struct {
+	ID        int64 `json:"delivery_id" db:"id"`
+	WebhookID int   `json:"webhook_id" db:"webhook_id"`
+	// banner event of delivery, it is nil for deliveries written before event log
+	EventID  *int64          `json:"event_id,omitempty" db:"event_id"`
+	Event    string          `json:"event" db:"event"`
+	Payload  json.RawMessage `json:"payload" db:"payload"`
+	Status   DeliveryStatus  `json:"status" db:"status"`
+	Attempts int             `json:"attempts" db:"attempts"`
+	// next try of pending delivery
+	NextAttemptAt  time.Time  `json:"next_attempt_at" db:"next_attempt_at"`
+	LastError      *string    `json:"last_error,omitempty" db:"last_error"`
+	LastStatusCode *int       `json:"last_status_code,omitempty" db:"last_status_code"`
+	CreatedAt      time.Time  `json:"created_at" db:"created_at"`
+	DeliveredAt    *time.Time `json:"delivered_at,omitempty" db:"delivered_at"`
+}
+
+// delivery taken by dispatcher with target of its webhook
+type ClaimedDelivery struct {
+	Delivery
+	URL    string
+	Secret string
+}
+
+// result of one attempt, StatusCode is 0 if there was no response
+type Attempt struct {
+	DeliveryID int64
+	StatusCode int
+	Err        error
+}
+
+type DeliveryFilter struct {
+	WebhookID int
+	// any status if empty
+	Status DeliveryStatus
+	Limit  int
+}
@@ -0,0 +1,60 @@
+// Token bucket rate limiting of API clients
+package ratelimit
+
+import (
+	"context"
+	"math"
+	"time"
+)
+
+const (
+	BackendMemory = "memory"
+	BackendRedis  = "redis"
+)
+
+// bucket refills with Rate tokens per second up to Burst, request takes one token
+type Limit struct {
+	Rate  float64
+	Burst int
+}
+
+type Result struct {
+	Allowed bool
+	Limit   int
+	// whole tokens left after request
+	Remaining int
+	// wait until next token if not allowed
+	RetryAfter time.Duration
+	// time until bucket is full again
+	Reset time.Duration
+}
+
+type Limiter interface {
+	Allow(ctx context.Context, key string, limit Limit) (Result, error)
+}
+
+// refill bucket by elapsed time and take one token if there is one
+func take(tokens float64, elapsed time.Duration, limit Limit) (float64, bool) {
+	tokens = math.Min(float64(limit.Burst), tokens+elapsed.Seconds()*limit.Rate)
+	if tokens < 1 {
+		return tokens, false
+	}
+	return tokens - 1, true
+}
+
+func newResult(tokens float64, allowed bool, limit Limit) Result {
+	result := Result{
+		Allowed:   allowed,
+		Limit:     limit.Burst,
+		Remaining: int(tokens),
+		Reset:     seconds((float64(limit.Burst) - tokens) / limit.Rate),
+	}
+	if !allowed {
+		result.RetryAfter = seconds((1 - tokens) / limit.Rate)
+	}
+	return result
+}
+
+func seconds(s float64) time.Duration {
+	return time.Duration(s * float64(time.Second))
+}
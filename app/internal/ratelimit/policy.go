@@ -0,0 +1,27 @@
+package ratelimit
+
+// limits of reads and writes of each identity, overrides are keyed by identity name
+type Policy struct {
+	Read      Limit
+	Write     Limit
+	Overrides map[string]Override
+}
+
+// limits of one identity instead of policy ones
+type Override struct {
+	Read  Limit
+	Write Limit
+}
+
+// bucket key and limit of identity, reads and writes of identity have separate buckets
+func (p Policy) For(name string, write bool) (string, Limit) {
+	read, writeLimit := p.Read, p.Write
+	if override, ok := p.Overrides[name]; ok {
+		read, writeLimit = override.Read, override.Write
+	}
+
+	if write {
+		return "write:" + name, writeLimit
+	}
+	return "read:" + name, read
+}
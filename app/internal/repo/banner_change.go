@@ -0,0 +1,62 @@
+package repo
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/jackc/pgx/v4"
+
+	bannermodels "banner/internal/models/banner"
+)
+
+// key of advisory lock which orders appends to banner_events
+const eventLogLockKey = 0x62616e6e6572 // "banner"
+
+// states of banner stored in payload of event
+type eventPayload struct {
+	Before *bannermodels.Banner `json:"before"`
+	After  *bannermodels.Banner `json:"after"`
+}
+
+// Append change to banner_events in transaction of change, so event exists only if change is committed.
+// Other appending transactions wait for commit of tx, it should be called right before commit.
+func recordChange(ctx context.Context, tx pgx.Tx, change bannermodels.BannerChange) error {
+	payload, err := json.Marshal(eventPayload{Before: change.Before, After: change.After})
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(ctx, stmtLockEventLog, eventLogLockKey)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(ctx, stmtAppendEvent, string(change.Type), change.BannerID, payload)
+	return err
+}
+
+// change of banner with its state after change read in tx,
+// before is nil for created and restored banner
+func bannerChange(ctx context.Context, tx pgx.Tx, changeType bannermodels.ChangeType, id int, before *bannermodels.Banner) (bannermodels.BannerChange, error) {
+	after, err := getBannerByID(ctx, tx, id)
+	if err != nil {
+		return bannermodels.BannerChange{}, err
+	}
+
+	return bannermodels.BannerChange{
+		Type:     changeType,
+		BannerID: id,
+		Before:   before,
+		After:    &after,
+	}, nil
+}
+
+// record change of banner, see bannerChange
+func recordBannerChange(ctx context.Context, tx pgx.Tx, changeType bannermodels.ChangeType, id int, before *bannermodels.Banner) error {
+	change, err := bannerChange(ctx, tx, changeType, id, before)
+	if err != nil {
+		return err
+	}
+
+	return recordChange(ctx, tx, change)
+}
@@ -0,0 +1,246 @@
+package repo
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+
+	"github.com/jackc/pgconn"
+	"github.com/jackc/pgx/v4"
+
+	bannermodels "banner/internal/models/banner"
+	"banner/internal/service"
+)
+
+// create or replace draft of banner, approval of previous draft is dropped
+func (repo *BannerRepo) SaveDraft(ctx context.Context, draft bannermodels.Draft) (_ bannermodels.Draft, err error) {
+	ctx, done := repo.observe(ctx, stmtNameSaveDraft)
+	defer func() { done(err) }()
+
+	contentJSON, err := json.Marshal(draft.Content)
+	if err != nil {
+		return bannermodels.Draft{}, err
+	}
+
+	row := repo.db.QueryRow(
+		ctx,
+		stmtSaveDraft,
+		draft.BannerID,
+		draft.TagIDs,
+		draft.FeatureID,
+		draft.IsActive,
+		contentJSON,
+		draft.Author,
+	)
+
+	saved, err := scanDraft(row)
+	if err != nil {
+		// no row if banner is missing or in trash, foreign key fails if it is purged concurrently
+		var pgErr *pgconn.PgError
+		if errors.Is(err, pgx.ErrNoRows) || errors.As(err, &pgErr) && pgErr.Code == SQLForeignKeyErrCode {
+			return bannermodels.Draft{}, service.ErrDBBannerNotFound
+		}
+		return bannermodels.Draft{}, err
+	}
+
+	return saved, nil
+}
+
+func (repo *BannerRepo) GetDraft(ctx context.Context, bannerID int) (_ bannermodels.Draft, err error) {
+	ctx, done := repo.observe(ctx, stmtNameGetDraft)
+	defer func() { done(err) }()
+
+	draft, err := scanDraft(repo.db.QueryRow(ctx, stmtGetDraft, bannerID))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return bannermodels.Draft{}, service.ErrDBDraftNotFound
+	}
+	return draft, err
+}
+
+// the most recently edited draft that holds slot (tagID, featureID)
+func (repo *BannerRepo) GetDraftForSlot(ctx context.Context, tagID int, featureID int) (_ bannermodels.Draft, err error) {
+	ctx, done := repo.observe(ctx, stmtNameGetDraftForSlot)
+	defer func() { done(err) }()
+
+	draft, err := scanDraft(repo.db.QueryRow(ctx, stmtGetDraftForSlot, tagID, featureID))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return bannermodels.Draft{}, service.ErrDBDraftNotFound
+	}
+	return draft, err
+}
+
+func (repo *BannerRepo) DeleteDraft(ctx context.Context, bannerID int) (err error) {
+	ctx, done := repo.observe(ctx, stmtNameDeleteDraft)
+	defer func() { done(err) }()
+
+	var id int
+	err = repo.db.QueryRow(ctx, stmtDeleteDraft, bannerID).Scan(&id)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return service.ErrDBDraftNotFound
+	}
+	return err
+}
+
+// record approver of draft, author can not approve own draft
+func (repo *BannerRepo) ApproveDraft(ctx context.Context, bannerID int, approver string) (_ bannermodels.Draft, err error) {
+	ctx, done := repo.observe(ctx, stmtNameApproveDraft)
+	defer func() { done(err) }()
+
+	tx, err := repo.db.Begin(ctx)
+	if err != nil {
+		return bannermodels.Draft{}, err
+	}
+	defer tx.Rollback(ctx)
+
+	draft, err := lockDraft(ctx, tx, bannerID)
+	if err != nil {
+		return bannermodels.Draft{}, err
+	}
+
+	if draft.Author == approver {
+		return bannermodels.Draft{}, service.ErrSelfApproval
+	}
+
+	approved, err := scanDraft(tx.QueryRow(ctx, stmtApproveDraft, bannerID, approver))
+	if err != nil {
+		return bannermodels.Draft{}, err
+	}
+
+	err = tx.Commit(ctx)
+	if err != nil {
+		return bannermodels.Draft{}, err
+	}
+	return approved, nil
+}
+
+// Replace live banner with approved draft and delete draft in one transaction.
+// Banner as it was before publishing is returned too.
+func (repo *BannerRepo) PublishDraft(ctx context.Context, bannerID int, publisher string) (_ bannermodels.PublishResult, _ bannermodels.Banner, err error) {
+	ctx, done := repo.observe(ctx, stmtNamePublishDraft)
+	defer func() { done(err) }()
+
+	tx, err := repo.db.Begin(ctx)
+	if err != nil {
+		return bannermodels.PublishResult{}, bannermodels.Banner{}, err
+	}
+	defer tx.Rollback(ctx)
+
+	draft, err := lockDraft(ctx, tx, bannerID)
+	if err != nil {
+		return bannermodels.PublishResult{}, bannermodels.Banner{}, err
+	}
+
+	if !draft.Approved() {
+		return bannermodels.PublishResult{}, bannermodels.Banner{}, service.ErrDraftNotApproved
+	}
+
+	// live banner exists while it has draft, it is needed to drop cache of old slots
+	live, err := getBannerByID(ctx, tx, bannerID)
+	if err != nil {
+		return bannermodels.PublishResult{}, bannermodels.Banner{}, err
+	}
+
+	contentJSON, err := json.Marshal(draft.Content)
+	if err != nil {
+		return bannermodels.PublishResult{}, bannermodels.Banner{}, err
+	}
+
+	result := bannermodels.PublishResult{
+		BannerID:    bannerID,
+		TagIDs:      draft.TagIDs,
+		FeatureID:   draft.FeatureID,
+		Author:      draft.Author,
+		ApprovedBy:  *draft.ApprovedBy,
+		PublishedBy: publisher,
+	}
+
+	batch := &pgx.Batch{}
+	batch.Queue(stmtDeleteBannerRelations, bannerID)
+	batch.Queue(stmtInsertNewTagIDs, bannerID, draft.FeatureID, draft.TagIDs)
+	batch.Queue(stmtDeleteDraft, bannerID)
+	batch.Queue(
+		stmtPublishBanner,
+		bannerID,
+		draft.TagIDs,
+		draft.FeatureID,
+		draft.IsActive,
+		contentJSON,
+		*draft.ApprovedBy,
+		publisher,
+	)
+
+	br := tx.SendBatch(ctx, batch)
+	for i := 0; i != batch.Len()-1; i++ {
+		_, err = br.Exec()
+		if err != nil {
+			br.Close()
+
+			var pgErr *pgconn.PgError
+			if errors.As(err, &pgErr) && pgErr.Code == SQLDuplicateErrCode {
+				tx.Rollback(ctx)
+				return bannermodels.PublishResult{}, bannermodels.Banner{}, repo.slotConflict(ctx, draft.FeatureID, draft.TagIDs, bannerID)
+			}
+			return bannermodels.PublishResult{}, bannermodels.Banner{}, err
+		}
+	}
+
+	err = br.QueryRow().Scan(&result.PublishedAt)
+	if err != nil {
+		br.Close()
+		return bannermodels.PublishResult{}, bannermodels.Banner{}, err
+	}
+
+	err = br.Close()
+	if err != nil {
+		return bannermodels.PublishResult{}, bannermodels.Banner{}, err
+	}
+
+	err = recordBannerChange(ctx, tx, bannermodels.ChangeUpdated, bannerID, &live)
+	if err != nil {
+		return bannermodels.PublishResult{}, bannermodels.Banner{}, err
+	}
+
+	err = tx.Commit(ctx)
+	if err != nil {
+		return bannermodels.PublishResult{}, bannermodels.Banner{}, err
+	}
+
+	return result, live, nil
+}
+
+func lockDraft(ctx context.Context, tx pgx.Tx, bannerID int) (bannermodels.Draft, error) {
+	draft, err := scanDraft(tx.QueryRow(ctx, stmtLockDraft, bannerID))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return bannermodels.Draft{}, service.ErrDBDraftNotFound
+	}
+	return draft, err
+}
+
+// row must have columns of draftColumns
+func scanDraft(row pgx.Row) (bannermodels.Draft, error) {
+	var draft bannermodels.Draft
+	var contentJSON []byte
+
+	err := row.Scan(
+		&draft.BannerID,
+		&draft.TagIDs,
+		&draft.FeatureID,
+		&contentJSON,
+		&draft.IsActive,
+		&draft.Author,
+		&draft.ApprovedBy,
+		&draft.ApprovedAt,
+		&draft.CreatedAt,
+		&draft.UpdatedAt,
+	)
+	if err != nil {
+		return bannermodels.Draft{}, err
+	}
+
+	err = json.Unmarshal(contentJSON, &draft.Content)
+	if err != nil {
+		return bannermodels.Draft{}, err
+	}
+
+	return draft, nil
+}
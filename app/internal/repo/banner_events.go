@@ -0,0 +1,132 @@
+package repo
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/jackc/pgx/v4"
+
+	bannermodels "banner/internal/models/banner"
+)
+
+type eventQuerier interface {
+	Query(ctx context.Context, sql string, args ...interface{}) (pgx.Rows, error)
+}
+
+func eventsAfter(ctx context.Context, q eventQuerier, after int64, limit int) ([]bannermodels.BannerChange, error) {
+	return queryEvents(ctx, q, stmtEventsAfter, after, limit)
+}
+
+// args of stmt are after and limit, followed by filters
+func queryEvents(ctx context.Context, q eventQuerier, stmt string, args ...interface{}) ([]bannermodels.BannerChange, error) {
+	rows, err := q.Query(ctx, stmt, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	events := []bannermodels.BannerChange{}
+	for rows.Next() {
+		var event bannermodels.BannerChange
+		var payload []byte
+		err = rows.Scan(&event.ID, &event.Type, &event.BannerID, &payload, &event.OccurredAt)
+		if err != nil {
+			return nil, err
+		}
+
+		var states eventPayload
+		err = json.Unmarshal(payload, &states)
+		if err != nil {
+			return nil, err
+		}
+		event.Before, event.After = states.Before, states.After
+
+		events = append(events, event)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return events, nil
+}
+
+// up to limit events with id greater than after, oldest first, only of banner bannerID if it is not 0
+func (repo *BannerRepo) BannerEvents(ctx context.Context, after int64, limit int, bannerID int) (_ []bannermodels.BannerChange, err error) {
+	ctx, done := repo.observe(ctx, stmtNameBannerEvents)
+	defer func() { done(err) }()
+
+	if bannerID != 0 {
+		return queryEvents(ctx, repo.db, stmtBannerEventsAfter, after, limit, bannerID)
+	}
+	return eventsAfter(ctx, repo.db, after, limit)
+}
+
+// Pass up to limit events after cursor of sink to fn and move cursor past them if fn succeeds.
+// Cursor stays locked while fn runs, so sink handled by other replica is skipped and 0 is returned.
+// Returns count of handled events.
+func (repo *BannerRepo) ConsumeEvents(
+	ctx context.Context,
+	sink string,
+	limit int,
+	fn func([]bannermodels.BannerChange) error,
+) (_ int, err error) {
+	ctx, done := repo.observe(ctx, stmtNameConsumeEvents)
+	defer func() { done(err) }()
+
+	_, err = repo.db.Exec(ctx, stmtCreateEventCursor, sink)
+	if err != nil {
+		return 0, err
+	}
+
+	tx, err := repo.db.Begin(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback(ctx)
+
+	var cursor int64
+	err = tx.QueryRow(ctx, stmtLockEventCursor, sink).Scan(&cursor)
+	switch {
+	case errors.Is(err, pgx.ErrNoRows):
+		return 0, nil
+	case err != nil:
+		return 0, err
+	}
+
+	events, err := eventsAfter(ctx, tx, cursor, limit)
+	if err != nil || len(events) == 0 {
+		return 0, err
+	}
+
+	err = fn(events)
+	if err != nil {
+		return 0, err
+	}
+
+	_, err = tx.Exec(ctx, stmtAdvanceEventCursor, sink, events[len(events)-1].ID)
+	if err != nil {
+		return 0, err
+	}
+
+	err = tx.Commit(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	return len(events), nil
+}
+
+// delete events older than retention, returns count of deleted
+func (repo *BannerRepo) PurgeEvents(ctx context.Context, retention time.Duration) (_ int, err error) {
+	ctx, done := repo.observe(ctx, stmtNamePurgeEvents)
+	defer func() { done(err) }()
+
+	ct, err := repo.db.Exec(ctx, stmtPurgeEvents, retention)
+	if err != nil {
+		return 0, err
+	}
+
+	return int(ct.RowsAffected()), nil
+}
@@ -0,0 +1,267 @@
+package repo
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+
+	"github.com/jackc/pgconn"
+	"github.com/jackc/pgx/v4"
+
+	bannermodels "banner/internal/models/banner"
+	"banner/internal/service"
+	"banner/internal/tools"
+)
+
+// call fn for every banner matching filter without loading all of them into memory,
+// limit, offset and cursor of filter are ignored
+func (repo *BannerRepo) ExportBanners(
+	ctx context.Context,
+	filter bannermodels.FilterSchema,
+	fn func(bannermodels.Banner) error,
+) (err error) {
+	ctx, done := repo.observe(ctx, stmtNameExportBanners)
+	defer func() { done(err) }()
+
+	qa := newQueryArgs()
+
+	whereClause, err := filterWhereClause(filter, qa, false)
+	if err != nil {
+		return err
+	}
+
+	orderBy, err := orderByClause(filter.Sort, filter.Desc)
+	if err != nil {
+		return err
+	}
+
+	rows, err := repo.db.Query(ctx, fmt.Sprintf(stmtBannerExportTemplate, whereClause, orderBy), qa.args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var bDB bannermodels.BannerDB
+		err = rows.Scan(
+			&bDB.ID,
+			&bDB.FeatureID,
+			&bDB.TagIDs,
+			&bDB.Content,
+			&bDB.IsActive,
+			&bDB.CreatedAt,
+			&bDB.UpdatedAt,
+		)
+		if err != nil {
+			return err
+		}
+
+		banner, err := bDB.ToBanner()
+		if err != nil {
+			return err
+		}
+
+		err = fn(banner)
+		if err != nil {
+			return err
+		}
+	}
+
+	return rows.Err()
+}
+
+// import all banners from reader in one transaction,
+// transaction is rolled back on dry run and on conflicts with ImportFailOnConflict policy
+func (repo *BannerRepo) ImportBanners(
+	ctx context.Context,
+	reader bannermodels.BannerReader,
+	policy bannermodels.ImportPolicy,
+	dryRun bool,
+) (_ bannermodels.ImportReport, err error) {
+	ctx, done := repo.observe(ctx, stmtNameImportBanners)
+	defer func() { done(err) }()
+
+	report := bannermodels.ImportReport{DryRun: dryRun}
+
+	tx, err := repo.db.Begin(ctx)
+	if err != nil {
+		return report, err
+	}
+	defer tx.Rollback(ctx)
+
+	// events are appended after body is read, lock of event log is held only until commit
+	var changes []bannermodels.BannerChange
+	for {
+		banner, err := reader.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return report, err
+		}
+
+		result, change, err := repo.importBanner(ctx, tx, banner, policy)
+		if err != nil {
+			return report, err
+		}
+		if change != nil {
+			changes = append(changes, *change)
+		}
+
+		result.Line = reader.Line()
+		report.Add(result)
+	}
+
+	if dryRun || (policy == bannermodels.ImportFailOnConflict && report.Conflicts != 0) {
+		return report, nil
+	}
+
+	for _, change := range changes {
+		err = recordChange(ctx, tx, change)
+		if err != nil {
+			return report, err
+		}
+	}
+
+	err = tx.Commit(ctx)
+	if err != nil {
+		return report, err
+	}
+
+	report.Applied = true
+	return report, nil
+}
+
+func (repo *BannerRepo) importBanner(
+	ctx context.Context,
+	tx pgx.Tx,
+	banner bannermodels.Banner,
+	policy bannermodels.ImportPolicy,
+) (bannermodels.ImportResult, *bannermodels.BannerChange, error) {
+	var holders []int
+	err := tx.QueryRow(ctx, stmtSlotHolders, banner.FeatureID, banner.TagIDs).Scan(&holders)
+	if err != nil {
+		return bannermodels.ImportResult{}, nil, err
+	}
+
+	contentJSON, err := json.Marshal(banner.Content)
+	if err != nil {
+		return bannermodels.ImportResult{}, nil, err
+	}
+
+	switch {
+	case len(holders) == 0:
+		var id int
+		err = tx.QueryRow(
+			ctx,
+			stmtCreateBanner,
+			banner.TagIDs,
+			banner.FeatureID,
+			banner.IsActive,
+			contentJSON,
+		).Scan(&id)
+		if err != nil {
+			return bannermodels.ImportResult{}, nil, importErr(err)
+		}
+
+		change, err := bannerChange(ctx, tx, bannermodels.ChangeCreated, id, nil)
+		if err != nil {
+			return bannermodels.ImportResult{}, nil, err
+		}
+
+		return bannermodels.ImportResult{
+			Action:   bannermodels.ImportCreated,
+			BannerID: id,
+			Slots:    bannerSlots(id, banner.FeatureID, banner.TagIDs),
+		}, &change, nil
+
+	// slots of one line can be replaced only in one banner
+	case policy == bannermodels.ImportFailOnConflict || len(holders) > 1:
+		return bannermodels.ImportResult{
+			Action:            bannermodels.ImportConflict,
+			ConflictBannerIDs: holders,
+		}, nil, nil
+	}
+
+	id := holders[0]
+	existing, err := getBannerByID(ctx, tx, id)
+	if err != nil {
+		return bannermodels.ImportResult{}, nil, err
+	}
+
+	if sameBannerState(existing, banner) {
+		return bannermodels.ImportResult{Action: bannermodels.ImportUnchanged, BannerID: id}, nil, nil
+	}
+
+	batch := &pgx.Batch{}
+	batch.Queue(stmtReplaceBanner, id, banner.TagIDs, banner.FeatureID, banner.IsActive, contentJSON)
+	batch.Queue(stmtDeleteBannerRelations, id)
+	batch.Queue(stmtInsertNewTagIDs, id, banner.FeatureID, banner.TagIDs)
+
+	br := tx.SendBatch(ctx, batch)
+	for i := 0; i != batch.Len(); i++ {
+		_, err = br.Exec()
+		if err != nil {
+			br.Close()
+			return bannermodels.ImportResult{}, nil, importErr(err)
+		}
+	}
+
+	err = br.Close()
+	if err != nil {
+		return bannermodels.ImportResult{}, nil, err
+	}
+
+	change, err := bannerChange(ctx, tx, bannermodels.ChangeUpdated, id, &existing)
+	if err != nil {
+		return bannermodels.ImportResult{}, nil, err
+	}
+
+	return bannermodels.ImportResult{
+		Action:   bannermodels.ImportUpdated,
+		BannerID: id,
+		Slots: append(
+			bannerSlots(id, existing.FeatureID, existing.TagIDs),
+			bannerSlots(id, banner.FeatureID, banner.TagIDs)...,
+		),
+	}, &change, nil
+}
+
+func getBannerByID(ctx context.Context, tx pgx.Tx, id int) (bannermodels.Banner, error) {
+	var bDB bannermodels.BannerDB
+	err := tx.QueryRow(ctx, stmtGetBannerByID, id).Scan(
+		&bDB.ID,
+		&bDB.FeatureID,
+		&bDB.TagIDs,
+		&bDB.Content,
+		&bDB.IsActive,
+		&bDB.CreatedAt,
+		&bDB.UpdatedAt,
+	)
+	if err != nil {
+		return bannermodels.Banner{}, err
+	}
+
+	return bDB.ToBanner()
+}
+
+// compare fields that are set by import
+func sameBannerState(lhs bannermodels.Banner, rhs bannermodels.Banner) bool {
+	return lhs.FeatureID == rhs.FeatureID &&
+		lhs.IsActive == rhs.IsActive &&
+		len(lhs.TagIDs) == len(rhs.TagIDs) &&
+		len(tools.SliceDiff(lhs.TagIDs, rhs.TagIDs)) == 0 &&
+		reflect.DeepEqual(lhs.Content, rhs.Content)
+}
+
+// duplicated tags in one line violate unique constraint of relations
+func importErr(err error) error {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == SQLDuplicateErrCode {
+		return service.ErrDBBannerAlreadyExists
+	}
+	return err
+}
@@ -6,32 +6,70 @@ import (
 	"errors"
 	"fmt"
 	"strings"
+	"time"
 
 	"github.com/jackc/pgconn"
 	"github.com/jackc/pgx/v4"
+	"go.opentelemetry.io/otel"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
 
 	bannermodels "banner/internal/models/banner"
 	"banner/internal/service"
 	"banner/internal/tools"
+	"banner/internal/tracing"
 )
 
 type database interface {
 	Begin(ctx context.Context) (pgx.Tx, error)
+	Exec(ctx context.Context, query string, args ...interface{}) (pgconn.CommandTag, error)
 	QueryRow(ctx context.Context, query string, args ...interface{}) pgx.Row
+	Query(ctx context.Context, query string, args ...interface{}) (pgx.Rows, error)
 	Select(ctx context.Context, dest interface{}, query string, args ...interface{}) error
 }
 
+var tracer = otel.Tracer("banner/internal/repo")
+
+type queryObserver interface {
+	ObserveQuery(statement string, duration time.Duration)
+}
+
 type BannerRepo struct {
-	db database
+	db       database
+	observer queryObserver
 }
 
-func NewBannerRepo(db database) *BannerRepo {
+func NewBannerRepo(db database, observer queryObserver) *BannerRepo {
 	return &BannerRepo{
-		db: db,
+		db:       db,
+		observer: observer,
 	}
 }
 
-func (repo *BannerRepo) CreateBanner(ctx context.Context, banner bannermodels.Banner) (int, error) {
+// start span and timing of statement, returned func must be called with result error when it is done
+func (repo *BannerRepo) observe(ctx context.Context, statement string) (context.Context, func(err error)) {
+	ctx, span := tracer.Start(
+		ctx,
+		"BannerRepo."+statement,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			semconv.DBSystemPostgreSQL,
+			semconv.DBOperationName(statement),
+		),
+	)
+
+	start := time.Now()
+	return ctx, func(err error) {
+		repo.observer.ObserveQuery(statement, time.Since(start))
+		tracing.RecordError(span, err)
+		span.End()
+	}
+}
+
+func (repo *BannerRepo) CreateBanner(ctx context.Context, banner bannermodels.Banner) (_ int, err error) {
+	ctx, done := repo.observe(ctx, stmtNameCreateBanner)
+	defer func() { done(err) }()
+
 	tx, err := repo.db.Begin(ctx)
 	if err != nil {
 		return 0, err
@@ -58,24 +96,94 @@ func (repo *BannerRepo) CreateBanner(ctx context.Context, banner bannermodels.Ba
 	if err != nil {
 		var pgErr *pgconn.PgError
 		if errors.As(err, &pgErr) && pgErr.Code == SQLDuplicateErrCode {
-			return 0, service.ErrBannerAlreadyExists
+			tx.Rollback(ctx)
+			return 0, repo.slotConflict(ctx, banner.FeatureID, banner.TagIDs, 0)
 		}
 
 		return 0, err
 	}
 
-	tx.Commit(ctx)
+	err = recordBannerChange(ctx, tx, bannermodels.ChangeCreated, id, nil)
+	if err != nil {
+		return 0, err
+	}
+
+	err = tx.Commit(ctx)
+	if err != nil {
+		return 0, err
+	}
 	return id, nil
 }
 
-func (repo *BannerRepo) GetUserBanner(ctx context.Context, tagID int, featureID int) (bannermodels.Banner, error) {
+// create banner with content of source banner and overridden fields, returns slots of new banner
+func (repo *BannerRepo) CloneBanner(ctx context.Context, sourceID int, clone bannermodels.BannerClone) (_ int, _ []bannermodels.Slot, err error) {
+	ctx, done := repo.observe(ctx, stmtNameCloneBanner)
+	defer func() { done(err) }()
+
+	tx, err := repo.db.Begin(ctx)
+	if err != nil {
+		return 0, nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	source, err := getBannerByID(ctx, tx, sourceID)
+	switch {
+	case errors.Is(err, pgx.ErrNoRows):
+		return 0, nil, service.ErrDBBannerNotFound
+	case err != nil:
+		return 0, nil, err
+	}
+
+	banner := clone.Apply(source)
+
+	contentJSON, err := json.Marshal(banner.Content)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	var id int
+	err = tx.QueryRow(
+		ctx,
+		stmtCloneBanner,
+		banner.TagIDs,
+		banner.FeatureID,
+		banner.IsActive,
+		contentJSON,
+		banner.ClonedFrom,
+	).Scan(&id)
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == SQLDuplicateErrCode {
+			tx.Rollback(ctx)
+			return 0, nil, repo.slotConflict(ctx, banner.FeatureID, banner.TagIDs, 0)
+		}
+		return 0, nil, err
+	}
+
+	err = recordBannerChange(ctx, tx, bannermodels.ChangeCreated, id, nil)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	err = tx.Commit(ctx)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	return id, bannerSlots(id, banner.FeatureID, banner.TagIDs), nil
+}
+
+func (repo *BannerRepo) GetUserBanner(ctx context.Context, tagID int, featureID int) (_ bannermodels.Banner, err error) {
+	ctx, done := repo.observe(ctx, stmtNameGetUserBanner)
+	defer func() { done(err) }()
+
 	// ADD TRANSATION ?
 	row := repo.db.QueryRow(ctx, stmtGetUserBanner, tagID, featureID)
 
 	var contentJSON []byte
 	var banner bannermodels.Banner
 
-	err := row.Scan(
+	err = row.Scan(
 		&banner.ID,
 		&banner.FeatureID,
 		&banner.TagIDs,
@@ -99,10 +207,14 @@ func (repo *BannerRepo) GetUserBanner(ctx context.Context, tagID int, featureID
 	return banner, nil
 }
 
-func (repo *BannerRepo) PartialUpdateBanner(ctx context.Context, id int, bannerPartial bannermodels.BannerPartialUpdate) error {
+// update fields of banner, returns its slots before and after update
+func (repo *BannerRepo) PartialUpdateBanner(ctx context.Context, id int, bannerPartial bannermodels.BannerPartialUpdate) (_ []bannermodels.Slot, err error) {
+	ctx, done := repo.observe(ctx, stmtNamePartialUpdateBanner)
+	defer func() { done(err) }()
+
 	tx, err := repo.db.Begin(ctx)
 	if err != nil {
-		return err
+		return nil, err
 	}
 	defer tx.Rollback(ctx)
 
@@ -123,19 +235,19 @@ func (repo *BannerRepo) PartialUpdateBanner(ctx context.Context, id int, bannerP
 
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
-			return service.ErrBannerNotFound
+			return nil, service.ErrBannerNotFound
 		}
-		return err
+		return nil, err
 	}
 
 	err = json.Unmarshal(contentJSON, &banner.Content)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	updatedBanner, err := bannermodels.UpdatedBanner(banner, bannerPartial)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	batch := &pgx.Batch{}
@@ -165,7 +277,7 @@ func (repo *BannerRepo) PartialUpdateBanner(ctx context.Context, id int, bannerP
 		len(updateArgs)+1,
 	)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	if len(updateArgsExtend) != 0 {
@@ -185,13 +297,15 @@ func (repo *BannerRepo) PartialUpdateBanner(ctx context.Context, id int, bannerP
 		if err != nil {
 			var pgErr *pgconn.PgError
 			if errors.As(err, &pgErr) && pgErr.Code == SQLDuplicateErrCode {
-				return service.ErrBannerAlreadyExists
+				br.Close()
+				tx.Rollback(ctx)
+				return nil, repo.slotConflict(ctx, updatedBanner.FeatureID, updatedBanner.TagIDs, id)
 			}
-			return err
+			return nil, err
 		}
 
 		if ct.RowsAffected() == 0 {
-			return fmt.Errorf(
+			return nil, fmt.Errorf(
 				"err update banner with id=%d, rows_affected=%v must be >= 1",
 				id,
 				ct.RowsAffected(),
@@ -201,26 +315,47 @@ func (repo *BannerRepo) PartialUpdateBanner(ctx context.Context, id int, bannerP
 
 	err = br.Close()
 	if err != nil {
-		return err
+		return nil, err
+	}
+
+	err = recordBannerChange(ctx, tx, bannermodels.ChangeUpdated, id, &banner)
+	if err != nil {
+		return nil, err
 	}
 
 	err = tx.Commit(ctx)
 	if err != nil {
-		return err
+		return nil, err
 	}
-	return nil
-}
 
-func (repo *BannerRepo) GetFiltered(ctx context.Context, filter bannermodels.FilterSchema) ([]bannermodels.Banner, error) {
-	if !(filter.HasFeatureID || filter.HasTagID) {
-		return repo.getFiltered(ctx, filter)
+	// old slots are released and new ones are taken
+	slots := bannerSlots(id, banner.FeatureID, banner.TagIDs)
+	if bannerPartial.TagIDs != nil || bannerPartial.FeatureID != nil {
+		slots = append(slots, bannerSlots(id, updatedBanner.FeatureID, updatedBanner.TagIDs)...)
 	}
-	return repo.getFilteredWithFeatureAndTagFilter(ctx, filter)
+	return slots, nil
 }
 
-func (repo *BannerRepo) getFiltered(ctx context.Context, filter bannermodels.FilterSchema) ([]bannermodels.Banner, error) {
+func (repo *BannerRepo) GetFiltered(ctx context.Context, filter bannermodels.FilterSchema) (_ []bannermodels.Banner, err error) {
+	ctx, done := repo.observe(ctx, stmtNameBannerList)
+	defer func() { done(err) }()
+
+	qa := newQueryArgs(filter.Limit, filter.Offset)
+
+	whereClause, err := filterWhereClause(filter, qa, true)
+	if err != nil {
+		return nil, err
+	}
+
+	orderBy, err := orderByClause(filter.Sort, filter.Desc)
+	if err != nil {
+		return nil, err
+	}
+
+	stmtBannerList := fmt.Sprintf(stmtBannerListTemplate, whereClause, orderBy)
+
 	var dbBanners []bannermodels.BannerDB
-	err := repo.db.Select(ctx, &dbBanners, stmtBannerList, filter.Limit, filter.Offset)
+	err = repo.db.Select(ctx, &dbBanners, stmtBannerList, qa.args...)
 	if err != nil {
 		return nil, err
 	}
@@ -228,69 +363,81 @@ func (repo *BannerRepo) getFiltered(ctx context.Context, filter bannermodels.Fil
 	return bannermodels.SliceBannerDBToBanners(dbBanners)
 }
 
-func (repo *BannerRepo) getFilteredWithFeatureAndTagFilter(ctx context.Context, filter bannermodels.FilterSchema) ([]bannermodels.Banner, error) {
-	var stmtWhereFilter string
-	args := []interface{}{
-		filter.Limit,
-		filter.Offset,
-	}
+// count of banners matching filter, cursor, limit and offset are ignored
+func (repo *BannerRepo) CountFiltered(ctx context.Context, filter bannermodels.FilterSchema) (_ int, err error) {
+	ctx, done := repo.observe(ctx, stmtNameBannerCount)
+	defer func() { done(err) }()
 
-	switch {
-	case filter.HasFeatureID && filter.HasTagID:
-		stmtWhereFilter = "feature_id=$3 AND tag_id=$4"
-		args = append(args, filter.FeatureID, filter.TagID)
-	case filter.HasFeatureID:
-		stmtWhereFilter = "feature_id=$3"
-		args = append(args, filter.FeatureID)
-	case filter.HasTagID:
-		args = append(args, filter.TagID)
-		stmtWhereFilter = "tag_id=$3"
-	default:
-		return nil, fmt.Errorf(
-			"at least one of the HasFeatureID and HasTagID should be true, got: %v, %v",
-			filter.HasFeatureID,
-			filter.HasTagID,
-		)
-	}
+	qa := newQueryArgs()
 
-	stmtBannerListWithFilter := fmt.Sprintf(stmtBannerListWithFilterTemplate, stmtWhereFilter)
+	whereClause, err := filterWhereClause(filter, qa, false)
+	if err != nil {
+		return 0, err
+	}
 
-	var dbBanners []bannermodels.BannerDB
-	err := repo.db.Select(
-		ctx,
-		&dbBanners,
-		stmtBannerListWithFilter,
-		args...,
-	)
+	var count int
+	err = repo.db.QueryRow(ctx, fmt.Sprintf(stmtBannerCountTemplate, whereClause), qa.args...).Scan(&count)
 	if err != nil {
-		return nil, err
+		return 0, err
 	}
 
-	return bannermodels.SliceBannerDBToBanners(dbBanners)
+	return count, nil
 }
 
-func (repo *BannerRepo) DeleteBanner(ctx context.Context, id int) error {
+// move banner to trash, its relations and draft are deleted, so slots are free,
+// returns freed slots
+func (repo *BannerRepo) DeleteBanner(ctx context.Context, id int, deletedBy string) (_ []bannermodels.Slot, err error) {
+	ctx, done := repo.observe(ctx, stmtNameDeleteBanner)
+	defer func() { done(err) }()
+
 	tx, err := repo.db.Begin(ctx)
 	if err != nil {
-		return err
+		return nil, err
 	}
 	defer tx.Rollback(ctx)
 
-	ct, err := tx.Exec(ctx, stmtDeleteBanner, id)
+	before, err := getBannerByID(ctx, tx, id)
+	switch {
+	case errors.Is(err, pgx.ErrNoRows):
+		return nil, service.ErrDBBannerNotFound
+	case err != nil:
+		return nil, err
+	}
+
+	var featureID int
+	var tagIDs []int
+	err = tx.QueryRow(ctx, stmtTrashBanner, id, deletedBy).Scan(&featureID, &tagIDs)
+	switch {
+	case errors.Is(err, pgx.ErrNoRows):
+		return nil, service.ErrDBBannerNotFound
+	case err != nil:
+		return nil, err
+	}
+
+	batch := &pgx.Batch{}
+	batch.Queue(stmtDeleteBannerRelations, id)
+	batch.Queue(stmtDeleteDraft, id)
+
+	err = tx.SendBatch(ctx, batch).Close()
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	if ct.RowsAffected() == 0 {
-		return service.ErrDBBannerNotFound
+	err = recordChange(ctx, tx, bannermodels.BannerChange{
+		Type:     bannermodels.ChangeDeleted,
+		BannerID: id,
+		Before:   &before,
+	})
+	if err != nil {
+		return nil, err
 	}
 
 	err = tx.Commit(ctx)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	return nil
+	return bannerSlots(id, featureID, tagIDs), nil
 }
 
 func (repo *BannerRepo) formUpdateArgsFields(
@@ -0,0 +1,155 @@
+package repo
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/georgysavva/scany/pgxscan"
+	"github.com/jackc/pgx/v4"
+
+	bannermodels "banner/internal/models/banner"
+	"banner/internal/service"
+	"banner/internal/tools"
+)
+
+// Build conflict error with banners holding requested slots.
+// Must be called outside of failed transaction, banner excludeID is not a holder.
+func (repo *BannerRepo) slotConflict(ctx context.Context, featureID int, tagIDs []int, excludeID int) error {
+	var slots []bannermodels.Slot
+	err := repo.db.Select(ctx, &slots, stmtSlotConflicts, featureID, tagIDs, excludeID)
+	if err != nil {
+		return err
+	}
+
+	return &service.SlotConflictError{Slots: slots}
+}
+
+// all slots of feature ordered by tag
+func (repo *BannerRepo) FeatureSlots(ctx context.Context, featureID int) (_ []bannermodels.Slot, err error) {
+	ctx, done := repo.observe(ctx, stmtNameFeatureSlots)
+	defer func() { done(err) }()
+
+	slots := []bannermodels.Slot{}
+	err = repo.db.Select(ctx, &slots, stmtFeatureSlots, featureID)
+	if err != nil {
+		return nil, err
+	}
+
+	return slots, nil
+}
+
+// Reassign slots between two banners of one feature in single transaction,
+// both banner.tag_ids and banner_relation are updated.
+func (repo *BannerRepo) TransferSlots(ctx context.Context, transfer bannermodels.SlotTransfer) (_ bannermodels.TransferResult, err error) {
+	ctx, done := repo.observe(ctx, stmtNameTransferSlots)
+	defer func() { done(err) }()
+
+	tx, err := repo.db.Begin(ctx)
+	if err != nil {
+		return bannermodels.TransferResult{}, err
+	}
+	defer tx.Rollback(ctx)
+
+	from, to, err := lockTransferBanners(ctx, tx, transfer.FromBannerID, transfer.ToBannerID)
+	if err != nil {
+		return bannermodels.TransferResult{}, err
+	}
+
+	if from.FeatureID != to.FeatureID {
+		return bannermodels.TransferResult{}, service.ErrFeatureMismatch
+	}
+
+	fromBefore, err := getBannerByID(ctx, tx, from.ID)
+	if err != nil {
+		return bannermodels.TransferResult{}, err
+	}
+	toBefore, err := getBannerByID(ctx, tx, to.ID)
+	if err != nil {
+		return bannermodels.TransferResult{}, err
+	}
+
+	if transfer.Mode == bannermodels.TransferMove {
+		if notHeld := tools.SliceDiff(transfer.TagIDs, from.TagIDs); len(notHeld) != 0 {
+			return bannermodels.TransferResult{}, fmt.Errorf("%w: tags %v", service.ErrSlotNotHeld, notHeld)
+		}
+	}
+
+	var slots []bannermodels.Slot
+	err = pgxscan.Select(ctx, tx, &slots, stmtTransferSlots, from.ID, to.ID, from.FeatureID, transfer.TagIDs)
+	if err != nil {
+		return bannermodels.TransferResult{}, err
+	}
+
+	if len(slots) != len(tools.SetFromSlice(transfer.TagIDs)) {
+		moved := make([]int, len(slots))
+		for i, slot := range slots {
+			moved[i] = slot.TagID
+		}
+		return bannermodels.TransferResult{}, fmt.Errorf(
+			"%w: tags %v", service.ErrSlotNotHeld, tools.SliceDiff(transfer.TagIDs, moved),
+		)
+	}
+
+	toFrom := make([]int, 0, len(slots))
+	toTo := make([]int, 0, len(slots))
+	for _, slot := range slots {
+		if slot.BannerID == from.ID {
+			toFrom = append(toFrom, slot.TagID)
+		} else {
+			toTo = append(toTo, slot.TagID)
+		}
+	}
+
+	batch := &pgx.Batch{}
+	batch.Queue(stmtSetBannerTagIDs, from.ID, append(tools.SliceDiff(from.TagIDs, toTo), toFrom...))
+	batch.Queue(stmtSetBannerTagIDs, to.ID, append(tools.SliceDiff(to.TagIDs, toFrom), toTo...))
+
+	err = tx.SendBatch(ctx, batch).Close()
+	if err != nil {
+		return bannermodels.TransferResult{}, err
+	}
+
+	for _, before := range []bannermodels.Banner{fromBefore, toBefore} {
+		err = recordBannerChange(ctx, tx, bannermodels.ChangeUpdated, before.ID, &before)
+		if err != nil {
+			return bannermodels.TransferResult{}, err
+		}
+	}
+
+	err = tx.Commit(ctx)
+	if err != nil {
+		return bannermodels.TransferResult{}, err
+	}
+
+	return bannermodels.TransferResult{Slots: slots}, nil
+}
+
+// lock both banners in id order, so concurrent transfers do not deadlock
+func lockTransferBanners(ctx context.Context, tx pgx.Tx, fromID int, toID int) (bannermodels.Banner, bannermodels.Banner, error) {
+	rows, err := tx.Query(ctx, stmtLockBannersForTransfer, []int{fromID, toID})
+	if err != nil {
+		return bannermodels.Banner{}, bannermodels.Banner{}, err
+	}
+	defer rows.Close()
+
+	banners := make(map[int]bannermodels.Banner, 2)
+	for rows.Next() {
+		var banner bannermodels.Banner
+		err = rows.Scan(&banner.ID, &banner.FeatureID, &banner.TagIDs)
+		if err != nil {
+			return bannermodels.Banner{}, bannermodels.Banner{}, err
+		}
+		banners[banner.ID] = banner
+	}
+	if err = rows.Err(); err != nil {
+		return bannermodels.Banner{}, bannermodels.Banner{}, err
+	}
+
+	from, okFrom := banners[fromID]
+	to, okTo := banners[toID]
+	if !okFrom || !okTo {
+		return bannermodels.Banner{}, bannermodels.Banner{}, service.ErrDBBannerNotFound
+	}
+
+	return from, to, nil
+}
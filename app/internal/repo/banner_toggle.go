@@ -0,0 +1,109 @@
+package repo
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	bannermodels "banner/internal/models/banner"
+)
+
+// set is_active of banners matching toggle in one statement, banners already
+// in target state are skipped, returns ids and slots of changed banners,
+// nothing is changed in dry run, change of every banner is appended to event log
+func (repo *BannerRepo) ToggleBanners(ctx context.Context, toggle bannermodels.BannerToggle, dryRun bool) (_ []int, _ []bannermodels.Slot, err error) {
+	ctx, done := repo.observe(ctx, stmtNameToggleBanners)
+	defer func() { done(err) }()
+
+	qa := newQueryArgs(*toggle.IsActive)
+
+	relationConditions := make([]string, 0, 2)
+	if toggle.FeatureID != nil {
+		relationConditions = append(relationConditions, "feature_id="+qa.add(*toggle.FeatureID))
+	}
+	if len(toggle.TagIDs) != 0 {
+		relationConditions = append(relationConditions, fmt.Sprintf("tag_id = ANY(%v::int[])", qa.add(toggle.TagIDs)))
+	}
+	if len(relationConditions) == 0 {
+		return nil, nil, fmt.Errorf("toggle without feature_id and tag_ids")
+	}
+
+	whereClause := strings.Join([]string{
+		"b.deleted_at IS NULL",
+		"b.is_active <> $1",
+		fmt.Sprintf(stmtRelationFilterCond, strings.Join(relationConditions, " AND ")),
+	}, " AND ")
+
+	template := stmtToggleBannersTemplate
+	if dryRun {
+		template = stmtToggleTargetsTemplate
+	}
+
+	tx, err := repo.db.Begin(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	rows, err := tx.Query(ctx, fmt.Sprintf(template, whereClause), qa.args...)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer rows.Close()
+
+	ids := make([]int, 0)
+	updatedAt := make(map[int]time.Time)
+	var slots []bannermodels.Slot
+	for rows.Next() {
+		var id, featureID int
+		var tagIDs []int
+		var previousUpdatedAt time.Time
+		err = rows.Scan(&id, &featureID, &tagIDs, &previousUpdatedAt)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		ids = append(ids, id)
+		updatedAt[id] = previousUpdatedAt
+		slots = append(slots, bannerSlots(id, featureID, tagIDs)...)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, nil, err
+	}
+
+	sort.Ints(ids)
+	if dryRun {
+		return ids, slots, nil
+	}
+
+	// only is_active and updated_at are changed, so state before is derived from state after
+	for _, id := range ids {
+		after, err := getBannerByID(ctx, tx, id)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		before := after
+		before.IsActive = !after.IsActive
+		before.UpdatedAt = updatedAt[id]
+
+		err = recordChange(ctx, tx, bannermodels.BannerChange{
+			Type:     bannermodels.ChangeUpdated,
+			BannerID: id,
+			Before:   &before,
+			After:    &after,
+		})
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
+	err = tx.Commit(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return ids, slots, nil
+}
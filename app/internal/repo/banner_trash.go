@@ -0,0 +1,84 @@
+package repo
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/jackc/pgconn"
+	"github.com/jackc/pgx/v4"
+
+	bannermodels "banner/internal/models/banner"
+	"banner/internal/service"
+)
+
+// take banner out of trash back to its slots, if any of them is taken
+// by another banner, conflict is returned and banner stays in trash,
+// returns restored slots
+func (repo *BannerRepo) RestoreBanner(ctx context.Context, id int) (_ []bannermodels.Slot, err error) {
+	ctx, done := repo.observe(ctx, stmtNameRestoreBanner)
+	defer func() { done(err) }()
+
+	tx, err := repo.db.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	var featureID int
+	var tagIDs []int
+	err = tx.QueryRow(ctx, stmtLockTrashedBanner, id).Scan(&featureID, &tagIDs)
+	switch {
+	case errors.Is(err, pgx.ErrNoRows):
+		return nil, service.ErrDBBannerNotFound
+	case err != nil:
+		return nil, err
+	}
+
+	batch := &pgx.Batch{}
+	batch.Queue(stmtInsertNewTagIDs, id, featureID, tagIDs)
+	batch.Queue(stmtRestoreBanner, id)
+
+	err = tx.SendBatch(ctx, batch).Close()
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == SQLDuplicateErrCode {
+			tx.Rollback(ctx)
+			return nil, repo.slotConflict(ctx, featureID, tagIDs, id)
+		}
+		return nil, err
+	}
+
+	err = recordBannerChange(ctx, tx, bannermodels.ChangeRestored, id, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	err = tx.Commit(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return bannerSlots(id, featureID, tagIDs), nil
+}
+
+func bannerSlots(bannerID int, featureID int, tagIDs []int) []bannermodels.Slot {
+	slots := make([]bannermodels.Slot, len(tagIDs))
+	for i, tagID := range tagIDs {
+		slots[i] = bannermodels.Slot{TagID: tagID, FeatureID: featureID, BannerID: bannerID}
+	}
+	return slots
+}
+
+// delete banners that are in trash longer than retention, returns count of deleted
+func (repo *BannerRepo) PurgeTrash(ctx context.Context, retention time.Duration) (_ int, err error) {
+	ctx, done := repo.observe(ctx, stmtNamePurgeTrash)
+	defer func() { done(err) }()
+
+	ct, err := repo.db.Exec(ctx, stmtPurgeTrash, retention)
+	if err != nil {
+		return 0, err
+	}
+
+	return int(ct.RowsAffected()), nil
+}
@@ -0,0 +1,230 @@
+package repo
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	bannermodels "banner/internal/models/banner"
+)
+
+const (
+	// config must be the same as in banner_content_tsv index
+	stmtContentFullTextCond = `jsonb_to_tsvector('simple', b.content, '["string"]') @@ websearch_to_tsquery('simple', %v)`
+
+	stmtRelationFilterCond = `b.id IN (SELECT banner_id FROM banner_relation WHERE %v)`
+)
+
+// collects positional args for query and returns placeholder for each added arg
+type queryArgs struct {
+	args []interface{}
+}
+
+func newQueryArgs(args ...interface{}) *queryArgs {
+	return &queryArgs{args: args}
+}
+
+func (qa *queryArgs) add(arg interface{}) string {
+	qa.args = append(qa.args, arg)
+	return fmt.Sprintf("$%d", len(qa.args))
+}
+
+// return WHERE clause for filter,
+// keyset condition for filter.Cursor is added only if withCursor
+func filterWhereClause(filter bannermodels.FilterSchema, qa *queryArgs, withCursor bool) (string, error) {
+	conditions := make([]string, 0, 9+len(filter.ContentFilters))
+
+	if filter.Trashed {
+		// banners in trash have no relations, so slots are matched by own columns
+		conditions = append(conditions, "b.deleted_at IS NOT NULL")
+		if filter.HasFeatureID {
+			conditions = append(conditions, "b.feature_id="+qa.add(filter.FeatureID))
+		}
+		if filter.HasTagID {
+			conditions = append(conditions, qa.add(filter.TagID)+" = ANY(b.tag_ids)")
+		}
+	} else {
+		conditions = append(conditions, "b.deleted_at IS NULL")
+
+		relationConditions := make([]string, 0, 2)
+		if filter.HasFeatureID {
+			relationConditions = append(relationConditions, "feature_id="+qa.add(filter.FeatureID))
+		}
+		if filter.HasTagID {
+			relationConditions = append(relationConditions, "tag_id="+qa.add(filter.TagID))
+		}
+		if len(relationConditions) != 0 {
+			conditions = append(
+				conditions,
+				fmt.Sprintf(stmtRelationFilterCond, strings.Join(relationConditions, " AND ")),
+			)
+		}
+	}
+
+	if filter.HasIsActive {
+		conditions = append(conditions, "b.is_active="+qa.add(filter.IsActive))
+	}
+
+	if filter.CreatedFrom != nil {
+		conditions = append(conditions, "b.created_at >= "+qa.add(*filter.CreatedFrom))
+	}
+	if filter.CreatedTo != nil {
+		conditions = append(conditions, "b.created_at <= "+qa.add(*filter.CreatedTo))
+	}
+	if filter.UpdatedFrom != nil {
+		conditions = append(conditions, "b.updated_at >= "+qa.add(*filter.UpdatedFrom))
+	}
+	if filter.UpdatedTo != nil {
+		conditions = append(conditions, "b.updated_at <= "+qa.add(*filter.UpdatedTo))
+	}
+
+	if filter.HasQuery {
+		conditions = append(conditions, fmt.Sprintf(stmtContentFullTextCond, qa.add(filter.Query)))
+	}
+
+	for _, contentFilter := range filter.ContentFilters {
+		cond, err := contentFilterCond(contentFilter, qa)
+		if err != nil {
+			return "", err
+		}
+		conditions = append(conditions, cond)
+	}
+
+	if withCursor && filter.Cursor != nil {
+		cond, err := cursorCond(*filter.Cursor, qa)
+		if err != nil {
+			return "", err
+		}
+		conditions = append(conditions, cond)
+	}
+
+	return "WHERE " + strings.Join(conditions, " AND "), nil
+}
+
+func sortColumn(sort bannermodels.SortField) (string, error) {
+	switch sort {
+	case bannermodels.SortByCreatedAt:
+		return "b.created_at", nil
+	case bannermodels.SortByUpdatedAt:
+		return "b.updated_at", nil
+	case bannermodels.SortByID:
+		return "b.id", nil
+	case bannermodels.SortByFeatureID:
+		return "b.feature_id", nil
+	default:
+		return "", fmt.Errorf("unknown sort field: %v", sort)
+	}
+}
+
+// id is always the last sort key, so order is total and keyset pagination is stable
+func orderByClause(sort bannermodels.SortField, desc bool) (string, error) {
+	column, err := sortColumn(sort)
+	if err != nil {
+		return "", err
+	}
+
+	direction := "ASC"
+	if desc {
+		direction = "DESC"
+	}
+
+	if sort == bannermodels.SortByID {
+		return fmt.Sprintf("b.id %v", direction), nil
+	}
+	return fmt.Sprintf("%v %v, b.id %v", column, direction, direction), nil
+}
+
+func cursorCond(cursor bannermodels.Cursor, qa *queryArgs) (string, error) {
+	column, err := sortColumn(cursor.Sort)
+	if err != nil {
+		return "", err
+	}
+
+	cmp := ">"
+	if cursor.Desc {
+		cmp = "<"
+	}
+
+	switch cursor.Sort {
+	case bannermodels.SortByID:
+		return fmt.Sprintf("b.id %v %v", cmp, qa.add(cursor.ID)), nil
+	case bannermodels.SortByFeatureID:
+		return fmt.Sprintf("(%v, b.id) %v (%v, %v)", column, cmp, qa.add(cursor.FeatureID), qa.add(cursor.ID)), nil
+	default:
+		return fmt.Sprintf("(%v, b.id) %v (%v, %v)", column, cmp, qa.add(cursor.Time), qa.add(cursor.ID)), nil
+	}
+}
+
+func contentFilterCond(filter bannermodels.ContentFilter, qa *queryArgs) (string, error) {
+	if len(filter.Path) == 0 {
+		return "", fmt.Errorf("empty path in content filter")
+	}
+
+	switch filter.Op {
+	case bannermodels.ContentFilterEq:
+		// @> is served by banner_content_path index, value may be stored
+		// as string or as json scalar, so check both
+		docs, err := containmentDocs(filter.Path, filter.Value)
+		if err != nil {
+			return "", err
+		}
+
+		alternatives := make([]string, len(docs))
+		for i, doc := range docs {
+			alternatives[i] = fmt.Sprintf("b.content @> %v::jsonb", qa.add(doc))
+		}
+		return "(" + strings.Join(alternatives, " OR ") + ")", nil
+
+	case bannermodels.ContentFilterContains:
+		pattern := qa.add("%" + escapeLike(filter.Value) + "%")
+		pathCond := fmt.Sprintf("b.content #>> %v::text[] ILIKE %v", qa.add(filter.Path), pattern)
+
+		// quotes, backslashes and control characters are escaped in content::text,
+		// so trigram prefilter can not be used for them
+		if strings.ContainsFunc(filter.Value, escapedInJSON) {
+			return pathCond, nil
+		}
+		return fmt.Sprintf("(b.content::text ILIKE %v AND %v)", pattern, pathCond), nil
+
+	default:
+		return "", fmt.Errorf("unknown content filter op: %v", filter.Op)
+	}
+}
+
+func escapedInJSON(r rune) bool {
+	return r == '"' || r == '\\' || r < 0x20
+}
+
+// return json documents {"path": {"to": value}} for string value
+// and for value as json scalar if it is valid one
+func containmentDocs(path []string, value string) ([][]byte, error) {
+	values := []interface{}{value}
+
+	var scalar interface{}
+	if err := json.Unmarshal([]byte(value), &scalar); err == nil {
+		switch scalar.(type) {
+		case float64, bool, nil:
+			values = append(values, json.RawMessage(value))
+		}
+	}
+
+	docs := make([][]byte, len(values))
+	for i, v := range values {
+		var doc interface{} = v
+		for j := len(path) - 1; j >= 0; j-- {
+			doc = map[string]interface{}{path[j]: doc}
+		}
+
+		docJSON, err := json.Marshal(doc)
+		if err != nil {
+			return nil, err
+		}
+		docs[i] = docJSON
+	}
+
+	return docs, nil
+}
+
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+}
@@ -1,7 +1,43 @@
 package repo
 
 const (
-	SQLDuplicateErrCode = "23505"
+	SQLDuplicateErrCode  = "23505"
+	SQLForeignKeyErrCode = "23503"
+
+	// statement names for query metrics
+	stmtNameCreateBanner        = "create_banner"
+	stmtNameCloneBanner         = "clone_banner"
+	stmtNameGetUserBanner       = "get_user_banner"
+	stmtNamePartialUpdateBanner = "partial_update_banner"
+	stmtNameBannerList          = "banner_list"
+	stmtNameBannerCount         = "banner_count"
+	stmtNameDeleteBanner        = "delete_banner"
+	stmtNameRestoreBanner       = "restore_banner"
+	stmtNamePurgeTrash          = "purge_trash"
+	stmtNameExportBanners       = "export_banners"
+	stmtNameImportBanners       = "import_banners"
+	stmtNameFeatureSlots        = "feature_slots"
+	stmtNameTransferSlots       = "transfer_slots"
+	stmtNameToggleBanners       = "toggle_banners"
+	stmtNameSaveDraft           = "save_draft"
+	stmtNameGetDraft            = "get_draft"
+	stmtNameGetDraftForSlot     = "get_draft_for_slot"
+	stmtNameDeleteDraft         = "delete_draft"
+	stmtNameApproveDraft        = "approve_draft"
+	stmtNamePublishDraft        = "publish_draft"
+	stmtNameCreateWebhook       = "create_webhook"
+	stmtNameListWebhooks        = "list_webhooks"
+	stmtNameDeleteWebhook       = "delete_webhook"
+	stmtNameWebhookDeliveries   = "webhook_deliveries"
+	stmtNameRedeliverWebhook    = "redeliver_webhook"
+	stmtNameClaimDeliveries     = "claim_webhook_deliveries"
+	stmtNameCompleteDelivery    = "complete_webhook_delivery"
+	stmtNameFailDelivery        = "fail_webhook_delivery"
+	stmtNamePurgeDeliveries     = "purge_webhook_deliveries"
+	stmtNameEnqueueDeliveries   = "enqueue_webhook_deliveries"
+	stmtNameBannerEvents        = "banner_events"
+	stmtNameConsumeEvents       = "consume_banner_events"
+	stmtNamePurgeEvents         = "purge_banner_events"
 
 	stmtCreateBanner = `
 	with create_banner AS (
@@ -20,6 +56,23 @@ const (
 	SELECT "id" FROM create_banner;
 	`
 
+	stmtCloneBanner = `
+	with create_banner AS (
+		INSERT into banner (tag_ids, feature_id, is_active, "content", cloned_from)
+		 VALUES ($1::int[], $2, $3, $4, $5)
+		 RETURNING "id", feature_id
+	),
+	create_banner_relation as (
+		INSERT into banner_relation (banner_id, feature_id, tag_id)
+		SELECT cb.id as banner_id
+		       , cb.feature_id as feature_id
+		       , UNNEST($1::int[]) as tag_id
+		  FROM create_banner AS cb
+	)
+
+	SELECT "id" FROM create_banner;
+	`
+
 	stmtGetUserBanner = `
 	with find_banner as (
 		SELECT banner_id, tag_id, feature_id FROM banner_relation WHERE feature_id=$2 AND tag_id=$1
@@ -46,7 +99,7 @@ const (
 		b.created_at,
 		b.updated_at
 	FROM banner as b
-	WHERE b.id = $1;
+	WHERE b.id = $1 AND b.deleted_at IS NULL;
 	`
 
 	stmtUpdateBannerTemplate = `
@@ -65,7 +118,7 @@ const (
 	UPDATE banner_relation SET feature_id=$2 WHERE banner_id=$1;
 	`
 
-	stmtBannerList = `
+	stmtBannerListTemplate = `
 	SELECT
 		b.id,
 		b.feature_id,
@@ -73,17 +126,21 @@ const (
 		b.content,
 		b.is_active,
 		b.created_at,
-		b.updated_at
+		b.updated_at,
+		b.cloned_from,
+		b.deleted_at,
+		b.deleted_by
 	FROM banner as b
-	ORDER BY b.created_at DESC
-	LIMIT $1 OFFSET $2
+	%v
+	ORDER BY %v
+	LIMIT $1 OFFSET $2;
 	`
 
-	stmtBannerListWithFilterTemplate = `
-	with filtered_banners as (
-	  SELECT DISTINCT banner_id FROM banner_relation WHERE %v
-	)
-	
+	stmtBannerCountTemplate = `
+	SELECT COUNT(*) FROM banner as b %v;
+	`
+
+	stmtBannerExportTemplate = `
 	SELECT
 		b.id,
 		b.feature_id,
@@ -92,12 +149,273 @@ const (
 		b.is_active,
 		b.created_at,
 		b.updated_at
-	FROM banner as b JOIN filtered_banners as fb ON (b.id = fb.banner_id)
-	ORDER BY b.created_at DESC
-	LIMIT $1 OFFSET $2;
+	FROM banner as b
+	%v
+	ORDER BY %v;
+	`
+
+	stmtSlotHolders = `
+	SELECT COALESCE(array_agg(DISTINCT banner_id ORDER BY banner_id), '{}')
+	FROM banner_relation
+	WHERE feature_id=$1 AND tag_id = ANY($2::int[]);
+	`
+
+	stmtSlotConflicts = `
+	SELECT tag_id, feature_id, banner_id
+	FROM banner_relation
+	WHERE feature_id=$1 AND tag_id = ANY($2::int[]) AND banner_id <> $3
+	ORDER BY tag_id;
+	`
+
+	stmtFeatureSlots = `
+	SELECT tag_id, feature_id, banner_id
+	FROM banner_relation
+	WHERE feature_id=$1
+	ORDER BY tag_id;
+	`
+
+	stmtLockBannersForTransfer = `
+	SELECT "id", feature_id, tag_ids
+	FROM banner
+	WHERE "id" = ANY($1::int[]) AND deleted_at IS NULL
+	ORDER BY "id"
+	FOR UPDATE;
+	`
+
+	// slots held by $1 go to $2 and vice versa, tags are not changed,
+	// so UNIQUE(feature_id, tag_id) holds on every row
+	stmtTransferSlots = `
+	UPDATE banner_relation
+	SET banner_id = CASE WHEN banner_id=$1 THEN $2 ELSE $1 END
+	WHERE feature_id=$3 AND tag_id = ANY($4::int[]) AND banner_id IN ($1, $2)
+	RETURNING tag_id, feature_id, banner_id;
+	`
+
+	stmtToggleTargetsTemplate = `
+	SELECT b.id, b.feature_id, b.tag_ids, b.updated_at FROM banner as b WHERE %v;
+	`
+
+	// old is snapshot of row before update, its updated_at goes to webhooks
+	stmtToggleBannersTemplate = `
+	UPDATE banner as b SET is_active=$1, updated_at=NOW()
+	FROM banner as old
+	WHERE old.id = b.id AND %v
+	RETURNING b.id, b.feature_id, b.tag_ids, old.updated_at;
+	`
+
+	stmtSetBannerTagIDs = `
+	UPDATE banner SET tag_ids=$2::int[], updated_at=NOW() WHERE "id"=$1;
+	`
+
+	draftColumns = `banner_id, tag_ids, feature_id, content, is_active, author, approved_by, approved_at, created_at, updated_at`
+
+	// banners in trash can not have draft
+	stmtSaveDraft = `
+	INSERT INTO banner_draft (banner_id, tag_ids, feature_id, is_active, content, author)
+	SELECT "id", $2::int[], $3::int, $4::bool, $5::jsonb, $6::text
+	FROM banner
+	WHERE "id"=$1 AND deleted_at IS NULL
+	ON CONFLICT (banner_id) DO UPDATE
+	SET tag_ids=EXCLUDED.tag_ids,
+	    feature_id=EXCLUDED.feature_id,
+	    is_active=EXCLUDED.is_active,
+	    content=EXCLUDED.content,
+	    author=EXCLUDED.author,
+	    approved_by=NULL,
+	    approved_at=NULL,
+	    updated_at=NOW()
+	RETURNING ` + draftColumns + `;
+	`
+
+	stmtGetDraft = `
+	SELECT ` + draftColumns + ` FROM banner_draft WHERE banner_id=$1;
+	`
+
+	stmtLockDraft = `
+	SELECT ` + draftColumns + ` FROM banner_draft WHERE banner_id=$1 FOR UPDATE;
+	`
+
+	// @> is served by banner_draft_feature_tags index
+	stmtGetDraftForSlot = `
+	SELECT ` + draftColumns + `
+	FROM banner_draft
+	WHERE feature_id=$2 AND tag_ids @> ARRAY[$1::int]
+	ORDER BY updated_at DESC
+	LIMIT 1;
+	`
+
+	stmtDeleteDraft = `
+	DELETE FROM banner_draft WHERE banner_id=$1 RETURNING banner_id;
+	`
+
+	stmtApproveDraft = `
+	UPDATE banner_draft SET approved_by=$2, approved_at=NOW()
+	WHERE banner_id=$1
+	RETURNING ` + draftColumns + `;
+	`
+
+	stmtPublishBanner = `
+	UPDATE banner
+	SET tag_ids=$2::int[], feature_id=$3, is_active=$4, "content"=$5,
+	    approved_by=$6, published_by=$7, published_at=NOW(), updated_at=NOW()
+	WHERE "id"=$1
+	RETURNING published_at;
+	`
+
+	stmtReplaceBanner = `
+	UPDATE banner
+	SET tag_ids=$2::int[], feature_id=$3, is_active=$4, "content"=$5, updated_at=NOW()
+	WHERE "id"=$1;
+	`
+
+	stmtDeleteBannerRelations = `
+	DELETE from banner_relation WHERE banner_id=$1;
+	`
+
+	// tag_ids are kept, so banner can be restored to the same slots
+	stmtTrashBanner = `
+	UPDATE banner SET deleted_at=NOW(), deleted_by=$2
+	WHERE "id"=$1 AND deleted_at IS NULL
+	RETURNING feature_id, tag_ids;
+	`
+
+	stmtLockTrashedBanner = `
+	SELECT feature_id, tag_ids FROM banner WHERE "id"=$1 AND deleted_at IS NOT NULL FOR UPDATE;
+	`
+
+	stmtRestoreBanner = `
+	UPDATE banner SET deleted_at=NULL, deleted_by=NULL, updated_at=NOW() WHERE "id"=$1;
+	`
+
+	// drafts of purged banners are deleted by cascade
+	stmtPurgeTrash = `
+	DELETE from banner WHERE deleted_at < NOW() - $1::interval;
+	`
+
+	// Appending transactions are serialized from first append until commit,
+	// so ids of events grow in commit order and readers never skip an event.
+	stmtLockEventLog = `
+	SELECT pg_advisory_xact_lock($1);
+	`
+
+	stmtAppendEvent = `
+	INSERT INTO banner_events (type, banner_id, payload) VALUES ($1, $2, $3);
+	`
+
+	stmtEventsAfter = `
+	SELECT id, type, banner_id, payload, occurred_at
+	FROM banner_events
+	WHERE id > $1
+	ORDER BY id
+	LIMIT $2;
+	`
+
+	stmtBannerEventsAfter = `
+	SELECT id, type, banner_id, payload, occurred_at
+	FROM banner_events
+	WHERE banner_id = $3 AND id > $1
+	ORDER BY id
+	LIMIT $2;
+	`
+
+	stmtCreateEventCursor = `
+	INSERT INTO banner_event_cursor (sink) VALUES ($1) ON CONFLICT DO NOTHING;
+	`
+
+	// cursor locked by other replica is skipped, sink is handled by one replica at a time
+	stmtLockEventCursor = `
+	SELECT last_event_id FROM banner_event_cursor WHERE sink=$1 FOR UPDATE SKIP LOCKED;
+	`
+
+	stmtAdvanceEventCursor = `
+	UPDATE banner_event_cursor SET last_event_id=$2, updated_at=NOW() WHERE sink=$1;
+	`
+
+	stmtPurgeEvents = `
+	DELETE FROM banner_events WHERE occurred_at < NOW() - $1::interval;
+	`
+
+	// deliveries of event to every matching webhook, event already enqueued for webhook is skipped
+	stmtEnqueueWebhookDeliveries = `
+	INSERT INTO webhook_delivery (webhook_id, event_id, event, payload)
+	SELECT w.id, $1, $2::text, $3::jsonb
+	FROM webhook as w
+	WHERE (cardinality(w.events) = 0 OR $2::text = ANY(w.events))
+	  AND (w.feature_id IS NULL OR w.feature_id = ANY($4::int[]))
+	  AND (w.tag_id IS NULL OR w.tag_id = ANY($5::int[]))
+	ON CONFLICT (webhook_id, event_id) DO NOTHING;
+	`
+
+	stmtCreateWebhook = `
+	INSERT INTO webhook (url, secret, feature_id, tag_id, events, created_by)
+	VALUES ($1, $2, $3, $4, $5::text[], $6)
+	RETURNING id, created_at;
+	`
+
+	// secrets are not listed
+	stmtListWebhooks = `
+	SELECT id, url, feature_id, tag_id, events, created_by, created_at
+	FROM webhook
+	ORDER BY id;
+	`
+
+	stmtWebhookExists = `
+	SELECT EXISTS (SELECT 1 FROM webhook WHERE id=$1);
+	`
+
+	stmtDeleteWebhook = `
+	DELETE FROM webhook WHERE id=$1;
+	`
+
+	deliveryColumns = `id, webhook_id, event_id, event, payload, status, attempts, next_attempt_at, last_error, last_status_code, created_at, delivered_at`
+
+	// zero webhook id and empty status match any, newest first
+	stmtWebhookDeliveries = `
+	SELECT ` + deliveryColumns + `
+	FROM webhook_delivery
+	WHERE ($1 = 0 OR webhook_id = $1) AND ($2 = '' OR status = $2)
+	ORDER BY id DESC
+	LIMIT $3;
+	`
+
+	stmtRedeliverWebhook = `
+	UPDATE webhook_delivery
+	SET status='pending', attempts=0, next_attempt_at=NOW(), delivered_at=NULL
+	WHERE id=$1 AND webhook_id=$2
+	RETURNING ` + deliveryColumns + `;
+	`
+
+	// Claimed deliveries are postponed by lease, so other replicas do not take them.
+	// If dispatcher dies, they are taken again after lease.
+	stmtClaimDeliveries = `
+	WITH due AS (
+		SELECT id FROM webhook_delivery
+		WHERE status='pending' AND next_attempt_at <= NOW()
+		ORDER BY next_attempt_at
+		LIMIT $1
+		FOR UPDATE SKIP LOCKED
+	)
+	UPDATE webhook_delivery as d
+	SET next_attempt_at = NOW() + $2::interval
+	FROM due, webhook as w
+	WHERE d.id = due.id AND w.id = d.webhook_id
+	RETURNING d.id, d.webhook_id, d.event, d.payload, d.attempts, w.url, w.secret;
+	`
+
+	stmtCompleteDelivery = `
+	UPDATE webhook_delivery
+	SET status='delivered', attempts=attempts+1, last_status_code=$2, last_error=NULL, delivered_at=NOW()
+	WHERE id=$1;
+	`
+
+	stmtFailDelivery = `
+	UPDATE webhook_delivery
+	SET status=$2, attempts=attempts+1, last_status_code=NULLIF($3, 0), last_error=$4,
+	    next_attempt_at=NOW() + $5::interval
+	WHERE id=$1;
 	`
 
-	stmtDeleteBanner = `
-	DELETE from banner WHERE "id" = $1;
+	stmtPurgeDeliveries = `
+	DELETE FROM webhook_delivery WHERE status='delivered' AND delivered_at < NOW() - $1::interval;
 	`
 )
@@ -0,0 +1,208 @@
+package repo
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/jackc/pgx/v4"
+
+	bannermodels "banner/internal/models/banner"
+	webhookmodels "banner/internal/models/webhook"
+	"banner/internal/service"
+)
+
+func (repo *BannerRepo) CreateWebhook(ctx context.Context, hook webhookmodels.Webhook) (_ webhookmodels.Webhook, err error) {
+	ctx, done := repo.observe(ctx, stmtNameCreateWebhook)
+	defer func() { done(err) }()
+
+	err = repo.db.QueryRow(
+		ctx,
+		stmtCreateWebhook,
+		hook.URL,
+		hook.Secret,
+		hook.FeatureID,
+		hook.TagID,
+		hook.Events,
+		hook.CreatedBy,
+	).Scan(&hook.ID, &hook.CreatedAt)
+	if err != nil {
+		return webhookmodels.Webhook{}, err
+	}
+
+	return hook, nil
+}
+
+// all webhooks without secrets
+func (repo *BannerRepo) ListWebhooks(ctx context.Context) (_ []webhookmodels.Webhook, err error) {
+	ctx, done := repo.observe(ctx, stmtNameListWebhooks)
+	defer func() { done(err) }()
+
+	hooks := []webhookmodels.Webhook{}
+	err = repo.db.Select(ctx, &hooks, stmtListWebhooks)
+	if err != nil {
+		return nil, err
+	}
+
+	return hooks, nil
+}
+
+// pending deliveries of webhook are deleted with it
+func (repo *BannerRepo) DeleteWebhook(ctx context.Context, id int) (err error) {
+	ctx, done := repo.observe(ctx, stmtNameDeleteWebhook)
+	defer func() { done(err) }()
+
+	ct, err := repo.db.Exec(ctx, stmtDeleteWebhook, id)
+	if err != nil {
+		return err
+	}
+	if ct.RowsAffected() == 0 {
+		return service.ErrDBWebhookNotFound
+	}
+
+	return nil
+}
+
+// newest deliveries matching filter, webhook of filter must exist
+func (repo *BannerRepo) WebhookDeliveries(ctx context.Context, filter webhookmodels.DeliveryFilter) (_ []webhookmodels.Delivery, err error) {
+	ctx, done := repo.observe(ctx, stmtNameWebhookDeliveries)
+	defer func() { done(err) }()
+
+	if filter.WebhookID != 0 {
+		var exists bool
+		err := repo.db.QueryRow(ctx, stmtWebhookExists, filter.WebhookID).Scan(&exists)
+		if err != nil {
+			return nil, err
+		}
+		if !exists {
+			return nil, service.ErrDBWebhookNotFound
+		}
+	}
+
+	deliveries := []webhookmodels.Delivery{}
+	err = repo.db.Select(ctx, &deliveries, stmtWebhookDeliveries, filter.WebhookID, string(filter.Status), filter.Limit)
+	if err != nil {
+		return nil, err
+	}
+
+	return deliveries, nil
+}
+
+// send delivery again with all attempts, whatever its status is
+func (repo *BannerRepo) RedeliverWebhook(ctx context.Context, webhookID int, deliveryID int64) (_ webhookmodels.Delivery, err error) {
+	ctx, done := repo.observe(ctx, stmtNameRedeliverWebhook)
+	defer func() { done(err) }()
+
+	var deliveries []webhookmodels.Delivery
+	err = repo.db.Select(ctx, &deliveries, stmtRedeliverWebhook, deliveryID, webhookID)
+	if err != nil {
+		return webhookmodels.Delivery{}, err
+	}
+	if len(deliveries) == 0 {
+		return webhookmodels.Delivery{}, service.ErrDBDeliveryNotFound
+	}
+
+	return deliveries[0], nil
+}
+
+// take up to limit due deliveries, they are not taken again until lease is over
+func (repo *BannerRepo) ClaimWebhookDeliveries(ctx context.Context, limit int, lease time.Duration) (_ []webhookmodels.ClaimedDelivery, err error) {
+	ctx, done := repo.observe(ctx, stmtNameClaimDeliveries)
+	defer func() { done(err) }()
+
+	rows, err := repo.db.Query(ctx, stmtClaimDeliveries, limit, lease)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var deliveries []webhookmodels.ClaimedDelivery
+	for rows.Next() {
+		var d webhookmodels.ClaimedDelivery
+		err = rows.Scan(&d.ID, &d.WebhookID, &d.Event, &d.Payload, &d.Attempts, &d.URL, &d.Secret)
+		if err != nil {
+			return nil, err
+		}
+		deliveries = append(deliveries, d)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return deliveries, nil
+}
+
+func (repo *BannerRepo) CompleteWebhookDelivery(ctx context.Context, attempt webhookmodels.Attempt) (err error) {
+	ctx, done := repo.observe(ctx, stmtNameCompleteDelivery)
+	defer func() { done(err) }()
+
+	_, err = repo.db.Exec(ctx, stmtCompleteDelivery, attempt.DeliveryID, attempt.StatusCode)
+	return err
+}
+
+// Save failed attempt, delivery is tried again after retryIn.
+// Dead delivery is not tried again until redelivery.
+func (repo *BannerRepo) FailWebhookDelivery(ctx context.Context, attempt webhookmodels.Attempt, retryIn time.Duration, dead bool) (err error) {
+	ctx, done := repo.observe(ctx, stmtNameFailDelivery)
+	defer func() { done(err) }()
+
+	status := webhookmodels.StatusPending
+	if dead {
+		status = webhookmodels.StatusDead
+	}
+
+	_, err = repo.db.Exec(
+		ctx,
+		stmtFailDelivery,
+		attempt.DeliveryID,
+		string(status),
+		attempt.StatusCode,
+		attempt.Err.Error(),
+		retryIn,
+	)
+	return err
+}
+
+// delete deliveries delivered longer than retention ago, returns count of deleted
+func (repo *BannerRepo) PurgeWebhookDeliveries(ctx context.Context, retention time.Duration) (_ int, err error) {
+	ctx, done := repo.observe(ctx, stmtNamePurgeDeliveries)
+	defer func() { done(err) }()
+
+	ct, err := repo.db.Exec(ctx, stmtPurgeDeliveries, retention)
+	if err != nil {
+		return 0, err
+	}
+
+	return int(ct.RowsAffected()), nil
+}
+
+// Write deliveries of events to matching webhooks, payload is the event.
+// Events enqueued before are skipped, so events can be enqueued again safely.
+func (repo *BannerRepo) EnqueueWebhookDeliveries(ctx context.Context, events []bannermodels.BannerChange) (err error) {
+	ctx, done := repo.observe(ctx, stmtNameEnqueueDeliveries)
+	defer func() { done(err) }()
+
+	batch := &pgx.Batch{}
+	for _, event := range events {
+		payload, err := json.Marshal(event)
+		if err != nil {
+			return err
+		}
+
+		featureIDs, tagIDs := event.Slots()
+		batch.Queue(stmtEnqueueWebhookDeliveries, event.ID, string(event.Type), payload, featureIDs, tagIDs)
+	}
+
+	tx, err := repo.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	err = tx.SendBatch(ctx, batch).Close()
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
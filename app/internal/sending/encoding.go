@@ -0,0 +1,94 @@
+package sending
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/vmihailenco/msgpack/v5"
+)
+
+const (
+	headerAccept       = "Accept"
+	ContentTypeMsgpack = "application/msgpack"
+)
+
+// Msgpack if client prefers it over JSON in Accept, JSON otherwise.
+// application/x-msgpack is accepted too, it is still used by some clients.
+func wantsMsgpack(r *http.Request) bool {
+	accept := r.Header.Get(headerAccept)
+	if accept == "" {
+		return false
+	}
+
+	msgpackQ, jsonQ := 0.0, 0.0
+	for _, item := range strings.Split(accept, ",") {
+		mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(item))
+		if err != nil {
+			continue
+		}
+
+		q := 1.0
+		if value, ok := params["q"]; ok {
+			q, err = strconv.ParseFloat(value, 64)
+			if err != nil {
+				continue
+			}
+		}
+
+		switch mediaType {
+		case ContentTypeMsgpack, "application/x-msgpack":
+			msgpackQ = max(msgpackQ, q)
+		case contentTypeJSON, "application/*", "*/*":
+			jsonQ = max(jsonQ, q)
+		}
+	}
+	return msgpackQ > 0 && msgpackQ > jsonQ
+}
+
+// msgpack uses json tags with their omitempty, so both formats have the same fields
+func marshalMsgpack(obj any) ([]byte, error) {
+	var buf bytes.Buffer
+	encoder := msgpack.NewEncoder(&buf)
+	encoder.SetCustomStructTag("json")
+
+	if err := encoder.Encode(obj); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// encode obj in format negotiated by Accept header, content type of body is returned
+func Marshal(r *http.Request, obj any) ([]byte, string, error) {
+	if wantsMsgpack(r) {
+		body, err := marshalMsgpack(obj)
+		return body, ContentTypeMsgpack, err
+	}
+	body, err := json.Marshal(obj)
+	return body, contentTypeJSON, err
+}
+
+// Write bytes to w with content type, Vary tells caches that format depends on Accept
+func SendBytes(w http.ResponseWriter, status int, contentType string, body []byte) {
+	w.Header().Add(headerVary, headerAccept)
+	w.Header().Set(contentTypeHeader, contentType)
+	w.WriteHeader(status)
+
+	// status is already sent, client has gone if write failed
+	_, _ = w.Write(body)
+}
+
+// like JSONMarshallAndSend, but msgpack is sent if client asks for it
+func MarshalAndSend(w http.ResponseWriter, r *http.Request, status int, obj any) {
+	body, contentType, err := Marshal(r, obj)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "marshal response", "err", err)
+		SendError(w, r, nil)
+		return
+	}
+	SendBytes(w, status, contentType, body)
+}
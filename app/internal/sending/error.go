@@ -0,0 +1,59 @@
+package sending
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"banner/internal/apierror"
+	"banner/internal/logging"
+	bannermodels "banner/internal/models/banner"
+)
+
+type errorEnvelope struct {
+	Error errorBody `json:"error"`
+}
+
+type errorBody struct {
+	Code      apierror.Code       `json:"code"`
+	Message   string              `json:"message"`
+	Details   []errorDetail       `json:"details,omitempty"`
+	Conflicts []bannermodels.Slot `json:"conflicts,omitempty"`
+	RequestID string              `json:"request_id,omitempty"`
+}
+
+type errorDetail struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// Send error envelope with messages in language of Accept-Language,
+// nil apiErr is sent as internal error.
+func SendError(w http.ResponseWriter, r *http.Request, apiErr *apierror.Error) {
+	if apiErr == nil {
+		apiErr = apierror.Internal()
+	}
+
+	lang := apierror.LangFromRequest(r)
+
+	body := errorBody{
+		Code:      apiErr.Code,
+		Message:   apierror.Localize(lang, apiErr.Message),
+		Conflicts: apiErr.Conflicts,
+		RequestID: logging.RequestID(r.Context()),
+	}
+	for _, detail := range apiErr.Details {
+		body.Details = append(body.Details, errorDetail{
+			Field:   detail.Field,
+			Message: apierror.Localize(lang, detail.Message, detail.Args...),
+		})
+	}
+
+	bodyJSON, err := json.Marshal(errorEnvelope{Error: body})
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Language", string(lang))
+	SendJSONBytes(w, apiErr.Status, bodyJSON)
+}
@@ -0,0 +1,55 @@
+package sending
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+const (
+	contentTypeNDJSON = "application/x-ndjson"
+
+	// flush to client every ndjsonFlushEvery objects
+	ndjsonFlushEvery = 100
+)
+
+// Streams objects as newline delimited JSON, one object per line.
+// Status and headers are sent before the first object,
+// so errors after that can only interrupt the stream.
+type NDJSONWriter struct {
+	w       http.ResponseWriter
+	enc     *json.Encoder
+	flusher http.Flusher
+	count   int
+}
+
+func NewNDJSONWriter(w http.ResponseWriter, status int) *NDJSONWriter {
+	w.Header().Set(contentTypeHeader, contentTypeNDJSON)
+	w.WriteHeader(status)
+
+	flusher, _ := w.(http.Flusher)
+
+	return &NDJSONWriter{
+		w:       w,
+		enc:     json.NewEncoder(w),
+		flusher: flusher,
+	}
+}
+
+func (nw *NDJSONWriter) Write(obj any) error {
+	err := nw.enc.Encode(obj)
+	if err != nil {
+		return err
+	}
+
+	nw.count++
+	if nw.count%ndjsonFlushEvery == 0 {
+		nw.Flush()
+	}
+	return nil
+}
+
+func (nw *NDJSONWriter) Flush() {
+	if nw.flusher != nil {
+		nw.flusher.Flush()
+	}
+}
@@ -0,0 +1,71 @@
+package sending
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+)
+
+const contentTypeEventStream = "text/event-stream"
+
+// Streams server-sent events, every event and comment is flushed at once.
+// Status and headers are sent on creation.
+type SSEWriter struct {
+	w       http.ResponseWriter
+	flusher http.Flusher
+}
+
+func NewSSEWriter(w http.ResponseWriter) *SSEWriter {
+	header := w.Header()
+	header.Set(contentTypeHeader, contentTypeEventStream)
+	header.Set("Cache-Control", "no-cache")
+	// nginx buffers proxied responses by default
+	header.Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+
+	flusher, _ := w.(http.Flusher)
+
+	sw := &SSEWriter{w: w, flusher: flusher}
+	sw.flush()
+	return sw
+}
+
+// event with JSON data of obj, empty id does not change last event id of client
+func (sw *SSEWriter) Event(id string, event string, obj any) error {
+	data, err := json.Marshal(obj)
+	if err != nil {
+		return err
+	}
+
+	if id != "" {
+		_, err = fmt.Fprintf(sw.w, "id: %s\n", id)
+		if err != nil {
+			return err
+		}
+	}
+
+	_, err = fmt.Fprintf(sw.w, "event: %s\ndata: %s\n\n", event, data)
+	if err != nil {
+		return err
+	}
+
+	sw.flush()
+	return nil
+}
+
+// comment is ignored by clients, it keeps idle connection open
+func (sw *SSEWriter) Comment(text string) error {
+	_, err := fmt.Fprintf(sw.w, ": %s\n\n", text)
+	if err != nil {
+		return err
+	}
+
+	sw.flush()
+	return nil
+}
+
+func (sw *SSEWriter) flush() {
+	if sw.flusher != nil {
+		sw.flusher.Flush()
+	}
+}
@@ -3,33 +3,61 @@ package service
 import (
 	bannermodels "banner/internal/models/banner"
 	usermodels "banner/internal/models/user"
+	webhookmodels "banner/internal/models/webhook"
 	"context"
-	"encoding/json"
 	"errors"
+	"log/slog"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
 )
 
 type bannerRepo interface {
 	GetUserBanner(ctx context.Context, tagID int, featureID int) (bannermodels.Banner, error)
 	GetFiltered(ctx context.Context, filter bannermodels.FilterSchema) ([]bannermodels.Banner, error)
+	CountFiltered(ctx context.Context, filter bannermodels.FilterSchema) (int, error)
+	ExportBanners(ctx context.Context, filter bannermodels.FilterSchema, fn func(bannermodels.Banner) error) error
+	ImportBanners(ctx context.Context, reader bannermodels.BannerReader, policy bannermodels.ImportPolicy, dryRun bool) (bannermodels.ImportReport, error)
 	CreateBanner(ctx context.Context, banner bannermodels.Banner) (int, error)
-	PartialUpdateBanner(ctx context.Context, id int, bannerPartial bannermodels.BannerPartialUpdate) error
-	DeleteBanner(ctx context.Context, id int) error
+	CloneBanner(ctx context.Context, sourceID int, clone bannermodels.BannerClone) (int, []bannermodels.Slot, error)
+	PartialUpdateBanner(ctx context.Context, id int, bannerPartial bannermodels.BannerPartialUpdate) ([]bannermodels.Slot, error)
+	DeleteBanner(ctx context.Context, id int, deletedBy string) ([]bannermodels.Slot, error)
+	RestoreBanner(ctx context.Context, id int) ([]bannermodels.Slot, error)
+	PurgeTrash(ctx context.Context, retention time.Duration) (int, error)
+	FeatureSlots(ctx context.Context, featureID int) ([]bannermodels.Slot, error)
+	TransferSlots(ctx context.Context, transfer bannermodels.SlotTransfer) (bannermodels.TransferResult, error)
+	ToggleBanners(ctx context.Context, toggle bannermodels.BannerToggle, dryRun bool) ([]int, []bannermodels.Slot, error)
+	SaveDraft(ctx context.Context, draft bannermodels.Draft) (bannermodels.Draft, error)
+	GetDraft(ctx context.Context, bannerID int) (bannermodels.Draft, error)
+	GetDraftForSlot(ctx context.Context, tagID int, featureID int) (bannermodels.Draft, error)
+	DeleteDraft(ctx context.Context, bannerID int) error
+	ApproveDraft(ctx context.Context, bannerID int, approver string) (bannermodels.Draft, error)
+	PublishDraft(ctx context.Context, bannerID int, publisher string) (bannermodels.PublishResult, bannermodels.Banner, error)
+	CreateWebhook(ctx context.Context, hook webhookmodels.Webhook) (webhookmodels.Webhook, error)
+	ListWebhooks(ctx context.Context) ([]webhookmodels.Webhook, error)
+	DeleteWebhook(ctx context.Context, id int) error
+	WebhookDeliveries(ctx context.Context, filter webhookmodels.DeliveryFilter) ([]webhookmodels.Delivery, error)
+	RedeliverWebhook(ctx context.Context, webhookID int, deliveryID int64) (webhookmodels.Delivery, error)
+	BannerEvents(ctx context.Context, after int64, limit int, bannerID int) ([]bannermodels.BannerChange, error)
 }
 
 type bannerCache interface {
 	GetBanner(ctx context.Context, tagID int, featureID int) (bannermodels.Banner, error)
 	SetBanner(ctx context.Context, tagID int, featureID int, banner bannermodels.Banner) error
+	DeleteBanner(ctx context.Context, tagID int, featureID int) error
 }
 
 type BannerService struct {
-	repo  bannerRepo
-	cache bannerCache
+	repo     bannerRepo
+	cache    bannerCache
+	watchers *slotWatchers
 }
 
 func NewBannerService(bannerRepo bannerRepo, bannerCache bannerCache) *BannerService {
 	return &BannerService{
-		repo:  bannerRepo,
-		cache: bannerCache,
+		repo:     bannerRepo,
+		cache:    bannerCache,
+		watchers: newSlotWatchers(),
 	}
 }
 
@@ -64,7 +92,16 @@ func (s *BannerService) getOrSetUserBannerFromCache(ctx context.Context, tagID i
 	return b, nil
 }
 
-func (s *BannerService) GetUserBanner(ctx context.Context, user usermodels.User, tagID int, featureID int, useLastRevision bool) ([]byte, error) {
+func (s *BannerService) GetUserBanner(ctx context.Context, user usermodels.User, tagID int, featureID int, useLastRevision bool) (bannermodels.UserBanner, error) {
+	ctx, span := startSpan(
+		ctx,
+		"GetUserBanner",
+		attribute.Int("banner.tag_id", tagID),
+		attribute.Int("banner.feature_id", featureID),
+		attribute.Bool("banner.use_last_revision", useLastRevision),
+	)
+	defer span.End()
+
 	var b bannermodels.Banner
 	var err error
 
@@ -73,31 +110,29 @@ func (s *BannerService) GetUserBanner(ctx context.Context, user usermodels.User,
 
 		switch {
 		case errors.Is(err, ErrDBBannerNotFound):
-			return nil, ErrBannerNotFound
+			return bannermodels.UserBanner{}, ErrBannerNotFound
 		case err != nil:
-			return nil, err
+			return bannermodels.UserBanner{}, err
 		}
 	} else {
 		b, err = s.getOrSetUserBannerFromCache(ctx, tagID, featureID)
 
 		if err != nil {
-			return nil, err
+			return bannermodels.UserBanner{}, err
 		}
 	}
 
 	if !b.IsActive && !user.IsAdmin {
-		return nil, ErrBannerNotFound
-	}
-
-	contentJSON, err := json.Marshal(b.Content)
-	if err != nil {
-		return nil, err // TODO
+		return bannermodels.UserBanner{}, ErrBannerNotFound
 	}
 
-	return contentJSON, nil
+	return bannermodels.UserBanner{Content: b.Content, IsActive: b.IsActive}, nil
 }
 
 func (s *BannerService) BannerList(ctx context.Context, filter bannermodels.FilterSchema) ([]bannermodels.Banner, error) {
+	ctx, span := startSpan(ctx, "BannerList")
+	defer span.End()
+
 	banners, err := s.repo.GetFiltered(ctx, filter)
 	if err != nil {
 		return nil, err
@@ -105,34 +140,154 @@ func (s *BannerService) BannerList(ctx context.Context, filter bannermodels.Filt
 	return banners, nil
 }
 
+// page of banners after filter.Cursor, withTotal adds count of all banners matching filter
+func (s *BannerService) BannerListPage(ctx context.Context, filter bannermodels.FilterSchema, withTotal bool) (bannermodels.BannerPage, error) {
+	ctx, span := startSpan(ctx, "BannerListPage")
+	defer span.End()
+
+	limit := filter.Limit
+	filter.Offset = 0
+
+	// one more banner to know if there is next page
+	filter.Limit = limit + 1
+	banners, err := s.repo.GetFiltered(ctx, filter)
+	if err != nil {
+		return bannermodels.BannerPage{}, err
+	}
+
+	page := bannermodels.BannerPage{Items: banners}
+
+	if len(banners) > limit {
+		page.Items = banners[:limit]
+
+		if limit > 0 {
+			last := page.Items[limit-1]
+			nextCursor, err := bannermodels.CursorAfter(last, filter.Sort, filter.Desc).Encode()
+			if err != nil {
+				return bannermodels.BannerPage{}, err
+			}
+			page.NextCursor = &nextCursor
+		}
+	}
+
+	if withTotal {
+		total, err := s.repo.CountFiltered(ctx, filter)
+		if err != nil {
+			return bannermodels.BannerPage{}, err
+		}
+		page.Total = &total
+	}
+
+	return page, nil
+}
+
+func (s *BannerService) ExportBanners(ctx context.Context, filter bannermodels.FilterSchema, fn func(bannermodels.Banner) error) error {
+	ctx, span := startSpan(ctx, "ExportBanners")
+	defer span.End()
+
+	return s.repo.ExportBanners(ctx, filter, fn)
+}
+
+func (s *BannerService) ImportBanners(
+	ctx context.Context,
+	reader bannermodels.BannerReader,
+	policy bannermodels.ImportPolicy,
+	dryRun bool,
+) (bannermodels.ImportReport, error) {
+	ctx, span := startSpan(ctx, "ImportBanners")
+	defer span.End()
+
+	report, err := s.repo.ImportBanners(ctx, reader, policy, dryRun)
+
+	switch {
+	case errors.Is(err, ErrDBBannerAlreadyExists):
+		return bannermodels.ImportReport{}, ErrBannerAlreadyExists
+	case err != nil:
+		return bannermodels.ImportReport{}, err
+	}
+
+	if report.Applied {
+		for _, result := range report.Results {
+			s.invalidateBannerSlots(ctx, result.Slots)
+		}
+	}
+
+	return report, nil
+}
+
 func (s *BannerService) CreateBanner(ctx context.Context, banner bannermodels.Banner) (int, error) {
+	ctx, span := startSpan(ctx, "CreateBanner")
+	defer span.End()
+
 	id, err := s.repo.CreateBanner(ctx, banner)
 
+	var conflictErr *SlotConflictError
+	switch {
+	case errors.As(err, &conflictErr):
+		return 0, conflictErr
+	case errors.Is(err, ErrDBBannerAlreadyExists):
+		return 0, ErrBannerAlreadyExists
+	case err != nil:
+		return 0, err
+	}
+
+	s.invalidateSlots(ctx, banner.FeatureID, banner.TagIDs)
+
+	return id, nil
+}
+
+// copy content of banner to new one with overridden slots and state
+func (s *BannerService) CloneBanner(ctx context.Context, sourceID int, clone bannermodels.BannerClone) (int, error) {
+	ctx, span := startSpan(ctx, "CloneBanner", attribute.Int("banner.id", sourceID))
+	defer span.End()
+
+	id, slots, err := s.repo.CloneBanner(ctx, sourceID, clone)
+
+	var conflictErr *SlotConflictError
 	switch {
+	case errors.As(err, &conflictErr):
+		return 0, conflictErr
+	case errors.Is(err, ErrDBBannerNotFound):
+		return 0, ErrBannerNotFound
 	case errors.Is(err, ErrDBBannerAlreadyExists):
 		return 0, ErrBannerAlreadyExists
 	case err != nil:
 		return 0, err
 	}
 
+	s.invalidateBannerSlots(ctx, slots)
+
 	return id, nil
 }
 
+// update fields of banner, cached banners of its old and new slots are dropped
 func (s *BannerService) PartialUpdateBanner(ctx context.Context, id int, bannerPartial bannermodels.BannerPartialUpdate) error {
-	err := s.repo.PartialUpdateBanner(ctx, id, bannerPartial)
+	ctx, span := startSpan(ctx, "PartialUpdateBanner")
+	defer span.End()
+
+	slots, err := s.repo.PartialUpdateBanner(ctx, id, bannerPartial)
 
+	var conflictErr *SlotConflictError
 	switch {
+	case errors.As(err, &conflictErr):
+		return conflictErr
 	case errors.Is(err, ErrDBBannerAlreadyExists):
 		return ErrBannerAlreadyExists
 	case err != nil:
 		return err
 	}
 
+	s.invalidateBannerSlots(ctx, slots)
+
 	return nil
 }
 
-func (s *BannerService) DeleteBanner(ctx context.Context, id int) error {
-	err := s.repo.DeleteBanner(ctx, id)
+// move banner to trash, cached banners of its slots are dropped
+func (s *BannerService) DeleteBanner(ctx context.Context, id int, deletedBy string) error {
+	ctx, span := startSpan(ctx, "DeleteBanner", attribute.Int("banner.id", id))
+	defer span.End()
+
+	slots, err := s.repo.DeleteBanner(ctx, id, deletedBy)
 
 	switch {
 	case errors.Is(err, ErrDBBannerNotFound):
@@ -141,5 +296,98 @@ func (s *BannerService) DeleteBanner(ctx context.Context, id int) error {
 		return err
 	}
 
+	s.invalidateBannerSlots(ctx, slots)
+
 	return nil
 }
+
+// tag to banner occupancy of feature
+func (s *BannerService) FeatureSlots(ctx context.Context, featureID int) ([]bannermodels.Slot, error) {
+	ctx, span := startSpan(ctx, "FeatureSlots", attribute.Int("banner.feature_id", featureID))
+	defer span.End()
+
+	return s.repo.FeatureSlots(ctx, featureID)
+}
+
+// move or swap slots between banners, cached banners of transferred slots are dropped
+func (s *BannerService) TransferSlots(ctx context.Context, transfer bannermodels.SlotTransfer) (bannermodels.TransferResult, error) {
+	ctx, span := startSpan(
+		ctx,
+		"TransferSlots",
+		attribute.Int("banner.from_id", transfer.FromBannerID),
+		attribute.Int("banner.to_id", transfer.ToBannerID),
+		attribute.String("banner.transfer_mode", string(transfer.Mode)),
+	)
+	defer span.End()
+
+	result, err := s.repo.TransferSlots(ctx, transfer)
+
+	switch {
+	case errors.Is(err, ErrDBBannerNotFound):
+		return bannermodels.TransferResult{}, ErrBannerNotFound
+	case err != nil:
+		return bannermodels.TransferResult{}, err
+	}
+
+	s.invalidateBannerSlots(ctx, result.Slots)
+
+	return result, nil
+}
+
+// Set state of banners matching toggle, cached banners of their slots are dropped.
+// Kill switch also drops cache of every slot of feature, so banners deactivated
+// before by update are not served from cache either.
+func (s *BannerService) ToggleBanners(ctx context.Context, toggle bannermodels.BannerToggle, dryRun bool) (bannermodels.ToggleResult, error) {
+	ctx, span := startSpan(
+		ctx,
+		"ToggleBanners",
+		attribute.Bool("banner.is_active", *toggle.IsActive),
+		attribute.Bool("banner.kill_switch", toggle.KillSwitch),
+		attribute.Bool("banner.dry_run", dryRun),
+	)
+	defer span.End()
+
+	ids, slots, err := s.repo.ToggleBanners(ctx, toggle, dryRun)
+	if err != nil {
+		return bannermodels.ToggleResult{}, err
+	}
+
+	result := bannermodels.ToggleResult{BannerIDs: ids, IsActive: *toggle.IsActive, DryRun: dryRun}
+	if dryRun {
+		return result, nil
+	}
+
+	if toggle.KillSwitch {
+		slots, err = s.repo.FeatureSlots(ctx, *toggle.FeatureID)
+		if err != nil {
+			return bannermodels.ToggleResult{}, err
+		}
+	}
+
+	s.invalidateBannerSlots(ctx, slots)
+
+	return result, nil
+}
+
+// Called after changes are committed, watchers of slots are notified.
+// Stale cache expires anyway, so failures are only logged.
+func (s *BannerService) invalidateSlots(ctx context.Context, featureID int, tagIDs []int) {
+	for _, tagID := range tagIDs {
+		err := s.cache.DeleteBanner(ctx, tagID, featureID)
+		if err != nil {
+			slog.WarnContext(ctx, "invalidate cache", "tag_id", tagID, "feature_id", featureID, "err", err)
+		}
+	}
+	s.watchers.notify(featureID, tagIDs)
+}
+
+func (s *BannerService) invalidateBannerSlots(ctx context.Context, slots []bannermodels.Slot) {
+	for _, slot := range slots {
+		s.invalidateSlots(ctx, slot.FeatureID, []int{slot.TagID})
+	}
+}
+
+// watch changes of user banners of tag and features, watch must be stopped when it is not needed
+func (s *BannerService) WatchUserBanners(tagID int, featureIDs []int) *SlotWatch {
+	return s.watchers.watch(tagID, featureIDs)
+}
@@ -0,0 +1,112 @@
+package service
+
+import (
+	bannermodels "banner/internal/models/banner"
+	"context"
+	"errors"
+
+	"go.opentelemetry.io/otel/attribute"
+)
+
+func draftErr(err error) error {
+	switch {
+	case errors.Is(err, ErrDBDraftNotFound):
+		return ErrDraftNotFound
+	case errors.Is(err, ErrDBBannerNotFound):
+		return ErrBannerNotFound
+	}
+	return err
+}
+
+// create or replace draft of banner by author, live banner is not changed
+func (s *BannerService) SaveDraft(ctx context.Context, bannerID int, author string, banner bannermodels.Banner) (bannermodels.Draft, error) {
+	ctx, span := startSpan(ctx, "SaveDraft", attribute.Int("banner.id", bannerID))
+	defer span.End()
+
+	draft, err := s.repo.SaveDraft(ctx, bannermodels.Draft{
+		BannerID:  bannerID,
+		TagIDs:    banner.TagIDs,
+		FeatureID: banner.FeatureID,
+		Content:   banner.Content,
+		IsActive:  banner.IsActive,
+		Author:    author,
+	})
+	return draft, draftErr(err)
+}
+
+func (s *BannerService) GetDraft(ctx context.Context, bannerID int) (bannermodels.Draft, error) {
+	ctx, span := startSpan(ctx, "GetDraft", attribute.Int("banner.id", bannerID))
+	defer span.End()
+
+	draft, err := s.repo.GetDraft(ctx, bannerID)
+	return draft, draftErr(err)
+}
+
+func (s *BannerService) DeleteDraft(ctx context.Context, bannerID int) error {
+	ctx, span := startSpan(ctx, "DeleteDraft", attribute.Int("banner.id", bannerID))
+	defer span.End()
+
+	return draftErr(s.repo.DeleteDraft(ctx, bannerID))
+}
+
+func (s *BannerService) ApproveDraft(ctx context.Context, bannerID int, approver string) (bannermodels.Draft, error) {
+	ctx, span := startSpan(ctx, "ApproveDraft", attribute.Int("banner.id", bannerID))
+	defer span.End()
+
+	draft, err := s.repo.ApproveDraft(ctx, bannerID, approver)
+	return draft, draftErr(err)
+}
+
+// make approved draft live, cached banners of old and new slots are dropped
+func (s *BannerService) PublishDraft(ctx context.Context, bannerID int, publisher string) (bannermodels.PublishResult, error) {
+	ctx, span := startSpan(ctx, "PublishDraft", attribute.Int("banner.id", bannerID))
+	defer span.End()
+
+	result, previous, err := s.repo.PublishDraft(ctx, bannerID, publisher)
+
+	var conflictErr *SlotConflictError
+	switch {
+	case errors.As(err, &conflictErr):
+		return bannermodels.PublishResult{}, conflictErr
+	case err != nil:
+		return bannermodels.PublishResult{}, draftErr(err)
+	}
+
+	s.invalidateSlots(ctx, previous.FeatureID, previous.TagIDs)
+	s.invalidateSlots(ctx, result.FeatureID, result.TagIDs)
+
+	return result, nil
+}
+
+// Content of draft holding slot as it would be shown after publishing,
+// live banner if there is no such draft. Only for admins, so inactive banners are shown too.
+func (s *BannerService) PreviewUserBanner(ctx context.Context, tagID int, featureID int) (map[string]interface{}, error) {
+	ctx, span := startSpan(
+		ctx,
+		"PreviewUserBanner",
+		attribute.Int("banner.tag_id", tagID),
+		attribute.Int("banner.feature_id", featureID),
+	)
+	defer span.End()
+
+	var content map[string]interface{}
+
+	draft, err := s.repo.GetDraftForSlot(ctx, tagID, featureID)
+	switch {
+	case err == nil:
+		content = draft.Content
+	case errors.Is(err, ErrDBDraftNotFound):
+		b, err := s.repo.GetUserBanner(ctx, tagID, featureID)
+		switch {
+		case errors.Is(err, ErrDBBannerNotFound):
+			return nil, ErrBannerNotFound
+		case err != nil:
+			return nil, err
+		}
+		content = b.Content
+	default:
+		return nil, err
+	}
+
+	return content, nil
+}
@@ -1,6 +1,10 @@
 package service
 
-import "errors"
+import (
+	bannermodels "banner/internal/models/banner"
+	"errors"
+	"fmt"
+)
 
 var (
 	ErrUserForbidden = errors.New("the user does not have access")
@@ -16,4 +20,33 @@ var (
 	)
 
 	ErrCacheBannerNotFound = errors.New("banner not found in cache")
+
+	ErrDraftNotFound   = errors.New("draft not found")
+	ErrDBDraftNotFound = errors.New("draft not found in db")
+
+	ErrSelfApproval     = errors.New("author can not approve own draft")
+	ErrDraftNotApproved = errors.New("draft is not approved")
+
+	ErrFeatureMismatch = errors.New("banners have different feature_id")
+	ErrSlotNotHeld     = errors.New("slot is not held by banners of transfer")
+
+	ErrWebhookNotFound    = errors.New("webhook not found")
+	ErrDBWebhookNotFound  = errors.New("webhook not found in db")
+	ErrDeliveryNotFound   = errors.New("webhook delivery not found")
+	ErrDBDeliveryNotFound = errors.New("webhook delivery not found in db")
 )
+
+// slots requested for banner are held by other banners,
+// is ErrBannerAlreadyExists and ErrDBBannerAlreadyExists
+type SlotConflictError struct {
+	// may be empty if holders released slots before they were looked up
+	Slots []bannermodels.Slot
+}
+
+func (e *SlotConflictError) Error() string {
+	return fmt.Sprintf("%v: %d slots are taken", ErrBannerAlreadyExists, len(e.Slots))
+}
+
+func (e *SlotConflictError) Is(target error) bool {
+	return target == ErrBannerAlreadyExists || target == ErrDBBannerAlreadyExists
+}
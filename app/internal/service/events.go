@@ -0,0 +1,132 @@
+package service
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+
+	bannermodels "banner/internal/models/banner"
+
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// Consumer of banner_events. Events come in log order, batch is passed again
+// until Handle succeeds, so Handle must tolerate repeated events.
+type EventSink interface {
+	// key of cursor of sink, it must not change between releases
+	Name() string
+	Handle(ctx context.Context, events []bannermodels.BannerChange) error
+}
+
+type eventLog interface {
+	ConsumeEvents(ctx context.Context, sink string, limit int, fn func([]bannermodels.BannerChange) error) (int, error)
+	PurgeEvents(ctx context.Context, retention time.Duration) (int, error)
+}
+
+type eventObserver interface {
+	ObserveEventsPublished(sink string, count int)
+	ObserveEventSinkError(sink string)
+}
+
+// up to limit events after id after, external consumers tail log by last event id they got,
+// history of one banner is read with bannerID
+func (s *BannerService) BannerEvents(ctx context.Context, after int64, limit int, bannerID int) ([]bannermodels.BannerChange, error) {
+	ctx, span := startSpan(ctx, "BannerEvents", attribute.Int64("events.after", after), attribute.Int("banner.id", bannerID))
+	defer span.End()
+
+	return s.repo.BannerEvents(ctx, after, limit, bannerID)
+}
+
+type EventPublisherConfig struct {
+	PollInterval time.Duration
+	BatchSize    int
+	// events are kept this long whether sinks got them or not
+	Retention time.Duration
+}
+
+// Passes events of log to sinks with at-least-once delivery, each sink has its own cursor.
+// Every replica may run it, each sink is handled by one of them at a time.
+type EventPublisher struct {
+	log      eventLog
+	observer eventObserver
+	cfg      EventPublisherConfig
+	sinks    []EventSink
+}
+
+func NewEventPublisher(log eventLog, observer eventObserver, cfg EventPublisherConfig, sinks ...EventSink) *EventPublisher {
+	return &EventPublisher{
+		log:      log,
+		observer: observer,
+		cfg:      cfg,
+		sinks:    sinks,
+	}
+}
+
+// publish events to every sink and purge expired events until ctx is done
+func (p *EventPublisher) Run(ctx context.Context) {
+	var wg sync.WaitGroup
+	for _, sink := range p.sinks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			p.runSink(ctx, sink)
+		}()
+	}
+
+	purgeTicker := time.NewTicker(time.Hour)
+	defer purgeTicker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			wg.Wait()
+			return
+		case <-purgeTicker.C:
+			purged, err := p.log.PurgeEvents(ctx, p.cfg.Retention)
+			switch {
+			case err != nil && ctx.Err() == nil:
+				slog.ErrorContext(ctx, "purge banner events", "err", err)
+			case purged != 0:
+				slog.InfoContext(ctx, "banner events purged", "events", purged, "retention", p.cfg.Retention.String())
+			}
+		}
+	}
+}
+
+// full batches are followed by next one at once, failed batch is retried after poll interval
+func (p *EventPublisher) runSink(ctx context.Context, sink EventSink) {
+	ticker := time.NewTicker(p.cfg.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		published, err := p.log.ConsumeEvents(ctx, sink.Name(), p.cfg.BatchSize, func(events []bannermodels.BannerChange) error {
+			ctx, span := startSpan(
+				ctx,
+				"PublishEvents",
+				attribute.String("events.sink", sink.Name()),
+				attribute.Int("events.count", len(events)),
+			)
+			defer span.End()
+
+			return sink.Handle(ctx, events)
+		})
+		switch {
+		case err != nil && ctx.Err() == nil:
+			slog.ErrorContext(ctx, "publish banner events", "sink", sink.Name(), "err", err)
+			p.observer.ObserveEventSinkError(sink.Name())
+		case published != 0:
+			p.observer.ObserveEventsPublished(sink.Name(), published)
+		}
+
+		if published == p.cfg.BatchSize && ctx.Err() == nil {
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,62 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// take banner out of trash, conflict is returned if its slots are taken
+func (s *BannerService) RestoreBanner(ctx context.Context, id int) error {
+	ctx, span := startSpan(ctx, "RestoreBanner", attribute.Int("banner.id", id))
+	defer span.End()
+
+	slots, err := s.repo.RestoreBanner(ctx, id)
+
+	var conflictErr *SlotConflictError
+	switch {
+	case errors.As(err, &conflictErr):
+		return conflictErr
+	case errors.Is(err, ErrDBBannerNotFound):
+		return ErrBannerNotFound
+	case err != nil:
+		return err
+	}
+
+	s.invalidateBannerSlots(ctx, slots)
+
+	return nil
+}
+
+// delete banners that are in trash longer than retention
+func (s *BannerService) PurgeTrash(ctx context.Context, retention time.Duration) (int, error) {
+	ctx, span := startSpan(ctx, "PurgeTrash", attribute.String("trash.retention", retention.String()))
+	defer span.End()
+
+	return s.repo.PurgeTrash(ctx, retention)
+}
+
+// purge trash every interval until ctx is done, failed runs are only logged
+func (s *BannerService) RunTrashPurge(ctx context.Context, retention time.Duration, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		purged, err := s.PurgeTrash(ctx, retention)
+		switch {
+		case err != nil && ctx.Err() == nil:
+			slog.ErrorContext(ctx, "purge trash", "err", err)
+		case purged != 0:
+			slog.InfoContext(ctx, "trash purged", "banners", purged, "retention", retention.String())
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
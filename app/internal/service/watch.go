@@ -0,0 +1,109 @@
+package service
+
+import (
+	"slices"
+	"sync"
+)
+
+// Watch of user banners of one tag and several features.
+// C gets value after banners of watched slots are changed, changes are coalesced,
+// so Changed must be called to know which features were changed.
+type SlotWatch struct {
+	C <-chan struct{}
+
+	tagID    int
+	features []int
+	notify   chan struct{}
+	watchers *slotWatchers
+
+	mu      sync.Mutex
+	changed map[int]struct{}
+}
+
+// features changed since previous call
+func (w *SlotWatch) Changed() []int {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	featureIDs := make([]int, 0, len(w.changed))
+	for featureID := range w.changed {
+		featureIDs = append(featureIDs, featureID)
+	}
+	clear(w.changed)
+
+	slices.Sort(featureIDs)
+	return featureIDs
+}
+
+func (w *SlotWatch) Stop() {
+	w.watchers.remove(w)
+}
+
+func (w *SlotWatch) add(featureID int) {
+	w.mu.Lock()
+	w.changed[featureID] = struct{}{}
+	w.mu.Unlock()
+
+	select {
+	case w.notify <- struct{}{}:
+	default:
+	}
+}
+
+// Watches of this process by feature, changes made by other replicas are not seen.
+type slotWatchers struct {
+	mu        sync.Mutex
+	byFeature map[int]map[*SlotWatch]struct{}
+}
+
+func newSlotWatchers() *slotWatchers {
+	return &slotWatchers{byFeature: make(map[int]map[*SlotWatch]struct{})}
+}
+
+func (sw *slotWatchers) watch(tagID int, featureIDs []int) *SlotWatch {
+	notify := make(chan struct{}, 1)
+	w := &SlotWatch{
+		C:        notify,
+		tagID:    tagID,
+		features: featureIDs,
+		notify:   notify,
+		watchers: sw,
+		changed:  make(map[int]struct{}),
+	}
+
+	sw.mu.Lock()
+	defer sw.mu.Unlock()
+
+	for _, featureID := range featureIDs {
+		watches, ok := sw.byFeature[featureID]
+		if !ok {
+			watches = make(map[*SlotWatch]struct{})
+			sw.byFeature[featureID] = watches
+		}
+		watches[w] = struct{}{}
+	}
+	return w
+}
+
+func (sw *slotWatchers) remove(w *SlotWatch) {
+	sw.mu.Lock()
+	defer sw.mu.Unlock()
+
+	for _, featureID := range w.features {
+		delete(sw.byFeature[featureID], w)
+		if len(sw.byFeature[featureID]) == 0 {
+			delete(sw.byFeature, featureID)
+		}
+	}
+}
+
+func (sw *slotWatchers) notify(featureID int, tagIDs []int) {
+	sw.mu.Lock()
+	defer sw.mu.Unlock()
+
+	for w := range sw.byFeature[featureID] {
+		if slices.Contains(tagIDs, w.tagID) {
+			w.add(featureID)
+		}
+	}
+}
@@ -0,0 +1,265 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"log/slog"
+	"sync"
+	"time"
+
+	bannermodels "banner/internal/models/banner"
+	webhookmodels "banner/internal/models/webhook"
+	"banner/internal/webhook"
+
+	"go.opentelemetry.io/otel/attribute"
+)
+
+const (
+	webhookSecretBytes = 32
+
+	// longer errors of receivers are cut
+	maxDeliveryErrorLen = 512
+)
+
+type webhookEnqueuer interface {
+	EnqueueWebhookDeliveries(ctx context.Context, events []bannermodels.BannerChange) error
+}
+
+type webhookOutbox interface {
+	ClaimWebhookDeliveries(ctx context.Context, limit int, lease time.Duration) ([]webhookmodels.ClaimedDelivery, error)
+	CompleteWebhookDelivery(ctx context.Context, attempt webhookmodels.Attempt) error
+	FailWebhookDelivery(ctx context.Context, attempt webhookmodels.Attempt, retryIn time.Duration, dead bool) error
+	PurgeWebhookDeliveries(ctx context.Context, retention time.Duration) (int, error)
+}
+
+type webhookSender interface {
+	Send(ctx context.Context, delivery webhookmodels.ClaimedDelivery) (int, error)
+}
+
+type deliveryObserver interface {
+	ObserveWebhookDelivery(result string)
+}
+
+func webhookErr(err error) error {
+	switch {
+	case errors.Is(err, ErrDBWebhookNotFound):
+		return ErrWebhookNotFound
+	case errors.Is(err, ErrDBDeliveryNotFound):
+		return ErrDeliveryNotFound
+	}
+	return err
+}
+
+// subscribe to banner changes, random secret is generated if it is empty
+func (s *BannerService) CreateWebhook(ctx context.Context, hook webhookmodels.Webhook) (webhookmodels.Webhook, error) {
+	ctx, span := startSpan(ctx, "CreateWebhook")
+	defer span.End()
+
+	if hook.Secret == "" {
+		secret := make([]byte, webhookSecretBytes)
+		if _, err := rand.Read(secret); err != nil {
+			return webhookmodels.Webhook{}, err
+		}
+		hook.Secret = hex.EncodeToString(secret)
+	}
+	if hook.Events == nil {
+		hook.Events = []string{}
+	}
+
+	return s.repo.CreateWebhook(ctx, hook)
+}
+
+func (s *BannerService) ListWebhooks(ctx context.Context) ([]webhookmodels.Webhook, error) {
+	ctx, span := startSpan(ctx, "ListWebhooks")
+	defer span.End()
+
+	return s.repo.ListWebhooks(ctx)
+}
+
+func (s *BannerService) DeleteWebhook(ctx context.Context, id int) error {
+	ctx, span := startSpan(ctx, "DeleteWebhook", attribute.Int("webhook.id", id))
+	defer span.End()
+
+	return webhookErr(s.repo.DeleteWebhook(ctx, id))
+}
+
+// deliveries of webhook or of all webhooks if filter.WebhookID is 0, dead ones are dead letters
+func (s *BannerService) WebhookDeliveries(ctx context.Context, filter webhookmodels.DeliveryFilter) ([]webhookmodels.Delivery, error) {
+	ctx, span := startSpan(ctx, "WebhookDeliveries", attribute.Int("webhook.id", filter.WebhookID))
+	defer span.End()
+
+	deliveries, err := s.repo.WebhookDeliveries(ctx, filter)
+	return deliveries, webhookErr(err)
+}
+
+// send delivery again, e.g. dead one after receiver is fixed
+func (s *BannerService) RedeliverWebhook(ctx context.Context, webhookID int, deliveryID int64) (webhookmodels.Delivery, error) {
+	ctx, span := startSpan(
+		ctx,
+		"RedeliverWebhook",
+		attribute.Int("webhook.id", webhookID),
+		attribute.Int64("webhook.delivery_id", deliveryID),
+	)
+	defer span.End()
+
+	delivery, err := s.repo.RedeliverWebhook(ctx, webhookID, deliveryID)
+	return delivery, webhookErr(err)
+}
+
+// Sink of banner events which writes deliveries to matching webhooks.
+// Deliveries are written once per webhook and event, even if event is passed again.
+type WebhookSink struct {
+	repo webhookEnqueuer
+}
+
+func NewWebhookSink(repo webhookEnqueuer) *WebhookSink {
+	return &WebhookSink{repo: repo}
+}
+
+func (s *WebhookSink) Name() string {
+	return "webhooks"
+}
+
+func (s *WebhookSink) Handle(ctx context.Context, events []bannermodels.BannerChange) error {
+	return s.repo.EnqueueWebhookDeliveries(ctx, events)
+}
+
+type WebhookDispatcherConfig struct {
+	PollInterval time.Duration
+	BatchSize    int
+	// time of one request, claimed deliveries are leased for twice of it
+	Timeout time.Duration
+	Retry   webhook.RetryPolicy
+	// delivered deliveries are kept this long
+	Retention time.Duration
+}
+
+// Sends deliveries written by webhook sink. Every replica may run it,
+// each delivery is claimed by one of them.
+type WebhookDispatcher struct {
+	outbox   webhookOutbox
+	sender   webhookSender
+	observer deliveryObserver
+	cfg      WebhookDispatcherConfig
+}
+
+func NewWebhookDispatcher(outbox webhookOutbox, sender webhookSender, observer deliveryObserver, cfg WebhookDispatcherConfig) *WebhookDispatcher {
+	return &WebhookDispatcher{
+		outbox:   outbox,
+		sender:   sender,
+		observer: observer,
+		cfg:      cfg,
+	}
+}
+
+// Deliver due deliveries until ctx is done, full batches are followed by next one at once.
+// Failed runs are only logged, deliveries stay in outbox.
+func (d *WebhookDispatcher) Run(ctx context.Context) {
+	ticker := time.NewTicker(d.cfg.PollInterval)
+	defer ticker.Stop()
+
+	purgeTicker := time.NewTicker(time.Hour)
+	defer purgeTicker.Stop()
+
+	for {
+		claimed, err := d.dispatch(ctx)
+		if err != nil && ctx.Err() == nil {
+			slog.ErrorContext(ctx, "dispatch webhooks", "err", err)
+		}
+
+		if claimed == d.cfg.BatchSize {
+			if ctx.Err() != nil {
+				return
+			}
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-purgeTicker.C:
+			d.purge(ctx)
+		case <-ticker.C:
+		}
+	}
+}
+
+// send one batch concurrently, returns count of claimed deliveries
+func (d *WebhookDispatcher) dispatch(ctx context.Context) (int, error) {
+	deliveries, err := d.outbox.ClaimWebhookDeliveries(ctx, d.cfg.BatchSize, 2*d.cfg.Timeout)
+	if err != nil {
+		return 0, err
+	}
+
+	var wg sync.WaitGroup
+	for _, delivery := range deliveries {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			d.deliver(ctx, delivery)
+		}()
+	}
+	wg.Wait()
+
+	return len(deliveries), nil
+}
+
+func (d *WebhookDispatcher) deliver(ctx context.Context, delivery webhookmodels.ClaimedDelivery) {
+	ctx, span := startSpan(
+		ctx,
+		"DeliverWebhook",
+		attribute.Int("webhook.id", delivery.WebhookID),
+		attribute.Int64("webhook.delivery_id", delivery.ID),
+		attribute.String("webhook.event", delivery.Event),
+	)
+	defer span.End()
+
+	statusCode, err := d.sender.Send(ctx, delivery)
+	// lease is over later, so delivery is sent again by any replica
+	if ctx.Err() != nil {
+		return
+	}
+
+	attempt := webhookmodels.Attempt{DeliveryID: delivery.ID, StatusCode: statusCode, Err: err}
+	logArgs := []any{"webhook_id", delivery.WebhookID, "delivery_id", delivery.ID, "attempt", delivery.Attempts + 1}
+
+	if err == nil {
+		err = d.outbox.CompleteWebhookDelivery(ctx, attempt)
+		if err != nil {
+			slog.ErrorContext(ctx, "complete webhook delivery", append(logArgs, "err", err)...)
+			return
+		}
+		d.observer.ObserveWebhookDelivery("delivered")
+		return
+	}
+
+	if msg := err.Error(); len(msg) > maxDeliveryErrorLen {
+		attempt.Err = errors.New(msg[:maxDeliveryErrorLen])
+	}
+
+	retryIn, retry := d.cfg.Retry.Next(delivery.Attempts + 1)
+	result := "retry"
+	if !retry {
+		result = "dead"
+		slog.WarnContext(ctx, "webhook delivery is dead", append(logArgs, "err", err)...)
+	}
+
+	err = d.outbox.FailWebhookDelivery(ctx, attempt, retryIn, !retry)
+	if err != nil {
+		slog.ErrorContext(ctx, "fail webhook delivery", append(logArgs, "err", err)...)
+		return
+	}
+	d.observer.ObserveWebhookDelivery(result)
+}
+
+func (d *WebhookDispatcher) purge(ctx context.Context) {
+	purged, err := d.outbox.PurgeWebhookDeliveries(ctx, d.cfg.Retention)
+	switch {
+	case err != nil && ctx.Err() == nil:
+		slog.ErrorContext(ctx, "purge webhook deliveries", "err", err)
+	case purged != 0:
+		slog.InfoContext(ctx, "webhook deliveries purged", "deliveries", purged, "retention", d.cfg.Retention.String())
+	}
+}
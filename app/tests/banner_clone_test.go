@@ -0,0 +1,102 @@
+package tests
+
+import (
+	bannermodels "banner/internal/models/banner"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const bannerCloneURL = baseURL + "/banner/%d/clone"
+
+func cloneBanner(t *testing.T, id int, body string) *http.Response {
+	t.Helper()
+
+	client, req, err := makeClientRequest(http.MethodPost, fmt.Sprintf(bannerCloneURL, id), bytes.NewBufferString(body))
+	if err != nil {
+		log.Panic(err)
+	}
+	resp, err := client.Do(req)
+	require.NoError(t, err)
+	return resp
+}
+
+func TestCloneBanner(t *testing.T) {
+	db.SetUp(t, bannerTableName, bannerRelationTableName)
+	defer db.TearDown(bannerTableName, bannerRelationTableName)
+
+	// arrange
+	source, err := createBanner(bannermodels.Banner{TagIDs: []int{1, 2}, FeatureID: 1, Content: testContentObj, IsActive: true})
+	if err != nil {
+		log.Panic(err)
+	}
+
+	// act
+	resp := cloneBanner(t, source.ID, `{"feature_id": 2, "tag_ids": [1, 3], "is_active": false}`)
+	defer resp.Body.Close()
+
+	// assert
+	require.Equal(t, http.StatusCreated, resp.StatusCode)
+
+	var created struct {
+		ID int `json:"banner_id"`
+	}
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&created))
+
+	clone, err := getBannerByID(created.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 2, clone.FeatureID)
+	assert.Equal(t, []int{1, 3}, clone.TagIDs)
+	assert.False(t, clone.IsActive)
+	assert.Equal(t, testContentObj, clone.Content)
+
+	var listed []bannermodels.Banner
+	getJSON(t, bannerListURL+"?feature_id=2", &listed)
+	require.Len(t, listed, 1)
+	require.NotNil(t, listed[0].ClonedFrom)
+	assert.Equal(t, source.ID, *listed[0].ClonedFrom)
+}
+
+func TestCloneBannerSlotConflict(t *testing.T) {
+	db.SetUp(t, bannerTableName, bannerRelationTableName)
+	defer db.TearDown(bannerTableName, bannerRelationTableName)
+
+	// arrange
+	source, err := createBanner(bannermodels.Banner{TagIDs: []int{1, 2}, FeatureID: 1, Content: testContentObj, IsActive: true})
+	if err != nil {
+		log.Panic(err)
+	}
+
+	// act: slots of source are kept, so they are taken
+	resp := cloneBanner(t, source.ID, `{"tag_ids": [2, 3]}`)
+	defer resp.Body.Close()
+
+	// assert
+	require.Equal(t, http.StatusConflict, resp.StatusCode)
+
+	var errResp struct {
+		Error struct {
+			Code      string              `json:"code"`
+			Conflicts []bannermodels.Slot `json:"conflicts"`
+		} `json:"error"`
+	}
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&errResp))
+	assert.Equal(t, "SLOT_CONFLICT", errResp.Error.Code)
+	assert.Equal(t, []bannermodels.Slot{{TagID: 2, FeatureID: 1, BannerID: source.ID}}, errResp.Error.Conflicts)
+}
+
+func TestCloneBannerNotFound(t *testing.T) {
+	db.SetUp(t, bannerTableName, bannerRelationTableName)
+	defer db.TearDown(bannerTableName, bannerRelationTableName)
+
+	resp := cloneBanner(t, 100, `{"feature_id": 2}`)
+	defer resp.Body.Close()
+
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+}
@@ -2,14 +2,14 @@ package tests
 
 import (
 	bannermodels "banner/internal/models/banner"
-	"errors"
+	"context"
 	"fmt"
 	"io"
 	"log"
 	"net/http"
 	"testing"
+	"time"
 
-	"github.com/jackc/pgx/v4"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -48,7 +48,27 @@ func TestDeleteBanner(t *testing.T) {
 
 	assert.Equal(t, http.StatusNoContent, resp.StatusCode, string(resultBytes))
 
-	_, err = getBannerByID(banner.ID)
-	require.Error(t, err)
-	require.True(t, errors.Is(err, pgx.ErrNoRows))
+	// banner is in trash and its slots are free
+	var deletedAt *time.Time
+	var deletedBy *string
+	err = db.DB.QueryRow(context.Background(), stmtGetBannerDeleted, banner.ID).Scan(&deletedAt, &deletedBy)
+	require.NoError(t, err)
+	require.NotNil(t, deletedAt)
+	require.NotNil(t, deletedBy)
+	assert.Equal(t, "admin", *deletedBy)
+
+	var relations int
+	err = db.DB.QueryRow(context.Background(), stmtCountBannerRelations, banner.ID).Scan(&relations)
+	require.NoError(t, err)
+	assert.Equal(t, 0, relations)
+
+	// second delete does not find banner
+	client, req, err = makeClientRequest(http.MethodDelete, url, nil)
+	if err != nil {
+		log.Panic(err)
+	}
+	resp, err = client.Do(req)
+	require.NoError(t, err, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
 }
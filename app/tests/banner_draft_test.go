@@ -0,0 +1,163 @@
+package tests
+
+import (
+	bannermodels "banner/internal/models/banner"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	bannerDraftURL        = baseURL + "/banner/%d/draft"
+	bannerDraftApproveURL = baseURL + "/banner/%d/draft/approve"
+	bannerDraftPublishURL = baseURL + "/banner/%d/draft/publish"
+
+	aliceToken = "alice_token"
+	bobToken   = "bob_token"
+)
+
+func draftRequest(t *testing.T, method string, url string, token string, body io.Reader) *http.Response {
+	client, req, err := makeClientRequest(method, url, body)
+	if err != nil {
+		log.Panic(err)
+	}
+	req.Header.Set(tokenHeaderName, token)
+
+	resp, err := client.Do(req)
+	require.NoError(t, err, err)
+	return resp
+}
+
+func userBannerTitle(t *testing.T, query string, token string) (int, string) {
+	resp := draftRequest(t, http.MethodGet, bannerGetUserURL+query, token, nil)
+	defer resp.Body.Close()
+
+	var content map[string]interface{}
+	if resp.StatusCode == http.StatusOK {
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&content))
+	}
+	title, _ := content["title"].(string)
+	return resp.StatusCode, title
+}
+
+func TestDraftWorkflow(t *testing.T) {
+	db.SetUp(t, bannerTableName, bannerRelationTableName)
+	defer db.TearDown(bannerTableName, bannerRelationTableName)
+
+	// arrange
+	banner, err := createBanner(bannermodels.Banner{
+		TagIDs:    []int{1},
+		FeatureID: 1,
+		Content:   map[string]interface{}{"title": "live"},
+		IsActive:  true,
+	})
+	if err != nil {
+		log.Panic(err)
+	}
+
+	draftBody, err := json.Marshal(bannermodels.BannerRequest{
+		TagIDs:    []int{1, 2},
+		FeatureID: 1,
+		Content:   map[string]interface{}{"title": "draft"},
+		IsActive:  true,
+	})
+	if err != nil {
+		log.Panic(err)
+	}
+
+	// act & assert
+	resp := draftRequest(t, http.MethodPut, fmt.Sprintf(bannerDraftURL, banner.ID), aliceToken, bytes.NewReader(draftBody))
+	resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	// live banner is not changed, preview shows draft
+	status, title := userBannerTitle(t, "?tag_id=1&feature_id=1", userToken)
+	require.Equal(t, http.StatusOK, status)
+	assert.Equal(t, "live", title)
+
+	status, title = userBannerTitle(t, "?tag_id=2&feature_id=1&preview=draft", adminToken)
+	require.Equal(t, http.StatusOK, status)
+	assert.Equal(t, "draft", title)
+
+	status, _ = userBannerTitle(t, "?tag_id=1&feature_id=1&preview=draft", userToken)
+	assert.Equal(t, http.StatusForbidden, status)
+
+	// not approved draft can not be published, author can not approve
+	resp = draftRequest(t, http.MethodPost, fmt.Sprintf(bannerDraftPublishURL, banner.ID), aliceToken, nil)
+	require.Equal(t, http.StatusConflict, resp.StatusCode)
+	assert.Equal(t, "DRAFT_NOT_APPROVED", readErrorResponse(t, resp).Error.Code)
+	resp.Body.Close()
+
+	resp = draftRequest(t, http.MethodPost, fmt.Sprintf(bannerDraftApproveURL, banner.ID), aliceToken, nil)
+	require.Equal(t, http.StatusForbidden, resp.StatusCode)
+	assert.Equal(t, "SELF_APPROVAL", readErrorResponse(t, resp).Error.Code)
+	resp.Body.Close()
+
+	resp = draftRequest(t, http.MethodPost, fmt.Sprintf(bannerDraftApproveURL, banner.ID), bobToken, nil)
+	resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	resp = draftRequest(t, http.MethodPost, fmt.Sprintf(bannerDraftPublishURL, banner.ID), aliceToken, nil)
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	var result bannermodels.PublishResult
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&result))
+	assert.Equal(t, "alice", result.Author)
+	assert.Equal(t, "bob", result.ApprovedBy)
+	assert.Equal(t, "alice", result.PublishedBy)
+
+	published, err := getBannerByID(banner.ID)
+	require.NoError(t, err, err)
+	assert.ElementsMatch(t, []int{1, 2}, published.TagIDs)
+	assert.Equal(t, "draft", published.Content["title"])
+
+	status, title = userBannerTitle(t, "?tag_id=2&feature_id=1", userToken)
+	require.Equal(t, http.StatusOK, status)
+	assert.Equal(t, "draft", title)
+
+	// draft is gone after publishing
+	resp = draftRequest(t, http.MethodGet, fmt.Sprintf(bannerDraftURL, banner.ID), adminToken, nil)
+	defer resp.Body.Close()
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+}
+
+func TestDraftEditDropsApproval(t *testing.T) {
+	db.SetUp(t, bannerTableName, bannerRelationTableName)
+	defer db.TearDown(bannerTableName, bannerRelationTableName)
+
+	// arrange
+	banner, err := createBanner(bannermodels.Banner{
+		TagIDs:    []int{1},
+		FeatureID: 1,
+		Content:   testContentObj,
+		IsActive:  true,
+	})
+	if err != nil {
+		log.Panic(err)
+	}
+
+	body := `{"tag_ids": [1], "feature_id": 1, "content": {"title": "draft"}, "is_active": true}`
+	resp := draftRequest(t, http.MethodPut, fmt.Sprintf(bannerDraftURL, banner.ID), aliceToken, bytes.NewBufferString(body))
+	resp.Body.Close()
+	resp = draftRequest(t, http.MethodPost, fmt.Sprintf(bannerDraftApproveURL, banner.ID), bobToken, nil)
+	resp.Body.Close()
+
+	// act
+	resp = draftRequest(t, http.MethodPut, fmt.Sprintf(bannerDraftURL, banner.ID), aliceToken, bytes.NewBufferString(body))
+
+	// assert
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	var draft bannermodels.Draft
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&draft))
+	assert.Nil(t, draft.ApprovedBy)
+}
@@ -0,0 +1,108 @@
+package tests
+
+import (
+	bannermodels "banner/internal/models/banner"
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func userBannerRequest(t *testing.T, url string, token string, ifNoneMatch string) *http.Response {
+	client, req, err := makeClientRequest(http.MethodGet, url, nil)
+	if err != nil {
+		log.Panic(err)
+	}
+	req.Header.Set(tokenHeaderName, token)
+	if ifNoneMatch != "" {
+		req.Header.Set("If-None-Match", ifNoneMatch)
+	}
+
+	resp, err := client.Do(req)
+	require.NoError(t, err, err)
+	return resp
+}
+
+func TestUserBannerNotModified(t *testing.T) {
+	db.SetUp(t, bannerTableName, bannerRelationTableName)
+	defer db.TearDown(bannerTableName, bannerRelationTableName)
+
+	// arrange
+	_, err := createBanner(bannermodels.Banner{TagIDs: []int{451}, FeatureID: 451, Content: testContentObj, IsActive: true})
+	if err != nil {
+		log.Panic(err)
+	}
+	url := bannerGetUserURL + "?tag_id=451&feature_id=451"
+
+	resp := userBannerRequest(t, url, userToken, "")
+	resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	etag := resp.Header.Get("ETag")
+	require.NotEmpty(t, etag)
+	assert.Regexp(t, `^public, max-age=\d+$`, resp.Header.Get("Cache-Control"))
+
+	// act
+	resp = userBannerRequest(t, url, userToken, etag)
+	defer resp.Body.Close()
+
+	// assert
+	assert.Equal(t, http.StatusNotModified, resp.StatusCode)
+	assert.Equal(t, etag, resp.Header.Get("ETag"))
+}
+
+func TestUserBannerETagChangesWithContent(t *testing.T) {
+	db.SetUp(t, bannerTableName, bannerRelationTableName)
+	defer db.TearDown(bannerTableName, bannerRelationTableName)
+
+	// arrange
+	banner, err := createBanner(bannermodels.Banner{TagIDs: []int{452}, FeatureID: 452, Content: testContentObj, IsActive: true})
+	if err != nil {
+		log.Panic(err)
+	}
+	url := bannerGetUserURL + "?tag_id=452&feature_id=452&use_last_revision=true"
+
+	resp := userBannerRequest(t, url, userToken, "")
+	resp.Body.Close()
+	etag := resp.Header.Get("ETag")
+	assert.Equal(t, "no-cache", resp.Header.Get("Cache-Control"))
+
+	_, err = db.DB.Exec(
+		context.Background(),
+		`UPDATE banner SET content = '{"title": "new"}' WHERE id = $1`,
+		banner.ID,
+	)
+	require.NoError(t, err, err)
+
+	// act
+	resp = userBannerRequest(t, url, userToken, etag)
+	defer resp.Body.Close()
+
+	// assert
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.NotEqual(t, etag, resp.Header.Get("ETag"))
+}
+
+func TestInactiveUserBannerPrivate(t *testing.T) {
+	db.SetUp(t, bannerTableName, bannerRelationTableName)
+	defer db.TearDown(bannerTableName, bannerRelationTableName)
+
+	// arrange
+	_, err := createBanner(bannermodels.Banner{TagIDs: []int{453}, FeatureID: 453, Content: testContentObj, IsActive: false})
+	if err != nil {
+		log.Panic(err)
+	}
+	url := bannerGetUserURL + fmt.Sprintf("?tag_id=%d&feature_id=%d", 453, 453)
+
+	// act
+	resp := userBannerRequest(t, url, adminToken, "")
+	defer resp.Body.Close()
+
+	// assert
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "private, no-cache", resp.Header.Get("Cache-Control"))
+}
@@ -0,0 +1,226 @@
+package tests
+
+import (
+	bannermodels "banner/internal/models/banner"
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	bannerExportURL = baseURL + "/banner/export"
+	bannerImportURL = baseURL + "/banner/import"
+)
+
+func TestExportBanners(t *testing.T) {
+	db.SetUp(t, bannerTableName, bannerRelationTableName)
+	defer db.TearDown(bannerTableName, bannerRelationTableName)
+
+	// arrange
+	banners := []bannermodels.Banner{
+		{FeatureID: 1, TagIDs: []int{1, 2}, IsActive: true, Content: testContentObj},
+		{FeatureID: 2, TagIDs: []int{1}, IsActive: false, Content: testContentObj},
+		{FeatureID: 1, TagIDs: []int{3}, IsActive: true, Content: testContentObj},
+	}
+
+	bannersCreated, err := createBunners(banners)
+	if err != nil {
+		log.Panic(err)
+	}
+
+	client, req, err := makeClientRequest(http.MethodGet, bannerExportURL+"?feature_id=1&sort=id&order=asc", nil)
+	if err != nil {
+		log.Panic(err)
+	}
+
+	// act
+	resp, err := client.Do(req)
+
+	// assert
+	require.NoError(t, err, err)
+	defer resp.Body.Close()
+
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	var exported []bannermodels.Banner
+	scanner := bufio.NewScanner(resp.Body)
+	for scanner.Scan() {
+		var b bannermodels.Banner
+		err = json.Unmarshal(scanner.Bytes(), &b)
+		require.NoError(t, err, scanner.Text())
+		exported = append(exported, b)
+	}
+	require.NoError(t, scanner.Err())
+
+	require.Equal(t, 2, len(exported), exported)
+	compareBanners(t, bannersCreated[0], exported[0])
+	compareBanners(t, bannersCreated[2], exported[1])
+}
+
+func TestImportBanners(t *testing.T) {
+	db.SetUp(t, bannerTableName, bannerRelationTableName)
+	defer db.TearDown(bannerTableName, bannerRelationTableName)
+
+	// arrange
+	existing, err := createBanner(bannermodels.Banner{
+		FeatureID: 1,
+		TagIDs:    []int{1},
+		IsActive:  true,
+		Content:   testContentObj,
+	})
+	if err != nil {
+		log.Panic(err)
+	}
+
+	newContentObj := map[string]interface{}{"title": "imported"}
+	lines := []bannermodels.Banner{
+		{FeatureID: 1, TagIDs: []int{1}, IsActive: false, Content: newContentObj},
+		{FeatureID: 2, TagIDs: []int{1, 2}, IsActive: true, Content: newContentObj},
+	}
+
+	var body bytes.Buffer
+	enc := json.NewEncoder(&body)
+	for _, b := range lines {
+		err = enc.Encode(b)
+		if err != nil {
+			log.Panic(err)
+		}
+	}
+
+	testCases := []struct {
+		name           string
+		query          string
+		expectedStatus int
+		expected       bannermodels.ImportReport
+	}{
+		{
+			name:           "dry run",
+			query:          "?on_conflict=upsert&dry_run=true",
+			expectedStatus: http.StatusOK,
+			expected:       bannermodels.ImportReport{DryRun: true, Created: 1, Updated: 1},
+		},
+		{
+			name:           "upsert",
+			query:          "?on_conflict=upsert",
+			expectedStatus: http.StatusOK,
+			expected:       bannermodels.ImportReport{Applied: true, Created: 1, Updated: 1},
+		},
+	}
+
+	t.Run("fail on conflict", func(t *testing.T) {
+		client, req, err := makeClientRequest(http.MethodPost, bannerImportURL+"?on_conflict=fail", bytes.NewReader(body.Bytes()))
+		if err != nil {
+			log.Panic(err)
+		}
+
+		// act
+		resp, err := client.Do(req)
+
+		// assert
+		require.NoError(t, err, err)
+		require.Equal(t, http.StatusConflict, resp.StatusCode)
+
+		errResp := readErrorResponse(t, resp)
+		assert.Equal(t, "SLOT_CONFLICT", errResp.Error.Code)
+		require.Len(t, errResp.Error.Details, 1)
+		assert.Equal(t, fmt.Sprintf("line 1: slots are taken by banners %d", existing.ID), errResp.Error.Details[0].Message)
+	})
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			client, req, err := makeClientRequest(http.MethodPost, bannerImportURL+tc.query, bytes.NewReader(body.Bytes()))
+			if err != nil {
+				log.Panic(err)
+			}
+
+			// act
+			resp, err := client.Do(req)
+
+			// assert
+			require.NoError(t, err, err)
+
+			resultBytes, err := io.ReadAll(resp.Body)
+			require.NoError(t, err, err)
+
+			require.Equal(t, tc.expectedStatus, resp.StatusCode, string(resultBytes))
+
+			var report bannermodels.ImportReport
+			err = json.Unmarshal(resultBytes, &report)
+			require.NoError(t, err, err)
+
+			assert.Equal(t, tc.expected.DryRun, report.DryRun)
+			assert.Equal(t, tc.expected.Applied, report.Applied)
+			assert.Equal(t, tc.expected.Created, report.Created)
+			assert.Equal(t, tc.expected.Updated, report.Updated)
+			assert.Equal(t, tc.expected.Conflicts, report.Conflicts)
+		})
+	}
+
+	bannerInDB, err := getBannerByID(existing.ID)
+	require.NoError(t, err, err)
+
+	assert.Equal(t, false, bannerInDB.IsActive)
+	assert.Equal(t, newContentObj, bannerInDB.Content)
+}
+
+func TestImportBannersBadLine(t *testing.T) {
+	for name, line := range map[string]string{
+		"no feature":    `{"tag_ids": [1], "content": {"title": "a"}}`,
+		"zero feature":  `{"feature_id": 0, "tag_ids": [1], "content": {"title": "a"}}`,
+		"no tags":       `{"feature_id": 1, "content": {"title": "a"}}`,
+		"empty tags":    `{"feature_id": 1, "tag_ids": [], "content": {"title": "a"}}`,
+		"negative tag":  `{"feature_id": 1, "tag_ids": [-1], "content": {"title": "a"}}`,
+		"no content":    `{"feature_id": 1, "tag_ids": [1]}`,
+		"not an object": `[1]`,
+	} {
+		t.Run(name, func(t *testing.T) {
+			client, req, err := makeClientRequest(http.MethodPost, bannerImportURL, bytes.NewBufferString(line+"\n"))
+			if err != nil {
+				log.Panic(err)
+			}
+
+			// act
+			resp, err := client.Do(req)
+			require.NoError(t, err, err)
+
+			// assert
+			assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+			assert.Equal(t, "VALIDATION_FAILED", readErrorResponse(t, resp).Error.Code)
+		})
+	}
+}
+
+func TestImportBannersLineNumbers(t *testing.T) {
+	db.SetUp(t, bannerTableName, bannerRelationTableName)
+	defer db.TearDown(bannerTableName, bannerRelationTableName)
+
+	// arrange, blank line is counted and two objects on one line are rejected
+	body := `{"feature_id": 471, "tag_ids": [1], "content": {"title": "a"}}` + "\n\n" +
+		`{"feature_id": 472, "tag_ids": [1], "content": {"title": "a"}} {"feature_id": 473, "tag_ids": [1], "content": {"title": "a"}}` + "\n"
+
+	client, req, err := makeClientRequest(http.MethodPost, bannerImportURL, bytes.NewBufferString(body))
+	if err != nil {
+		log.Panic(err)
+	}
+
+	// act
+	resp, err := client.Do(req)
+	require.NoError(t, err, err)
+
+	// assert
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+
+	errResp := readErrorResponse(t, resp)
+	assert.Equal(t, "VALIDATION_FAILED", errResp.Error.Code)
+	require.Len(t, errResp.Error.Details, 1)
+	assert.True(t, strings.HasPrefix(errResp.Error.Details[0].Message, "line 3:"), errResp.Error.Details[0].Message)
+}
@@ -0,0 +1,107 @@
+package tests
+
+import (
+	bannermodels "banner/internal/models/banner"
+	"encoding/json"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBannerListCursor(t *testing.T) {
+	db.SetUp(t, bannerTableName, bannerRelationTableName)
+	defer db.TearDown(bannerTableName, bannerRelationTableName)
+
+	// arrange
+	banners := make([]bannermodels.Banner, 5)
+	for i := range banners {
+		banners[i] = bannermodels.Banner{
+			FeatureID: i + 1,
+			TagIDs:    []int{1},
+			IsActive:  i%2 == 0,
+			Content:   testContentObj,
+		}
+	}
+
+	bannersCreated, err := createBunners(banners)
+	if err != nil {
+		log.Panic(err)
+	}
+
+	query := url.Values{
+		"sort":       {"id"},
+		"order":      {"asc"},
+		"is_active":  {"true"},
+		"limit":      {"2"},
+		"with_total": {"true"},
+		"cursor":     {""},
+	}
+
+	var resultBanners []bannermodels.Banner
+	for {
+		client, req, err := makeClientRequest(http.MethodGet, bannerListURL+"?"+query.Encode(), nil)
+		if err != nil {
+			log.Panic(err)
+		}
+
+		// act
+		resp, err := client.Do(req)
+
+		// assert
+		require.NoError(t, err, err)
+
+		resultBytes, err := io.ReadAll(resp.Body)
+		require.NoError(t, err, err)
+
+		require.Equal(t, http.StatusOK, resp.StatusCode, string(resultBytes))
+
+		var page bannermodels.BannerPage
+		err = json.Unmarshal(resultBytes, &page)
+		require.NoError(t, err, err)
+
+		require.NotNil(t, page.Total)
+		assert.Equal(t, 3, *page.Total)
+
+		resultBanners = append(resultBanners, page.Items...)
+
+		if page.NextCursor == nil {
+			break
+		}
+		query.Set("cursor", *page.NextCursor)
+	}
+
+	require.Equal(t, 3, len(resultBanners), resultBanners)
+	compareBanners(t, bannersCreated[0], resultBanners[0])
+	compareBanners(t, bannersCreated[2], resultBanners[1])
+	compareBanners(t, bannersCreated[4], resultBanners[2])
+}
+
+func TestBannerListCursorBadArgs(t *testing.T) {
+	queries := []string{
+		"?cursor=&offset=1",
+		"?cursor=&limit=0",
+		"?cursor=not-a-cursor",
+		"?sort=name",
+		"?order=up",
+		"?with_total=true",
+		"?created_from=yesterday",
+	}
+
+	for _, query := range queries {
+		client, req, err := makeClientRequest(http.MethodGet, bannerListURL+query, nil)
+		if err != nil {
+			log.Panic(err)
+		}
+
+		resp, err := client.Do(req)
+		require.NoError(t, err, err)
+		resp.Body.Close()
+
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode, query)
+	}
+}
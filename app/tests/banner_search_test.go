@@ -0,0 +1,135 @@
+package tests
+
+import (
+	bannermodels "banner/internal/models/banner"
+	"encoding/json"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBannerListContentSearch(t *testing.T) {
+	db.SetUp(t, bannerTableName, bannerRelationTableName)
+	defer db.TearDown(bannerTableName, bannerRelationTableName)
+
+	// arrange
+	banners := []bannermodels.Banner{
+		{
+			FeatureID: 1,
+			TagIDs:    []int{1},
+			IsActive:  true,
+			Content: map[string]interface{}{
+				"title": "spring sale",
+				"url":   "https://old-domain.com/sale",
+			},
+		},
+		{
+			FeatureID: 1,
+			TagIDs:    []int{2},
+			IsActive:  true,
+			Content: map[string]interface{}{
+				"title": "winter sale",
+				"url":   "https://new-domain.com/sale",
+				"text":  "line one\nline two",
+			},
+		},
+		{
+			FeatureID: 2,
+			TagIDs:    []int{1},
+			IsActive:  true,
+			Content: map[string]interface{}{
+				"title": "spring promo",
+				"url":   "https://old-domain.com/promo",
+			},
+		},
+	}
+
+	bannersCreated, err := createBunners(banners)
+	if err != nil {
+		log.Panic(err)
+	}
+
+	testCases := []struct {
+		name     string
+		query    url.Values
+		expected []bannermodels.Banner
+	}{
+		{
+			name:     "full-text",
+			query:    url.Values{"q": {"spring"}},
+			expected: []bannermodels.Banner{bannersCreated[2], bannersCreated[0]},
+		},
+		{
+			name:     "path contains",
+			query:    url.Values{"content.url[contains]": {"OLD-DOMAIN"}},
+			expected: []bannermodels.Banner{bannersCreated[2], bannersCreated[0]},
+		},
+		{
+			name:     "path contains control character",
+			query:    url.Values{"content.text[contains]": {"one\nline"}},
+			expected: []bannermodels.Banner{bannersCreated[1]},
+		},
+		{
+			name:     "path equals",
+			query:    url.Values{"content.title": {"winter sale"}},
+			expected: []bannermodels.Banner{bannersCreated[1]},
+		},
+		{
+			name: "combined with feature",
+			query: url.Values{
+				"feature_id":            {"1"},
+				"content.url[contains]": {"old-domain"},
+			},
+			expected: []bannermodels.Banner{bannersCreated[0]},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			client, req, err := makeClientRequest(http.MethodGet, bannerListURL+"?"+tc.query.Encode(), nil)
+			if err != nil {
+				log.Panic(err)
+			}
+
+			// act
+			resp, err := client.Do(req)
+
+			// assert
+			require.NoError(t, err, err)
+
+			resultBytes, err := io.ReadAll(resp.Body)
+			require.NoError(t, err, err)
+
+			require.Equal(t, http.StatusOK, resp.StatusCode, string(resultBytes))
+
+			var resultBanners []bannermodels.Banner
+			err = json.Unmarshal(resultBytes, &resultBanners)
+			require.NoError(t, err, err)
+
+			require.Equal(t, len(tc.expected), len(resultBanners), resultBanners)
+			for i := range tc.expected {
+				compareBanners(t, tc.expected[i], resultBanners[i])
+			}
+		})
+	}
+}
+
+func TestBannerListContentSearchBadArgs(t *testing.T) {
+	for _, query := range []string{"?q=%20", "?content..url=x", "?content.url[contains]="} {
+		client, req, err := makeClientRequest(http.MethodGet, bannerListURL+query, nil)
+		if err != nil {
+			log.Panic(err)
+		}
+
+		resp, err := client.Do(req)
+		require.NoError(t, err, err)
+		resp.Body.Close()
+
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode, query)
+	}
+}
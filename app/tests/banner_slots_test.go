@@ -0,0 +1,315 @@
+package tests
+
+import (
+	bannermodels "banner/internal/models/banner"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const slotsURL = baseURL + "/slots?feature_id=%d"
+
+func TestCreateBannerSlotConflict(t *testing.T) {
+	db.SetUp(t, bannerTableName, bannerRelationTableName)
+	defer db.TearDown(bannerTableName, bannerRelationTableName)
+
+	// arrange
+	holders, err := createBunners([]bannermodels.Banner{
+		{TagIDs: []int{1, 2}, FeatureID: 1, Content: testContentObj, IsActive: true},
+		{TagIDs: []int{3}, FeatureID: 1, Content: testContentObj, IsActive: true},
+		{TagIDs: []int{4}, FeatureID: 2, Content: testContentObj, IsActive: true},
+	})
+	if err != nil {
+		log.Panic(err)
+	}
+
+	body, err := json.Marshal(bannermodels.BannerRequest{
+		TagIDs:    []int{2, 3, 4, 5},
+		FeatureID: 1,
+		Content:   testContentObj,
+		IsActive:  true,
+	})
+	if err != nil {
+		log.Panic(err)
+	}
+
+	client, req, err := makeClientRequest(http.MethodPost, bannerCreateURL, bytes.NewBuffer(body))
+	if err != nil {
+		log.Panic(err)
+	}
+
+	// act
+	resp, err := client.Do(req)
+
+	// assert
+	require.NoError(t, err, err)
+	defer resp.Body.Close()
+
+	require.Equal(t, http.StatusConflict, resp.StatusCode)
+
+	var errResp struct {
+		Error struct {
+			Code      string              `json:"code"`
+			Conflicts []bannermodels.Slot `json:"conflicts"`
+		} `json:"error"`
+	}
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&errResp))
+
+	assert.Equal(t, "SLOT_CONFLICT", errResp.Error.Code)
+	assert.Equal(t, []bannermodels.Slot{
+		{TagID: 2, FeatureID: 1, BannerID: holders[0].ID},
+		{TagID: 3, FeatureID: 1, BannerID: holders[1].ID},
+	}, errResp.Error.Conflicts)
+}
+
+func TestUpdateBannerSlotConflict(t *testing.T) {
+	db.SetUp(t, bannerTableName, bannerRelationTableName)
+	defer db.TearDown(bannerTableName, bannerRelationTableName)
+
+	// arrange
+	banners, err := createBunners([]bannermodels.Banner{
+		{TagIDs: []int{1, 2}, FeatureID: 1, Content: testContentObj, IsActive: true},
+		{TagIDs: []int{3}, FeatureID: 1, Content: testContentObj, IsActive: true},
+	})
+	if err != nil {
+		log.Panic(err)
+	}
+
+	// banner keeps its own tag 3, tag 2 is taken
+	url := fmt.Sprintf(bannerUpdateURL, banners[1].ID)
+	client, req, err := makeClientRequest(http.MethodPatch, url, bytes.NewBufferString(`{"tag_ids": [2, 3]}`))
+	if err != nil {
+		log.Panic(err)
+	}
+
+	// act
+	resp, err := client.Do(req)
+
+	// assert
+	require.NoError(t, err, err)
+	defer resp.Body.Close()
+
+	require.Equal(t, http.StatusConflict, resp.StatusCode)
+
+	var errResp struct {
+		Error struct {
+			Conflicts []bannermodels.Slot `json:"conflicts"`
+		} `json:"error"`
+	}
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&errResp))
+
+	assert.Equal(t, []bannermodels.Slot{
+		{TagID: 2, FeatureID: 1, BannerID: banners[0].ID},
+	}, errResp.Error.Conflicts)
+}
+
+func TestFeatureSlots(t *testing.T) {
+	db.SetUp(t, bannerTableName, bannerRelationTableName)
+	defer db.TearDown(bannerTableName, bannerRelationTableName)
+
+	// arrange
+	banners, err := createBunners([]bannermodels.Banner{
+		{TagIDs: []int{3, 1}, FeatureID: 1, Content: testContentObj, IsActive: true},
+		{TagIDs: []int{2}, FeatureID: 1, Content: testContentObj, IsActive: false},
+		{TagIDs: []int{1}, FeatureID: 2, Content: testContentObj, IsActive: true},
+	})
+	if err != nil {
+		log.Panic(err)
+	}
+
+	client, req, err := makeClientRequest(http.MethodGet, fmt.Sprintf(slotsURL, 1), nil)
+	if err != nil {
+		log.Panic(err)
+	}
+
+	// act
+	resp, err := client.Do(req)
+
+	// assert
+	require.NoError(t, err, err)
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err, err)
+	require.Equal(t, http.StatusOK, resp.StatusCode, string(body))
+
+	var slots []bannermodels.Slot
+	require.NoError(t, json.Unmarshal(body, &slots), string(body))
+
+	assert.Equal(t, []bannermodels.Slot{
+		{TagID: 1, FeatureID: 1, BannerID: banners[0].ID},
+		{TagID: 2, FeatureID: 1, BannerID: banners[1].ID},
+		{TagID: 3, FeatureID: 1, BannerID: banners[0].ID},
+	}, slots)
+}
+
+const slotsTransferURL = baseURL + "/slots/transfer"
+
+func transferSlots(t *testing.T, body string) *http.Response {
+	client, req, err := makeClientRequest(http.MethodPost, slotsTransferURL, bytes.NewBufferString(body))
+	if err != nil {
+		log.Panic(err)
+	}
+
+	resp, err := client.Do(req)
+	require.NoError(t, err, err)
+	return resp
+}
+
+func TestTransferSlotsMove(t *testing.T) {
+	db.SetUp(t, bannerTableName, bannerRelationTableName)
+	defer db.TearDown(bannerTableName, bannerRelationTableName)
+
+	// arrange
+	banners, err := createBunners([]bannermodels.Banner{
+		{TagIDs: []int{1, 5}, FeatureID: 1, Content: map[string]interface{}{"title": "a"}, IsActive: true},
+		{TagIDs: []int{2}, FeatureID: 1, Content: map[string]interface{}{"title": "b"}, IsActive: true},
+	})
+	if err != nil {
+		log.Panic(err)
+	}
+
+	// banner a gets to cache for slot (5, 1)
+	client, req, err := makeClientRequest(http.MethodGet, bannerGetUserURL+"?tag_id=5&feature_id=1", nil)
+	if err != nil {
+		log.Panic(err)
+	}
+	resp, err := client.Do(req)
+	require.NoError(t, err, err)
+	resp.Body.Close()
+
+	// act
+	resp = transferSlots(t, fmt.Sprintf(
+		`{"from_banner_id": %d, "to_banner_id": %d, "tag_ids": [5], "mode": "move"}`,
+		banners[0].ID, banners[1].ID,
+	))
+
+	// assert
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	from, err := getBannerByID(banners[0].ID)
+	require.NoError(t, err, err)
+	to, err := getBannerByID(banners[1].ID)
+	require.NoError(t, err, err)
+
+	assert.ElementsMatch(t, []int{1}, from.TagIDs)
+	assert.ElementsMatch(t, []int{2, 5}, to.TagIDs)
+
+	// cached banner of moved slot is dropped
+	client, req, err = makeClientRequest(http.MethodGet, bannerGetUserURL+"?tag_id=5&feature_id=1", nil)
+	if err != nil {
+		log.Panic(err)
+	}
+	resp, err = client.Do(req)
+	require.NoError(t, err, err)
+	defer resp.Body.Close()
+
+	var content map[string]interface{}
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&content))
+	assert.Equal(t, "b", content["title"])
+}
+
+func TestTransferSlotsSwap(t *testing.T) {
+	db.SetUp(t, bannerTableName, bannerRelationTableName)
+	defer db.TearDown(bannerTableName, bannerRelationTableName)
+
+	// arrange
+	banners, err := createBunners([]bannermodels.Banner{
+		{TagIDs: []int{1, 2}, FeatureID: 1, Content: testContentObj, IsActive: true},
+		{TagIDs: []int{3, 4}, FeatureID: 1, Content: testContentObj, IsActive: true},
+	})
+	if err != nil {
+		log.Panic(err)
+	}
+
+	// act
+	resp := transferSlots(t, fmt.Sprintf(
+		`{"from_banner_id": %d, "to_banner_id": %d, "tag_ids": [2, 3], "mode": "swap"}`,
+		banners[0].ID, banners[1].ID,
+	))
+
+	// assert
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	var result bannermodels.TransferResult
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&result))
+	assert.ElementsMatch(t, []bannermodels.Slot{
+		{TagID: 2, FeatureID: 1, BannerID: banners[1].ID},
+		{TagID: 3, FeatureID: 1, BannerID: banners[0].ID},
+	}, result.Slots)
+
+	from, err := getBannerByID(banners[0].ID)
+	require.NoError(t, err, err)
+	to, err := getBannerByID(banners[1].ID)
+	require.NoError(t, err, err)
+
+	assert.ElementsMatch(t, []int{1, 3}, from.TagIDs)
+	assert.ElementsMatch(t, []int{2, 4}, to.TagIDs)
+}
+
+func TestTransferSlotsErrors(t *testing.T) {
+	db.SetUp(t, bannerTableName, bannerRelationTableName)
+	defer db.TearDown(bannerTableName, bannerRelationTableName)
+
+	banners, err := createBunners([]bannermodels.Banner{
+		{TagIDs: []int{1}, FeatureID: 1, Content: testContentObj, IsActive: true},
+		{TagIDs: []int{2}, FeatureID: 1, Content: testContentObj, IsActive: true},
+		{TagIDs: []int{3}, FeatureID: 2, Content: testContentObj, IsActive: true},
+	})
+	if err != nil {
+		log.Panic(err)
+	}
+
+	testCases := []struct {
+		name           string
+		body           string
+		expectedStatus int
+		expectedCode   string
+	}{
+		{
+			name:           "tag of other banner",
+			body:           fmt.Sprintf(`{"from_banner_id": %d, "to_banner_id": %d, "tag_ids": [2]}`, banners[0].ID, banners[1].ID),
+			expectedStatus: http.StatusConflict,
+			expectedCode:   "SLOT_NOT_HELD",
+		},
+		{
+			name:           "other feature",
+			body:           fmt.Sprintf(`{"from_banner_id": %d, "to_banner_id": %d, "tag_ids": [1]}`, banners[0].ID, banners[2].ID),
+			expectedStatus: http.StatusConflict,
+			expectedCode:   "FEATURE_MISMATCH",
+		},
+		{
+			name:           "unknown banner",
+			body:           fmt.Sprintf(`{"from_banner_id": %d, "to_banner_id": %d, "tag_ids": [1]}`, banners[0].ID, banners[2].ID+100),
+			expectedStatus: http.StatusNotFound,
+			expectedCode:   "BANNER_NOT_FOUND",
+		},
+		{
+			name:           "same banner",
+			body:           fmt.Sprintf(`{"from_banner_id": %d, "to_banner_id": %d, "tag_ids": [1]}`, banners[0].ID, banners[0].ID),
+			expectedStatus: http.StatusBadRequest,
+			expectedCode:   "VALIDATION_FAILED",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			// act
+			resp := transferSlots(t, tc.body)
+
+			// assert
+			defer resp.Body.Close()
+			require.Equal(t, tc.expectedStatus, resp.StatusCode)
+			assert.Equal(t, tc.expectedCode, readErrorResponse(t, resp).Error.Code)
+		})
+	}
+}
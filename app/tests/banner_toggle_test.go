@@ -0,0 +1,156 @@
+package tests
+
+import (
+	bannermodels "banner/internal/models/banner"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const bannerToggleURL = baseURL + "/banner/toggle"
+
+func toggleBanners(t *testing.T, query string, body string) *http.Response {
+	t.Helper()
+
+	client, req, err := makeClientRequest(http.MethodPost, bannerToggleURL+query, bytes.NewBufferString(body))
+	if err != nil {
+		log.Panic(err)
+	}
+	resp, err := client.Do(req)
+	require.NoError(t, err)
+	return resp
+}
+
+func readToggleResult(t *testing.T, resp *http.Response) bannermodels.ToggleResult {
+	t.Helper()
+	defer resp.Body.Close()
+
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	var result bannermodels.ToggleResult
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&result))
+	return result
+}
+
+// status of /user_banner for regular user, served from cache if it is there
+func userBannerStatus(t *testing.T, tagID int, featureID int) int {
+	t.Helper()
+
+	client, req, err := makeClientRequest(
+		http.MethodGet,
+		fmt.Sprintf("%s?tag_id=%d&feature_id=%d", bannerGetUserURL, tagID, featureID),
+		nil,
+	)
+	if err != nil {
+		log.Panic(err)
+	}
+	req.Header.Set(tokenHeaderName, userToken)
+
+	resp, err := client.Do(req)
+	require.NoError(t, err)
+	resp.Body.Close()
+	return resp.StatusCode
+}
+
+func TestToggleBannersByTags(t *testing.T) {
+	db.SetUp(t, bannerTableName, bannerRelationTableName)
+	defer db.TearDown(bannerTableName, bannerRelationTableName)
+
+	// arrange
+	banners, err := createBunners([]bannermodels.Banner{
+		{TagIDs: []int{1, 2}, FeatureID: 1, Content: testContentObj, IsActive: true},
+		{TagIDs: []int{3}, FeatureID: 1, Content: testContentObj, IsActive: true},
+		{TagIDs: []int{1}, FeatureID: 2, Content: testContentObj, IsActive: true},
+		{TagIDs: []int{2}, FeatureID: 2, Content: testContentObj, IsActive: false},
+	})
+	if err != nil {
+		log.Panic(err)
+	}
+
+	// act: dry run changes nothing
+	dryRun := readToggleResult(t, toggleBanners(t, "?dry_run=true", `{"tag_ids": [1, 2], "is_active": false}`))
+
+	// assert
+	assert.True(t, dryRun.DryRun)
+	assert.Equal(t, []int{banners[0].ID, banners[2].ID}, dryRun.BannerIDs)
+
+	b, err := getBannerByID(banners[0].ID)
+	require.NoError(t, err)
+	assert.True(t, b.IsActive)
+
+	// act
+	result := readToggleResult(t, toggleBanners(t, "", `{"tag_ids": [1, 2], "is_active": false}`))
+
+	// assert: banner already inactive and banner of other tags are not touched
+	assert.False(t, result.DryRun)
+	assert.Equal(t, []int{banners[0].ID, banners[2].ID}, result.BannerIDs)
+
+	for i, active := range []bool{false, true, false, false} {
+		b, err := getBannerByID(banners[i].ID)
+		require.NoError(t, err)
+		assert.Equal(t, active, b.IsActive, "banner %d", i)
+	}
+}
+
+func TestToggleKillSwitch(t *testing.T) {
+	db.SetUp(t, bannerTableName, bannerRelationTableName)
+	defer db.TearDown(bannerTableName, bannerRelationTableName)
+
+	// arrange: banner is cached while active
+	banners, err := createBunners([]bannermodels.Banner{
+		{TagIDs: []int{1, 2}, FeatureID: 1, Content: testContentObj, IsActive: true},
+		{TagIDs: []int{3}, FeatureID: 1, Content: testContentObj, IsActive: true},
+		{TagIDs: []int{1}, FeatureID: 2, Content: testContentObj, IsActive: true},
+	})
+	if err != nil {
+		log.Panic(err)
+	}
+	require.Equal(t, http.StatusOK, userBannerStatus(t, 1, 1))
+
+	// act
+	result := readToggleResult(t, toggleBanners(t, "", `{"feature_id": 1, "kill_switch": true}`))
+
+	// assert: feature is not served right away, other features are
+	assert.Equal(t, []int{banners[0].ID, banners[1].ID}, result.BannerIDs)
+	assert.False(t, result.IsActive)
+
+	assert.Equal(t, http.StatusNotFound, userBannerStatus(t, 1, 1))
+	assert.Equal(t, http.StatusNotFound, userBannerStatus(t, 3, 1))
+	assert.Equal(t, http.StatusOK, userBannerStatus(t, 1, 2))
+}
+
+func TestToggleBannersValidation(t *testing.T) {
+	tests := []struct {
+		name   string
+		body   string
+		fields []string
+	}{
+		{"no filter and state", `{}`, []string{"feature_id", "is_active"}},
+		{"kill switch without feature", `{"kill_switch": true}`, []string{"feature_id"}},
+		{"kill switch with tags and activation", `{"kill_switch": true, "feature_id": 1, "tag_ids": [1], "is_active": true}`, []string{"tag_ids", "is_active"}},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			resp := toggleBanners(t, "", tc.body)
+			defer resp.Body.Close()
+
+			require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+
+			errResp := readErrorResponse(t, resp)
+			assert.Equal(t, "VALIDATION_FAILED", errResp.Error.Code)
+
+			fields := make([]string, len(errResp.Error.Details))
+			for i, detail := range errResp.Error.Details {
+				fields[i] = detail.Field
+			}
+			assert.Equal(t, tc.fields, fields)
+		})
+	}
+}
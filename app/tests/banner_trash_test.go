@@ -0,0 +1,195 @@
+package tests
+
+import (
+	bannermodels "banner/internal/models/banner"
+	"banner/internal/repo"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	bannerRestoreURL = baseURL + "/banner/%d/restore"
+	trashListURL     = baseURL + "/banner?trashed=true&feature_id=%d"
+
+	stmtGetBannerDeleted = `
+	SELECT deleted_at, deleted_by FROM banner WHERE id = $1;
+	`
+
+	stmtCountBannerRelations = `
+	SELECT COUNT(*) FROM banner_relation WHERE banner_id = $1;
+	`
+
+	stmtBackdateTrash = `
+	UPDATE banner SET deleted_at = deleted_at - $2::interval WHERE id = $1;
+	`
+)
+
+type noopQueryObserver struct{}
+
+func (noopQueryObserver) ObserveQuery(string, time.Duration) {}
+
+func deleteBanner(t *testing.T, id int) {
+	t.Helper()
+
+	client, req, err := makeClientRequest(http.MethodDelete, fmt.Sprintf(bannerDeleteURL, id), nil)
+	if err != nil {
+		log.Panic(err)
+	}
+	resp, err := client.Do(req)
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusNoContent, resp.StatusCode)
+}
+
+func restoreBanner(t *testing.T, id int) *http.Response {
+	t.Helper()
+
+	client, req, err := makeClientRequest(http.MethodPost, fmt.Sprintf(bannerRestoreURL, id), nil)
+	if err != nil {
+		log.Panic(err)
+	}
+	resp, err := client.Do(req)
+	require.NoError(t, err)
+	return resp
+}
+
+func TestTrashListAndRestore(t *testing.T) {
+	db.SetUp(t, bannerTableName, bannerRelationTableName)
+	defer db.TearDown(bannerTableName, bannerRelationTableName)
+
+	// arrange
+	banners, err := createBunners([]bannermodels.Banner{
+		{TagIDs: []int{1, 2}, FeatureID: 1, Content: testContentObj, IsActive: true},
+		{TagIDs: []int{3}, FeatureID: 1, Content: testContentObj, IsActive: true},
+	})
+	if err != nil {
+		log.Panic(err)
+	}
+	deleteBanner(t, banners[0].ID)
+
+	// act, assert: trash has only deleted banner, live list has only the other one
+	var trashed []bannermodels.Banner
+	getJSON(t, fmt.Sprintf(trashListURL, 1), &trashed)
+	require.Len(t, trashed, 1)
+	assert.Equal(t, banners[0].ID, trashed[0].ID)
+	assert.Equal(t, []int{1, 2}, trashed[0].TagIDs)
+	require.NotNil(t, trashed[0].DeletedAt)
+
+	var live []bannermodels.Banner
+	getJSON(t, bannerListURL+"?feature_id=1", &live)
+	require.Len(t, live, 1)
+	assert.Equal(t, banners[1].ID, live[0].ID)
+
+	// user does not see banner in trash
+	client, req, err := makeClientRequest(http.MethodGet, fmt.Sprintf("%s?tag_id=1&feature_id=1&use_last_revision=true", bannerGetUserURL), nil)
+	if err != nil {
+		log.Panic(err)
+	}
+	resp, err := client.Do(req)
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+
+	// restore returns banner to its slots
+	resp = restoreBanner(t, banners[0].ID)
+	resp.Body.Close()
+	require.Equal(t, http.StatusNoContent, resp.StatusCode)
+
+	var relations int
+	err = db.DB.QueryRow(context.Background(), stmtCountBannerRelations, banners[0].ID).Scan(&relations)
+	require.NoError(t, err)
+	assert.Equal(t, 2, relations)
+
+	getJSON(t, fmt.Sprintf(trashListURL, 1), &trashed)
+	assert.Empty(t, trashed)
+
+	// banner is not in trash anymore
+	resp = restoreBanner(t, banners[0].ID)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+}
+
+func TestRestoreBannerSlotConflict(t *testing.T) {
+	db.SetUp(t, bannerTableName, bannerRelationTableName)
+	defer db.TearDown(bannerTableName, bannerRelationTableName)
+
+	// arrange: slot 2 of deleted banner is taken by new one
+	banners, err := createBunners([]bannermodels.Banner{
+		{TagIDs: []int{1, 2}, FeatureID: 1, Content: testContentObj, IsActive: true},
+	})
+	if err != nil {
+		log.Panic(err)
+	}
+	deleteBanner(t, banners[0].ID)
+
+	holder, err := createBanner(bannermodels.Banner{TagIDs: []int{2}, FeatureID: 1, Content: testContentObj, IsActive: true})
+	if err != nil {
+		log.Panic(err)
+	}
+
+	// act
+	resp := restoreBanner(t, banners[0].ID)
+	defer resp.Body.Close()
+
+	// assert
+	require.Equal(t, http.StatusConflict, resp.StatusCode)
+
+	var errResp struct {
+		Error struct {
+			Code      string              `json:"code"`
+			Conflicts []bannermodels.Slot `json:"conflicts"`
+		} `json:"error"`
+	}
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&errResp))
+	assert.Equal(t, "SLOT_CONFLICT", errResp.Error.Code)
+	assert.Equal(t, []bannermodels.Slot{{TagID: 2, FeatureID: 1, BannerID: holder.ID}}, errResp.Error.Conflicts)
+
+	// banner stays in trash
+	var deletedAt *time.Time
+	var deletedBy *string
+	err = db.DB.QueryRow(context.Background(), stmtGetBannerDeleted, banners[0].ID).Scan(&deletedAt, &deletedBy)
+	require.NoError(t, err)
+	assert.NotNil(t, deletedAt)
+}
+
+func TestPurgeTrash(t *testing.T) {
+	db.SetUp(t, bannerTableName, bannerRelationTableName)
+	defer db.TearDown(bannerTableName, bannerRelationTableName)
+
+	// arrange: one banner is in trash for two days, the other one is just deleted
+	banners, err := createBunners([]bannermodels.Banner{
+		{TagIDs: []int{1}, FeatureID: 1, Content: testContentObj, IsActive: true},
+		{TagIDs: []int{2}, FeatureID: 1, Content: testContentObj, IsActive: true},
+		{TagIDs: []int{3}, FeatureID: 1, Content: testContentObj, IsActive: true},
+	})
+	if err != nil {
+		log.Panic(err)
+	}
+	deleteBanner(t, banners[0].ID)
+	deleteBanner(t, banners[1].ID)
+
+	_, err = db.DB.Exec(context.Background(), stmtBackdateTrash, banners[0].ID, 48*time.Hour)
+	require.NoError(t, err)
+
+	// act
+	purged, err := repo.NewBannerRepo(db.DB, noopQueryObserver{}).PurgeTrash(context.Background(), 24*time.Hour)
+
+	// assert
+	require.NoError(t, err)
+	assert.Equal(t, 1, purged)
+
+	_, err = getBannerByID(banners[0].ID)
+	assert.Error(t, err)
+	_, err = getBannerByID(banners[1].ID)
+	assert.NoError(t, err)
+	_, err = getBannerByID(banners[2].ID)
+	assert.NoError(t, err)
+}
@@ -5,8 +5,11 @@ import (
 	"encoding/json"
 	"io"
 	"net/http"
+	"testing"
 
 	bannermodels "banner/internal/models/banner"
+
+	"github.com/stretchr/testify/require"
 )
 
 const (
@@ -150,3 +153,18 @@ func createBunners(banners []bannermodels.Banner) ([]bannermodels.Banner, error)
 	}
 	return result, nil
 }
+
+// GET url as admin and decode successful JSON response to dest
+func getJSON(t *testing.T, url string, dest interface{}) {
+	t.Helper()
+
+	client, req, err := makeClientRequest(http.MethodGet, url, nil)
+	require.NoError(t, err)
+
+	resp, err := client.Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(dest))
+}
@@ -0,0 +1,158 @@
+package tests
+
+import (
+	bannermodels "banner/internal/models/banner"
+	"compress/gzip"
+	"encoding/json"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vmihailenco/msgpack/v5"
+)
+
+func TestBannerListGzip(t *testing.T) {
+	db.SetUp(t, bannerTableName, bannerRelationTableName)
+	defer db.TearDown(bannerTableName, bannerRelationTableName)
+
+	// arrange, list is longer than compression threshold
+	for i := 1; i <= 20; i++ {
+		_, err := createBanner(bannermodels.Banner{TagIDs: []int{i}, FeatureID: 461, Content: testContentObj, IsActive: true})
+		if err != nil {
+			log.Panic(err)
+		}
+	}
+
+	client, req, err := makeClientRequest(http.MethodGet, bannerListURL+"?feature_id=461", nil)
+	if err != nil {
+		log.Panic(err)
+	}
+	// set explicitly, so transport does not decompress body itself
+	req.Header.Set("Accept-Encoding", "gzip")
+
+	// act
+	resp, err := client.Do(req)
+
+	// assert
+	require.NoError(t, err, err)
+	defer resp.Body.Close()
+
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.Equal(t, "gzip", resp.Header.Get("Content-Encoding"))
+
+	reader, err := gzip.NewReader(resp.Body)
+	require.NoError(t, err, err)
+
+	var banners []bannermodels.Banner
+	require.NoError(t, json.NewDecoder(reader).Decode(&banners))
+	assert.Len(t, banners, 20)
+}
+
+func TestUserBannerMsgpack(t *testing.T) {
+	db.SetUp(t, bannerTableName, bannerRelationTableName)
+	defer db.TearDown(bannerTableName, bannerRelationTableName)
+
+	// arrange
+	_, err := createBanner(bannermodels.Banner{TagIDs: []int{462}, FeatureID: 462, Content: testContentObj, IsActive: true})
+	if err != nil {
+		log.Panic(err)
+	}
+
+	client, req, err := makeClientRequest(http.MethodGet, bannerGetUserURL+"?tag_id=462&feature_id=462", nil)
+	if err != nil {
+		log.Panic(err)
+	}
+	req.Header.Set("Accept", "application/msgpack")
+
+	// act
+	resp, err := client.Do(req)
+
+	// assert
+	require.NoError(t, err, err)
+	defer resp.Body.Close()
+
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.Equal(t, "application/msgpack", resp.Header.Get("Content-Type"))
+
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err, err)
+
+	var content map[string]interface{}
+	require.NoError(t, msgpack.Unmarshal(body, &content))
+	assert.Equal(t, testContentObj, content)
+}
+
+func TestUserBannerCompressedVary(t *testing.T) {
+	db.SetUp(t, bannerTableName, bannerRelationTableName)
+	defer db.TearDown(bannerTableName, bannerRelationTableName)
+
+	// arrange, content is longer than compression threshold
+	content := map[string]interface{}{"title": "some_title", "text": strings.Repeat("some_text ", 200)}
+	_, err := createBanner(bannermodels.Banner{TagIDs: []int{463}, FeatureID: 463, Content: content, IsActive: true})
+	if err != nil {
+		log.Panic(err)
+	}
+
+	client, req, err := makeClientRequest(http.MethodGet, bannerGetUserURL+"?tag_id=463&feature_id=463", nil)
+	if err != nil {
+		log.Panic(err)
+	}
+	req.Header.Set("Accept-Encoding", "gzip")
+
+	// act
+	resp, err := client.Do(req)
+
+	// assert
+	require.NoError(t, err, err)
+	defer resp.Body.Close()
+
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.Equal(t, "gzip", resp.Header.Get("Content-Encoding"))
+	assert.NotEmpty(t, resp.Header.Get("Cache-Control"))
+
+	vary := strings.Join(resp.Header.Values("Vary"), ", ")
+	assert.Contains(t, vary, "Accept-Encoding")
+	assert.Contains(t, vary, "token, Accept")
+}
+
+func TestBannerListMsgpackKeepsZeroFields(t *testing.T) {
+	db.SetUp(t, bannerTableName, bannerRelationTableName)
+	defer db.TearDown(bannerTableName, bannerRelationTableName)
+
+	// arrange
+	_, err := createBanner(bannermodels.Banner{TagIDs: []int{464}, FeatureID: 464, Content: testContentObj, IsActive: false})
+	if err != nil {
+		log.Panic(err)
+	}
+
+	client, req, err := makeClientRequest(http.MethodGet, bannerListURL+"?feature_id=464", nil)
+	if err != nil {
+		log.Panic(err)
+	}
+	req.Header.Set("Accept", "application/msgpack")
+
+	// act
+	resp, err := client.Do(req)
+
+	// assert
+	require.NoError(t, err, err)
+	defer resp.Body.Close()
+
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.Equal(t, "application/msgpack", resp.Header.Get("Content-Type"))
+
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err, err)
+
+	var banners []map[string]interface{}
+	require.NoError(t, msgpack.Unmarshal(body, &banners))
+	require.Len(t, banners, 1)
+
+	isActive, ok := banners[0]["is_active"]
+	require.True(t, ok, banners[0])
+	assert.Equal(t, false, isActive)
+}
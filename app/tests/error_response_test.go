@@ -0,0 +1,153 @@
+package tests
+
+import (
+	"encoding/json"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const userToken = "user_token"
+
+type errorResponse struct {
+	Error struct {
+		Code    string `json:"code"`
+		Message string `json:"message"`
+		Details []struct {
+			Field   string `json:"field"`
+			Message string `json:"message"`
+		} `json:"details"`
+		RequestID string `json:"request_id"`
+	} `json:"error"`
+}
+
+func readErrorResponse(t *testing.T, resp *http.Response) errorResponse {
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err, err)
+
+	var errResp errorResponse
+	require.NoError(t, json.Unmarshal(body, &errResp), string(body))
+	return errResp
+}
+
+func TestErrorBannerNotFound(t *testing.T) {
+	db.SetUp(t, bannerTableName, bannerRelationTableName)
+	defer db.TearDown(bannerTableName, bannerRelationTableName)
+
+	// arrange
+	url := bannerGetUserURL + "?tag_id=1&feature_id=1"
+
+	client, req, err := makeClientRequest(http.MethodGet, url, nil)
+	if err != nil {
+		log.Panic(err)
+	}
+	req.Header.Set(requestIDHeaderName, "not-found-request")
+
+	// act
+	resp, err := client.Do(req)
+
+	// assert
+	require.NoError(t, err, err)
+	defer resp.Body.Close()
+
+	require.Equal(t, http.StatusNotFound, resp.StatusCode)
+
+	errResp := readErrorResponse(t, resp)
+	assert.Equal(t, "BANNER_NOT_FOUND", errResp.Error.Code)
+	assert.Equal(t, "баннер не найден", errResp.Error.Message)
+	assert.Equal(t, "not-found-request", errResp.Error.RequestID)
+}
+
+func TestErrorValidationLocalized(t *testing.T) {
+	// arrange
+	url := bannerGetUserURL + "?tag_id=abc&feature_id=1"
+
+	client, req, err := makeClientRequest(http.MethodGet, url, nil)
+	if err != nil {
+		log.Panic(err)
+	}
+	req.Header.Set("Accept-Language", "en-US,en;q=0.9,ru;q=0.5")
+
+	// act
+	resp, err := client.Do(req)
+
+	// assert
+	require.NoError(t, err, err)
+	defer resp.Body.Close()
+
+	require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+
+	errResp := readErrorResponse(t, resp)
+	assert.Equal(t, "VALIDATION_FAILED", errResp.Error.Code)
+	assert.Equal(t, "request validation failed", errResp.Error.Message)
+	require.Len(t, errResp.Error.Details, 1)
+	assert.Equal(t, "tag_id", errResp.Error.Details[0].Field)
+	assert.Equal(t, "tag_id must be an integer", errResp.Error.Details[0].Message)
+}
+
+func TestErrorInvalidJSON(t *testing.T) {
+	// arrange
+	client, req, err := makeClientRequest(http.MethodPost, bannerCreateURL, strings.NewReader(`{"tag_ids": [1`))
+	if err != nil {
+		log.Panic(err)
+	}
+
+	// act
+	resp, err := client.Do(req)
+
+	// assert
+	require.NoError(t, err, err)
+	defer resp.Body.Close()
+
+	require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	assert.Equal(t, "INVALID_JSON", readErrorResponse(t, resp).Error.Code)
+}
+
+func TestErrorSlotConflict(t *testing.T) {
+	db.SetUp(t, bannerTableName, bannerRelationTableName)
+	defer db.TearDown(bannerTableName, bannerRelationTableName)
+
+	// arrange
+	body := `{"tag_ids": [1], "feature_id": 1, "content": {"title": "t"}, "is_active": true}`
+	for i, expectedStatus := range []int{http.StatusCreated, http.StatusConflict} {
+		client, req, err := makeClientRequest(http.MethodPost, bannerCreateURL, strings.NewReader(body))
+		if err != nil {
+			log.Panic(err)
+		}
+
+		// act
+		resp, err := client.Do(req)
+
+		// assert
+		require.NoError(t, err, err)
+		require.Equal(t, expectedStatus, resp.StatusCode, i)
+		if expectedStatus == http.StatusConflict {
+			assert.Equal(t, "SLOT_CONFLICT", readErrorResponse(t, resp).Error.Code)
+		}
+		resp.Body.Close()
+	}
+}
+
+func TestErrorForbidden(t *testing.T) {
+	// arrange
+	client, req, err := makeClientRequest(http.MethodGet, bannerListURL, nil)
+	if err != nil {
+		log.Panic(err)
+	}
+	req.Header.Set(tokenHeaderName, userToken)
+
+	// act
+	resp, err := client.Do(req)
+
+	// assert
+	require.NoError(t, err, err)
+	defer resp.Body.Close()
+
+	require.Equal(t, http.StatusForbidden, resp.StatusCode)
+	assert.Equal(t, "FORBIDDEN", readErrorResponse(t, resp).Error.Code)
+}
@@ -0,0 +1,109 @@
+package tests
+
+import (
+	bannermodels "banner/internal/models/banner"
+	"fmt"
+	"log"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	bannerEventsTableName      = "banner_events"
+	bannerEventCursorTableName = "banner_event_cursor"
+
+	eventsURL = baseURL + "/events"
+)
+
+func TestEventsLogOfBannerChanges(t *testing.T) {
+	// cursors of sinks are truncated with log, so sinks do not wait for old ids
+	db.SetUp(t, bannerTableName, bannerRelationTableName, bannerEventsTableName, bannerEventCursorTableName)
+	defer db.TearDown(bannerTableName, bannerRelationTableName, bannerEventsTableName, bannerEventCursorTableName)
+
+	// arrange
+	id := createBannerByAPI(t, `{"tag_ids": [501], "feature_id": 501, "content": {"title": "a"}, "is_active": true}`)
+	patchBanner(t, id, `{"content": {"title": "b"}}`)
+	deleteBanner(t, id)
+
+	// act
+	var events []bannermodels.BannerChange
+	getJSON(t, eventsURL, &events)
+
+	// assert
+	require.Len(t, events, 3)
+	assert.Equal(t, bannermodels.ChangeCreated, events[0].Type)
+	assert.Equal(t, bannermodels.ChangeUpdated, events[1].Type)
+	assert.Equal(t, bannermodels.ChangeDeleted, events[2].Type)
+
+	for i, event := range events {
+		assert.Equal(t, id, event.BannerID)
+		assert.False(t, event.OccurredAt.IsZero())
+		if i != 0 {
+			assert.Greater(t, event.ID, events[i-1].ID)
+		}
+	}
+
+	assert.Nil(t, events[0].Before)
+	assert.Equal(t, "a", events[1].Before.Content["title"])
+	assert.Equal(t, "b", events[1].After.Content["title"])
+	assert.Nil(t, events[2].After)
+
+	// consumer continues from last event it got
+	var tail []bannermodels.BannerChange
+	getJSON(t, fmt.Sprintf("%s?after=%d&limit=1", eventsURL, events[0].ID), &tail)
+	require.Len(t, tail, 1)
+	assert.Equal(t, events[1].ID, tail[0].ID)
+
+	getJSON(t, fmt.Sprintf("%s?after=%d", eventsURL, events[2].ID), &tail)
+	assert.Empty(t, tail)
+}
+
+func TestEventsOfBanner(t *testing.T) {
+	db.SetUp(t, bannerTableName, bannerRelationTableName, bannerEventsTableName, bannerEventCursorTableName)
+	defer db.TearDown(bannerTableName, bannerRelationTableName, bannerEventsTableName, bannerEventCursorTableName)
+
+	// arrange
+	id := createBannerByAPI(t, `{"tag_ids": [502], "feature_id": 502, "content": {"title": "a"}, "is_active": true}`)
+	other := createBannerByAPI(t, `{"tag_ids": [503], "feature_id": 502, "content": {"title": "a"}, "is_active": true}`)
+	patchBanner(t, id, `{"content": {"title": "b"}}`)
+	patchBanner(t, other, `{"content": {"title": "b"}}`)
+
+	// act
+	var events []bannermodels.BannerChange
+	getJSON(t, fmt.Sprintf("%s?banner_id=%d", eventsURL, id), &events)
+
+	// assert
+	require.Len(t, events, 2)
+	assert.Equal(t, bannermodels.ChangeCreated, events[0].Type)
+	assert.Equal(t, bannermodels.ChangeUpdated, events[1].Type)
+	for _, event := range events {
+		assert.Equal(t, id, event.BannerID)
+	}
+
+	getJSON(t, fmt.Sprintf("%s?banner_id=%d&after=%d", eventsURL, id, events[0].ID), &events)
+	require.Len(t, events, 1)
+	assert.Equal(t, bannermodels.ChangeUpdated, events[0].Type)
+}
+
+func TestEventsBadRequest(t *testing.T) {
+	for _, query := range []string{"after=-1", "after=x", "limit=x", "banner_id=0", "banner_id=x"} {
+		t.Run(query, func(t *testing.T) {
+			resp := webhookRequest(t, http.MethodGet, eventsURL+"?"+query, "")
+			assert.Equal(t, "VALIDATION_FAILED", readErrorResponse(t, resp).Error.Code)
+			assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+		})
+	}
+
+	client, req, err := makeClientRequest(http.MethodGet, eventsURL, nil)
+	if err != nil {
+		log.Panic(err)
+	}
+	req.Header.Set(tokenHeaderName, userToken)
+	resp, err := client.Do(req)
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+}
@@ -0,0 +1,117 @@
+package tests
+
+import (
+	bannermodels "banner/internal/models/banner"
+	"context"
+	"log"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/structpb"
+
+	"banner/internal/grpcapi/bannerpb"
+)
+
+const grpcAddr = "localhost:9090"
+
+func grpcClient(t *testing.T) bannerpb.BannerServiceClient {
+	conn, err := grpc.NewClient(grpcAddr, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	require.NoError(t, err, err)
+	t.Cleanup(func() { conn.Close() })
+
+	return bannerpb.NewBannerServiceClient(conn)
+}
+
+func withToken(token string) context.Context {
+	return metadata.AppendToOutgoingContext(context.Background(), tokenHeaderName, token)
+}
+
+func TestGRPCGetUserBanner(t *testing.T) {
+	db.SetUp(t, bannerTableName, bannerRelationTableName)
+	defer db.TearDown(bannerTableName, bannerRelationTableName)
+
+	// arrange
+	_, err := createBanner(bannermodels.Banner{TagIDs: []int{471}, FeatureID: 471, Content: testContentObj, IsActive: true})
+	if err != nil {
+		log.Panic(err)
+	}
+	client := grpcClient(t)
+
+	// act
+	resp, err := client.GetUserBanner(withToken(userToken), &bannerpb.GetUserBannerRequest{
+		TagId:           471,
+		FeatureId:       471,
+		UseLastRevision: true,
+	})
+
+	// assert
+	require.NoError(t, err, err)
+	assert.Equal(t, testContentObj, resp.GetContent().AsMap())
+}
+
+func TestGRPCBatchGetUserBanners(t *testing.T) {
+	db.SetUp(t, bannerTableName, bannerRelationTableName)
+	defer db.TearDown(bannerTableName, bannerRelationTableName)
+
+	// arrange
+	_, err := createBanner(bannermodels.Banner{TagIDs: []int{472}, FeatureID: 472, Content: testContentObj, IsActive: true})
+	if err != nil {
+		log.Panic(err)
+	}
+	client := grpcClient(t)
+
+	// act
+	resp, err := client.BatchGetUserBanners(withToken(userToken), &bannerpb.BatchGetUserBannersRequest{
+		Slots:           []*bannerpb.Slot{{TagId: 472, FeatureId: 472}, {TagId: 473, FeatureId: 472}},
+		UseLastRevision: true,
+	})
+
+	// assert
+	require.NoError(t, err, err)
+	require.Len(t, resp.GetResults(), 2)
+	assert.True(t, resp.GetResults()[0].GetFound())
+	assert.Equal(t, testContentObj, resp.GetResults()[0].GetContent().AsMap())
+	assert.False(t, resp.GetResults()[1].GetFound())
+}
+
+func TestGRPCAuth(t *testing.T) {
+	client := grpcClient(t)
+
+	// no token
+	_, err := client.GetUserBanner(context.Background(), &bannerpb.GetUserBannerRequest{TagId: 1, FeatureId: 1})
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+
+	// admin method with user token
+	_, err = client.DeleteBanner(withToken(userToken), &bannerpb.DeleteBannerRequest{BannerId: 1})
+	assert.Equal(t, codes.PermissionDenied, status.Code(err))
+}
+
+func TestGRPCCreateAndDeleteBanner(t *testing.T) {
+	db.SetUp(t, bannerTableName, bannerRelationTableName)
+	defer db.TearDown(bannerTableName, bannerRelationTableName)
+
+	// arrange
+	client := grpcClient(t)
+	content, err := structpb.NewStruct(testContentObj)
+	require.NoError(t, err, err)
+
+	req := &bannerpb.CreateBannerRequest{TagIds: []int64{474}, FeatureId: 474, Content: content, IsActive: true}
+
+	// act
+	created, err := client.CreateBanner(withToken(adminToken), req)
+	require.NoError(t, err, err)
+	_, conflictErr := client.CreateBanner(withToken(adminToken), req)
+	_, deleteErr := client.DeleteBanner(withToken(adminToken), &bannerpb.DeleteBannerRequest{BannerId: created.GetBannerId()})
+	_, notFoundErr := client.DeleteBanner(withToken(adminToken), &bannerpb.DeleteBannerRequest{BannerId: created.GetBannerId()})
+
+	// assert
+	assert.Equal(t, codes.AlreadyExists, status.Code(conflictErr))
+	assert.NoError(t, deleteErr)
+	assert.Equal(t, codes.NotFound, status.Code(notFoundErr))
+}
@@ -0,0 +1,68 @@
+package tests
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"testing"
+
+	"banner/internal/health"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	livenessURL  = baseURL + "/healthz"
+	readinessURL = baseURL + "/readyz"
+)
+
+func TestLiveness(t *testing.T) {
+	// act, no token
+	resp, err := http.Get(livenessURL)
+
+	// assert
+	require.NoError(t, err, err)
+	defer resp.Body.Close()
+
+	var report health.Report
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&report))
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, health.StatusOK, report.Status)
+}
+
+func TestReadiness(t *testing.T) {
+	// act, no token
+	resp, err := http.Get(readinessURL)
+
+	// assert
+	require.NoError(t, err, err)
+	defer resp.Body.Close()
+
+	var report health.Report
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&report))
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, health.StatusOK, report.Status)
+	for _, name := range []string{"postgres", "redis", "schema"} {
+		require.Contains(t, report.Checks, name)
+		assert.Equal(t, health.StatusOK, report.Checks[name].Status, name)
+	}
+}
+
+func TestProbesNotInMetrics(t *testing.T) {
+	// arrange
+	resp, err := http.Get(readinessURL)
+	require.NoError(t, err, err)
+	resp.Body.Close()
+
+	// act
+	resp, err = http.Get(metricsURL)
+
+	// assert
+	require.NoError(t, err, err)
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err, err)
+	assert.NotContains(t, string(body), health.ReadinessPath)
+}
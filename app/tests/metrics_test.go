@@ -0,0 +1,40 @@
+package tests
+
+import (
+	"io"
+	"log"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const metricsURL = "http://localhost:9100/metrics"
+
+func TestMetrics(t *testing.T) {
+	// arrange
+	client, req, err := makeClientRequest(http.MethodGet, bannerListURL, nil)
+	if err != nil {
+		log.Panic(err)
+	}
+
+	resp, err := client.Do(req)
+	require.NoError(t, err, err)
+	resp.Body.Close()
+
+	// act
+	resp, err = http.Get(metricsURL)
+
+	// assert
+	require.NoError(t, err, err)
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err, err)
+
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Contains(t, string(body), `banner_http_requests_total{code="200",method="GET",route="/banner"}`)
+	assert.Contains(t, string(body), `banner_repo_query_duration_seconds_count{statement="banner_list"}`)
+	assert.Contains(t, string(body), "banner_pgxpool_total_conns")
+}
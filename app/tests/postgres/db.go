@@ -33,7 +33,7 @@ func (d *TDB) TearDown(tableName ...string) {
 
 func (d *TDB) truncateTable(ctx context.Context, tableName ...string) {
 
-	q := fmt.Sprintf("TRUNCATE table %s RESTART IDENTITY", strings.Join(tableName, ","))
+	q := fmt.Sprintf("TRUNCATE table %s RESTART IDENTITY CASCADE", strings.Join(tableName, ","))
 	if _, err := d.DB.Exec(ctx, q); err != nil {
 		panic(err)
 	}
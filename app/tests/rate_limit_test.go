@@ -0,0 +1,71 @@
+package tests
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// reads are limited to burst 2 and writes to burst 1 with RATE_LIMIT_OVERRIDES
+const carolToken = "carol_token"
+
+func TestRateLimitThrottles(t *testing.T) {
+	// arrange, drain bucket of carol
+	for i := 0; i < 2; i++ {
+		resp := draftRequest(t, http.MethodGet, bannerListURL, carolToken, nil)
+		resp.Body.Close()
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.Equal(t, "2", resp.Header.Get("RateLimit-Limit"))
+	}
+
+	// act
+	resp := draftRequest(t, http.MethodGet, bannerListURL, carolToken, nil)
+	defer resp.Body.Close()
+
+	// assert
+	require.Equal(t, http.StatusTooManyRequests, resp.StatusCode)
+	assert.Equal(t, "0", resp.Header.Get("RateLimit-Remaining"))
+
+	retryAfter, err := strconv.Atoi(resp.Header.Get("Retry-After"))
+	require.NoError(t, err, err)
+	assert.GreaterOrEqual(t, retryAfter, 1)
+
+	errResp := readErrorResponse(t, resp)
+	assert.Equal(t, "RATE_LIMITED", errResp.Error.Code)
+}
+
+func TestRateLimitPerIdentity(t *testing.T) {
+	// arrange, carol is throttled
+	for i := 0; i < 3; i++ {
+		resp := draftRequest(t, http.MethodGet, bannerListURL, carolToken, nil)
+		resp.Body.Close()
+	}
+
+	// act
+	resp := draftRequest(t, http.MethodGet, bannerListURL, aliceToken, nil)
+	defer resp.Body.Close()
+
+	// assert
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.NotEmpty(t, resp.Header.Get("RateLimit-Remaining"))
+}
+
+func TestRateLimitReadsAndWritesSeparately(t *testing.T) {
+	// arrange, reads of carol are throttled
+	for i := 0; i < 3; i++ {
+		resp := draftRequest(t, http.MethodGet, bannerListURL, carolToken, nil)
+		resp.Body.Close()
+	}
+
+	// act
+	resp := draftRequest(t, http.MethodDelete, fmt.Sprintf(bannerDeleteURL, 1<<30), carolToken, nil)
+	defer resp.Body.Close()
+
+	// assert
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+	assert.Equal(t, "1", resp.Header.Get("RateLimit-Limit"))
+}
@@ -0,0 +1,51 @@
+package tests
+
+import (
+	"log"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const requestIDHeaderName = "X-Request-ID"
+
+func TestRequestIDEchoed(t *testing.T) {
+	// arrange
+	client, req, err := makeClientRequest(http.MethodGet, bannerListURL, nil)
+	if err != nil {
+		log.Panic(err)
+	}
+	req.Header.Set(requestIDHeaderName, "test-request-id")
+
+	// act
+	resp, err := client.Do(req)
+
+	// assert
+	require.NoError(t, err, err)
+	defer resp.Body.Close()
+
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "test-request-id", resp.Header.Get(requestIDHeaderName))
+}
+
+func TestRequestIDGenerated(t *testing.T) {
+	// arrange
+	client := makeClient()
+	req, err := http.NewRequest(http.MethodGet, bannerListURL, nil)
+	if err != nil {
+		log.Panic(err)
+	}
+
+	// act
+	resp, err := client.Do(req)
+
+	// assert
+	require.NoError(t, err, err)
+	defer resp.Body.Close()
+
+	// rejected by auth, but still has id
+	require.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+	assert.NotEmpty(t, resp.Header.Get(requestIDHeaderName))
+}
@@ -0,0 +1,237 @@
+package tests
+
+import (
+	bannermodels "banner/internal/models/banner"
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const bannerStreamURL = baseURL + "/user_banner/stream"
+
+type sseEvent struct {
+	ID    string
+	Event string
+	Data  map[string]interface{}
+}
+
+// events of stream are read in background until response body is closed
+func openStream(t *testing.T, query string, token string, lastEventID string) (*http.Response, <-chan sseEvent) {
+	t.Helper()
+
+	client, req, err := makeClientRequest(http.MethodGet, bannerStreamURL+"?"+query, nil)
+	if err != nil {
+		log.Panic(err)
+	}
+	req.Header.Set(tokenHeaderName, token)
+	if lastEventID != "" {
+		req.Header.Set("Last-Event-ID", lastEventID)
+	}
+
+	resp, err := client.Do(req)
+	require.NoError(t, err, err)
+
+	events := make(chan sseEvent, 16)
+	go func() {
+		defer close(events)
+
+		var event sseEvent
+		scanner := bufio.NewScanner(resp.Body)
+		for scanner.Scan() {
+			line := scanner.Text()
+			switch {
+			case line == "":
+				if event.Event != "" {
+					events <- event
+				}
+				event = sseEvent{}
+			case strings.HasPrefix(line, "id: "):
+				event.ID = strings.TrimPrefix(line, "id: ")
+			case strings.HasPrefix(line, "event: "):
+				event.Event = strings.TrimPrefix(line, "event: ")
+			case strings.HasPrefix(line, "data: "):
+				json.Unmarshal([]byte(strings.TrimPrefix(line, "data: ")), &event.Data)
+			}
+		}
+	}()
+
+	return resp, events
+}
+
+func nextEvent(t *testing.T, events <-chan sseEvent) sseEvent {
+	t.Helper()
+
+	select {
+	case event, ok := <-events:
+		require.True(t, ok, "stream is closed")
+		return event
+	case <-time.After(5 * time.Second):
+		require.FailNow(t, "no event in 5s")
+	}
+	return sseEvent{}
+}
+
+func noEvent(t *testing.T, events <-chan sseEvent, wait time.Duration) {
+	t.Helper()
+
+	select {
+	case event := <-events:
+		assert.Failf(t, "unexpected event", "%+v", event)
+	case <-time.After(wait):
+	}
+}
+
+func patchBanner(t *testing.T, id int, body string) {
+	t.Helper()
+
+	client, req, err := makeClientRequest(http.MethodPatch, fmt.Sprintf(bannerUpdateURL, id), bytes.NewBufferString(body))
+	if err != nil {
+		log.Panic(err)
+	}
+	resp, err := client.Do(req)
+	require.NoError(t, err, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+}
+
+func TestStreamPushesCurrentAndChangedBanner(t *testing.T) {
+	db.SetUp(t, bannerTableName, bannerRelationTableName)
+	defer db.TearDown(bannerTableName, bannerRelationTableName)
+
+	// arrange
+	banner, err := createBanner(bannermodels.Banner{TagIDs: []int{481}, FeatureID: 481, Content: testContentObj, IsActive: true})
+	if err != nil {
+		log.Panic(err)
+	}
+
+	resp, events := openStream(t, "tag_id=481&feature_id=481", userToken, "")
+	defer resp.Body.Close()
+
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "text/event-stream", resp.Header.Get("Content-Type"))
+
+	// act, assert
+	event := nextEvent(t, events)
+	assert.Equal(t, "banner", event.Event)
+	assert.NotEmpty(t, event.ID)
+	assert.Equal(t, testContentObj, event.Data["content"])
+
+	patchBanner(t, banner.ID, `{"content": {"title": "new"}}`)
+
+	event = nextEvent(t, events)
+	assert.Equal(t, "banner", event.Event)
+	assert.Equal(t, map[string]interface{}{"title": "new"}, event.Data["content"])
+
+	// inactive banner is hidden from users
+	patchBanner(t, banner.ID, `{"is_active": false}`)
+
+	event = nextEvent(t, events)
+	assert.Equal(t, "removed", event.Event)
+	assert.Equal(t, float64(481), event.Data["feature_id"])
+}
+
+func TestStreamAdminSeesInactiveBanner(t *testing.T) {
+	db.SetUp(t, bannerTableName, bannerRelationTableName)
+	defer db.TearDown(bannerTableName, bannerRelationTableName)
+
+	// arrange
+	_, err := createBanner(bannermodels.Banner{TagIDs: []int{482}, FeatureID: 482, Content: testContentObj, IsActive: false})
+	if err != nil {
+		log.Panic(err)
+	}
+
+	// act
+	userResp, userEvents := openStream(t, "tag_id=482&feature_id=482", userToken, "")
+	defer userResp.Body.Close()
+	adminResp, adminEvents := openStream(t, "tag_id=482&feature_id=482", adminToken, "")
+	defer adminResp.Body.Close()
+
+	// assert
+	assert.Equal(t, "removed", nextEvent(t, userEvents).Event)
+
+	event := nextEvent(t, adminEvents)
+	assert.Equal(t, "banner", event.Event)
+	assert.Equal(t, false, event.Data["is_active"])
+}
+
+func TestStreamMultipleFeatures(t *testing.T) {
+	db.SetUp(t, bannerTableName, bannerRelationTableName)
+	defer db.TearDown(bannerTableName, bannerRelationTableName)
+
+	// arrange
+	banners, err := createBunners([]bannermodels.Banner{
+		{TagIDs: []int{483}, FeatureID: 483, Content: testContentObj, IsActive: true},
+		{TagIDs: []int{483}, FeatureID: 484, Content: testContentObj, IsActive: true},
+	})
+	if err != nil {
+		log.Panic(err)
+	}
+
+	resp, events := openStream(t, "tag_id=483&feature_id=483&feature_id=484&feature_id=485", userToken, "")
+	defer resp.Body.Close()
+
+	// act
+	var initial []string
+	for range 3 {
+		event := nextEvent(t, events)
+		initial = append(initial, fmt.Sprintf("%s:%v", event.Event, event.Data["feature_id"]))
+	}
+
+	deleteBanner(t, banners[1].ID)
+
+	// assert
+	assert.Equal(t, []string{"banner:483", "banner:484", "removed:485"}, initial)
+
+	event := nextEvent(t, events)
+	assert.Equal(t, "removed", event.Event)
+	assert.Equal(t, float64(484), event.Data["feature_id"])
+}
+
+func TestStreamResumeWithLastEventID(t *testing.T) {
+	db.SetUp(t, bannerTableName, bannerRelationTableName)
+	defer db.TearDown(bannerTableName, bannerRelationTableName)
+
+	// arrange
+	banner, err := createBanner(bannermodels.Banner{TagIDs: []int{486}, FeatureID: 486, Content: testContentObj, IsActive: true})
+	if err != nil {
+		log.Panic(err)
+	}
+
+	resp, events := openStream(t, "tag_id=486&feature_id=486", userToken, "")
+	lastEventID := nextEvent(t, events).ID
+	resp.Body.Close()
+
+	// act, assert
+	resp, events = openStream(t, "tag_id=486&feature_id=486", userToken, lastEventID)
+	noEvent(t, events, 500*time.Millisecond)
+	resp.Body.Close()
+
+	patchBanner(t, banner.ID, `{"content": {"title": "missed"}}`)
+
+	resp, events = openStream(t, "tag_id=486&feature_id=486", userToken, lastEventID)
+	defer resp.Body.Close()
+
+	event := nextEvent(t, events)
+	assert.Equal(t, map[string]interface{}{"title": "missed"}, event.Data["content"])
+	assert.NotEqual(t, lastEventID, event.ID)
+}
+
+func TestStreamBadFeatures(t *testing.T) {
+	for _, query := range []string{"tag_id=1", "tag_id=1&feature_id=x"} {
+		t.Run(query, func(t *testing.T) {
+			resp, _ := openStream(t, query, userToken, "")
+			defer resp.Body.Close()
+
+			assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+		})
+	}
+}
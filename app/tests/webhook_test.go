@@ -0,0 +1,291 @@
+package tests
+
+import (
+	bannermodels "banner/internal/models/banner"
+	webhookmodels "banner/internal/models/webhook"
+	"banner/internal/repo"
+	"banner/internal/service"
+	"banner/internal/webhook"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	webhookTableName = "webhook"
+
+	webhooksURL           = baseURL + "/webhooks"
+	webhookURL            = baseURL + "/webhooks/%d"
+	webhookDeliveriesURL  = baseURL + "/webhooks/%d/deliveries"
+	webhookRedeliverURL   = baseURL + "/webhooks/%d/deliveries/%d/redeliver"
+	allWebhookDeliveryURL = baseURL + "/webhooks/deliveries"
+
+	// nothing listens there, deliveries of container dispatcher fail
+	unreachableWebhookURL = "http://127.0.0.1:1/hook"
+)
+
+type noopDeliveryObserver struct{}
+
+func (noopDeliveryObserver) ObserveWebhookDelivery(string) {}
+
+func webhookRequest(t *testing.T, method string, url string, body string) *http.Response {
+	t.Helper()
+
+	client, req, err := makeClientRequest(method, url, bytes.NewBufferString(body))
+	if err != nil {
+		log.Panic(err)
+	}
+	resp, err := client.Do(req)
+	require.NoError(t, err)
+	return resp
+}
+
+func createWebhook(t *testing.T, body string) webhookmodels.Webhook {
+	t.Helper()
+
+	resp := webhookRequest(t, http.MethodPost, webhooksURL, body)
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusCreated, resp.StatusCode)
+
+	var hook webhookmodels.Webhook
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&hook))
+	return hook
+}
+
+func createBannerByAPI(t *testing.T, body string) int {
+	t.Helper()
+
+	resp := webhookRequest(t, http.MethodPost, bannerCreateURL, body)
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusCreated, resp.StatusCode)
+
+	var created BannerCreateResponse
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&created))
+	return created.BannerID
+}
+
+// deliveries are written by event publisher of service shortly after change
+func waitDeliveries(t *testing.T, url string, count int) []webhookmodels.Delivery {
+	t.Helper()
+
+	var deliveries []webhookmodels.Delivery
+	require.Eventually(t, func() bool {
+		getJSON(t, url, &deliveries)
+		return len(deliveries) >= count
+	}, 5*time.Second, 50*time.Millisecond)
+	require.Len(t, deliveries, count)
+
+	return deliveries
+}
+
+func TestWebhookCreateListDelete(t *testing.T) {
+	db.SetUp(t, webhookTableName)
+	defer db.TearDown(webhookTableName)
+
+	// act
+	hook := createWebhook(t, `{"url": "https://example.com/hook", "feature_id": 7, "events": ["banner.updated"]}`)
+
+	var hooks []webhookmodels.Webhook
+	getJSON(t, webhooksURL, &hooks)
+
+	// assert
+	assert.Len(t, hook.Secret, 64)
+	assert.Equal(t, "admin", hook.CreatedBy)
+
+	require.Len(t, hooks, 1)
+	assert.Equal(t, hook.ID, hooks[0].ID)
+	assert.Empty(t, hooks[0].Secret)
+	assert.Equal(t, 7, *hooks[0].FeatureID)
+	assert.Nil(t, hooks[0].TagID)
+	assert.Equal(t, []string{"banner.updated"}, hooks[0].Events)
+
+	resp := webhookRequest(t, http.MethodDelete, fmt.Sprintf(webhookURL, hook.ID), "")
+	resp.Body.Close()
+	assert.Equal(t, http.StatusNoContent, resp.StatusCode)
+
+	resp = webhookRequest(t, http.MethodDelete, fmt.Sprintf(webhookURL, hook.ID), "")
+	assert.Equal(t, "WEBHOOK_NOT_FOUND", readErrorResponse(t, resp).Error.Code)
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+}
+
+func TestWebhookBadRequest(t *testing.T) {
+	for name, body := range map[string]string{
+		"relative url": `{"url": "/hook"}`,
+		"ftp url":      `{"url": "ftp://example.com/hook"}`,
+		"bad event":    `{"url": "https://example.com/hook", "events": ["banner.viewed"]}`,
+		"short secret": `{"url": "https://example.com/hook", "secret": "short"}`,
+	} {
+		t.Run(name, func(t *testing.T) {
+			resp := webhookRequest(t, http.MethodPost, webhooksURL, body)
+			assert.Equal(t, "VALIDATION_FAILED", readErrorResponse(t, resp).Error.Code)
+			assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+		})
+	}
+
+	resp := webhookRequest(t, http.MethodGet, allWebhookDeliveryURL+"?status=lost", "")
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	resp.Body.Close()
+
+	client, req, err := makeClientRequest(http.MethodGet, webhooksURL, nil)
+	if err != nil {
+		log.Panic(err)
+	}
+	req.Header.Set(tokenHeaderName, userToken)
+	resp, err = client.Do(req)
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+}
+
+func TestWebhookDeliveriesOfMatchingChanges(t *testing.T) {
+	db.SetUp(t, bannerTableName, bannerRelationTableName, webhookTableName)
+	defer db.TearDown(bannerTableName, bannerRelationTableName, webhookTableName)
+
+	// arrange
+	tag := createWebhook(t, fmt.Sprintf(`{"url": %q, "tag_id": 491}`, unreachableWebhookURL))
+	feature := createWebhook(t, fmt.Sprintf(`{"url": %q, "feature_id": 491}`, unreachableWebhookURL))
+	deletes := createWebhook(t, fmt.Sprintf(`{"url": %q, "tag_id": 491, "events": ["banner.deleted"]}`, unreachableWebhookURL))
+
+	// act
+	id := createBannerByAPI(t, `{"tag_ids": [491], "feature_id": 492, "content": {"title": "a"}, "is_active": true}`)
+	patchBanner(t, id, `{"feature_id": 491}`)
+	deleteBanner(t, id)
+
+	// assert
+	deliveries := waitDeliveries(t, fmt.Sprintf(webhookDeliveriesURL, tag.ID), 3)
+	assert.Equal(t, "banner.deleted", deliveries[0].Event)
+	assert.Equal(t, "banner.updated", deliveries[1].Event)
+	assert.Equal(t, "banner.created", deliveries[2].Event)
+
+	var change bannermodels.BannerChange
+	require.NoError(t, json.Unmarshal(deliveries[1].Payload, &change))
+	assert.Equal(t, *deliveries[1].EventID, change.ID)
+	assert.Equal(t, id, change.BannerID)
+	assert.Equal(t, 492, change.Before.FeatureID)
+	assert.Equal(t, 491, change.After.FeatureID)
+
+	// banner moved to subscribed feature and was deleted from it
+	deliveries = waitDeliveries(t, fmt.Sprintf(webhookDeliveriesURL, feature.ID), 2)
+	assert.Equal(t, "banner.deleted", deliveries[0].Event)
+	assert.Equal(t, "banner.updated", deliveries[1].Event)
+
+	deliveries = waitDeliveries(t, fmt.Sprintf(webhookDeliveriesURL, deletes.ID), 1)
+	assert.Equal(t, "banner.deleted", deliveries[0].Event)
+
+	getJSON(t, allWebhookDeliveryURL+"?limit=2", &deliveries)
+	assert.Len(t, deliveries, 2)
+}
+
+func TestWebhookRedeliver(t *testing.T) {
+	db.SetUp(t, bannerTableName, bannerRelationTableName, webhookTableName)
+	defer db.TearDown(bannerTableName, bannerRelationTableName, webhookTableName)
+
+	// arrange
+	hook := createWebhook(t, fmt.Sprintf(`{"url": %q, "tag_id": 493}`, unreachableWebhookURL))
+	createBannerByAPI(t, `{"tag_ids": [493], "feature_id": 493, "content": {"title": "a"}, "is_active": true}`)
+
+	deliveries := waitDeliveries(t, fmt.Sprintf(webhookDeliveriesURL, hook.ID), 1)
+
+	_, err := db.DB.Exec(
+		context.Background(),
+		`UPDATE webhook_delivery SET status='dead', attempts=10 WHERE id=$1;`,
+		deliveries[0].ID,
+	)
+	require.NoError(t, err)
+
+	var dead []webhookmodels.Delivery
+	getJSON(t, allWebhookDeliveryURL+"?status=dead", &dead)
+	require.Len(t, dead, 1)
+
+	// act
+	resp := webhookRequest(t, http.MethodPost, fmt.Sprintf(webhookRedeliverURL, hook.ID, deliveries[0].ID), "")
+	defer resp.Body.Close()
+
+	// assert
+	require.Equal(t, http.StatusAccepted, resp.StatusCode)
+
+	var delivery webhookmodels.Delivery
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&delivery))
+	assert.Equal(t, webhookmodels.StatusPending, delivery.Status)
+	assert.Equal(t, 0, delivery.Attempts)
+
+	resp = webhookRequest(t, http.MethodPost, fmt.Sprintf(webhookRedeliverURL, hook.ID, deliveries[0].ID+100), "")
+	assert.Equal(t, "DELIVERY_NOT_FOUND", readErrorResponse(t, resp).Error.Code)
+}
+
+func TestWebhookDispatcherSendsSignedDelivery(t *testing.T) {
+	db.SetUp(t, bannerTableName, bannerRelationTableName, webhookTableName)
+	defer db.TearDown(bannerTableName, bannerRelationTableName, webhookTableName)
+
+	// arrange
+	type received struct {
+		header http.Header
+		body   []byte
+	}
+	var mu sync.Mutex
+	var requests []received
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		mu.Lock()
+		requests = append(requests, received{header: r.Header, body: body})
+		mu.Unlock()
+	}))
+	defer receiver.Close()
+
+	secret := "0123456789abcdef0123456789abcdef"
+	hook := createWebhook(t, fmt.Sprintf(`{"url": %q, "secret": %q, "tag_id": 494}`, receiver.URL, secret))
+	id := createBannerByAPI(t, `{"tag_ids": [494], "feature_id": 494, "content": {"title": "a"}, "is_active": true}`)
+
+	// container dispatcher may take delivery first, it fails and delivery is retried after backoff
+	dispatcher := service.NewWebhookDispatcher(
+		repo.NewBannerRepo(db.DB, noopQueryObserver{}),
+		webhook.NewSender(time.Second),
+		noopDeliveryObserver{},
+		service.WebhookDispatcherConfig{
+			PollInterval: 50 * time.Millisecond,
+			BatchSize:    10,
+			Timeout:      time.Second,
+			Retry:        webhook.RetryPolicy{MaxAttempts: 10, MinBackoff: time.Second, MaxBackoff: time.Second},
+			Retention:    time.Hour,
+		},
+	)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go dispatcher.Run(ctx)
+
+	// act
+	require.Eventually(t, func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return len(requests) != 0
+	}, 20*time.Second, 50*time.Millisecond)
+
+	// assert
+	mu.Lock()
+	req := requests[0]
+	mu.Unlock()
+
+	var timestamp int64
+	_, err := fmt.Sscan(req.header.Get(webhook.HeaderTimestamp), &timestamp)
+	require.NoError(t, err)
+	assert.Equal(t, webhook.Sign(secret, timestamp, req.body), req.header.Get(webhook.HeaderSignature))
+	assert.Equal(t, "banner.created", req.header.Get(webhook.HeaderEvent))
+
+	var change bannermodels.BannerChange
+	require.NoError(t, json.Unmarshal(req.body, &change))
+	assert.Equal(t, id, change.BannerID)
+	assert.Nil(t, change.Before)
+
+	waitDeliveries(t, fmt.Sprintf(webhookDeliveriesURL, hook.ID)+"?status=delivered", 1)
+}